@@ -0,0 +1,377 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConfigureAlertmanagerName is the name of the singleton ConfigureAlertmanager resource.
+const ConfigureAlertmanagerName = "cluster"
+
+// EnvironmentProfile selects environment-specific rendering of the Alertmanager config.
+// +kubebuilder:validation:Enum=Standard;FedRAMP
+type EnvironmentProfile string
+
+const (
+	// ProfileStandard is the default profile for OSD and ROSA clusters.
+	ProfileStandard EnvironmentProfile = "Standard"
+	// ProfileFedRAMP removes cluster-identifying details from notifications.
+	ProfileFedRAMP EnvironmentProfile = "FedRAMP"
+)
+
+// DriftPolicy decides what the operator does when alertmanager-main was edited outside the operator.
+// +kubebuilder:validation:Enum=Overwrite;PreserveAndAlert
+type DriftPolicy string
+
+const (
+	// DriftPolicyOverwrite replaces manual edits with the rendered config.
+	DriftPolicyOverwrite DriftPolicy = "Overwrite"
+	// DriftPolicyPreserveAndAlert leaves manual edits in place and reports them until they are reverted.
+	DriftPolicyPreserveAndAlert DriftPolicy = "PreserveAndAlert"
+)
+
+// OutputMode decides where the operator writes the rendered Alertmanager config.
+// +kubebuilder:validation:Enum=Secret;AlertmanagerConfig
+type OutputMode string
+
+const (
+	// OutputModeSecret writes the rendered config to the alertmanager-main secret.
+	OutputModeSecret OutputMode = "Secret"
+	// OutputModeAlertmanagerConfig writes the rendered routes and receivers to an
+	// AlertmanagerConfig resource selected by the Alertmanager, leaving alertmanager-main
+	// to cluster-monitoring-operator.
+	OutputModeAlertmanagerConfig OutputMode = "AlertmanagerConfig"
+)
+
+// ClusterType is the kind of cluster the operator runs on.
+// +kubebuilder:validation:Enum=Standard;ManagementCluster
+type ClusterType string
+
+const (
+	// ClusterTypeStandard is an OSD, ROSA Classic or hosted cluster.
+	ClusterTypeStandard ClusterType = "Standard"
+	// ClusterTypeManagementCluster is a HyperShift management cluster, which also routes
+	// alerts from its additional namespaces and hosted control planes.
+	ClusterTypeManagementCluster ClusterType = "ManagementCluster"
+)
+
+// NamespaceSourceState is how a source of routed namespaces was used.
+// +kubebuilder:validation:Enum=Loaded;Defaulted;Empty;Skipped
+type NamespaceSourceState string
+
+const (
+	// NamespaceSourceLoaded means the namespaces were read from the source.
+	NamespaceSourceLoaded NamespaceSourceState = "Loaded"
+	// NamespaceSourceDefaulted means the source was missing, couldn't be parsed or had no
+	// valid entries, so its default namespaces were routed instead.
+	NamespaceSourceDefaulted NamespaceSourceState = "Defaulted"
+	// NamespaceSourceEmpty means the source listed no namespaces and has no defaults.
+	NamespaceSourceEmpty NamespaceSourceState = "Empty"
+	// NamespaceSourceSkipped means the source doesn't apply to this cluster type.
+	NamespaceSourceSkipped NamespaceSourceState = "Skipped"
+)
+
+// Condition types reported on ConfigureAlertmanager.
+const (
+	// ConditionReady indicates the config was applied and the cluster is ready for paging integrations.
+	ConditionReady = "Ready"
+	// ConditionConfigValid indicates whether the rendered config passed Alertmanager validation.
+	ConditionConfigValid = "ConfigValid"
+	// ConditionConfigApplied indicates whether the rendered config was written to alertmanager-main.
+	ConditionConfigApplied = "ConfigApplied"
+	// ConditionDegraded indicates the most recent reconcile encountered an error.
+	ConditionDegraded = "Degraded"
+)
+
+// ConfigureAlertmanagerSpec defines the desired behaviour of configure-alertmanager-operator.
+// Any field left unset falls back to the operator's environment variable or built-in default.
+type ConfigureAlertmanagerSpec struct {
+	// Profile selects environment-specific rendering. Defaults to FedRAMP when the
+	// FEDRAMP environment variable is true, otherwise Standard.
+	// +optional
+	Profile EnvironmentProfile `json:"profile,omitempty"`
+
+	// Readiness tunes how the operator decides the cluster is ready for paging.
+	// +optional
+	Readiness ReadinessSpec `json:"readiness,omitempty"`
+
+	// ManagedNamespace is the namespace holding the integration secrets, namespace
+	// ConfigMaps and the alertmanager-main secret. Defaults to openshift-monitoring.
+	// Changes take effect when the operator restarts.
+	// +optional
+	ManagedNamespace string `json:"managedNamespace,omitempty"`
+
+	// Features enables or disables optional operator behaviour.
+	// +optional
+	Features FeatureToggles `json:"features,omitempty"`
+
+	// DriftPolicy decides what happens when alertmanager-main was edited outside the
+	// operator. Defaults to Overwrite. Management can also be paused by annotating
+	// alertmanager-main with configure-alertmanager-operator.openshift.io/paused.
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// AlertmanagerConfigs selects prometheus-operator AlertmanagerConfig resources whose
+	// routes and receivers are added to the managed config. None are selected by default.
+	// +optional
+	AlertmanagerConfigs AlertmanagerConfigSelection `json:"alertmanagerConfigs,omitempty"`
+
+	// OutputMode decides where the rendered config is written. Defaults to Secret.
+	// AlertmanagerConfig requires the Alertmanager to select AlertmanagerConfigs in the
+	// managed namespace without restricting them to alerts from that namespace.
+	// +optional
+	OutputMode OutputMode `json:"outputMode,omitempty"`
+
+	// ClusterType overrides detection of whether this is a HyperShift management cluster,
+	// which is otherwise based on the Infrastructure and ClusterVersion resources.
+	// +optional
+	ClusterType ClusterType `json:"clusterType,omitempty"`
+
+	// UserWorkload configures management of the user-workload Alertmanager.
+	// +optional
+	UserWorkload UserWorkloadSpec `json:"userWorkload,omitempty"`
+
+	// AutomationRoutes send alerts with matching labels to automation services, such as
+	// auto-remediation or ticketing, ahead of the paging routes. The built-in cad route,
+	// for alerts labelled route_to_cad=true, is changed by listing a route named cad.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	AutomationRoutes []AutomationRoute `json:"automationRoutes,omitempty"`
+}
+
+// AutomationRoute sends alerts with matching labels to an automation service whose
+// PagerDuty routing key or webhook URL is read from a Secret in the managed namespace.
+type AutomationRoute struct {
+	// Name identifies the route. Alerts are sent to the automation-<name> receiver, or
+	// cad-pagerduty for the cad route.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// MatchLabels are the labels an alert must have, with these exact values, to be
+	// routed. Required unless the route changes a built-in one.
+	// +optional
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+
+	// SecretName is the Secret holding the PagerDuty routing key under PAGERDUTY_KEY, the
+	// webhook URL under WEBHOOK_URL, or both. The route is left out until the Secret
+	// has either. Required unless the route changes a built-in one.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Continue also sends matching alerts on to the routes after this one, so they page
+	// as usual. Defaults to false: matching alerts only go to the automation service.
+	// +optional
+	Continue *bool `json:"continue,omitempty"`
+}
+
+// UserWorkloadSpec configures management of the alertmanager-user-workload secret in
+// openshift-user-workload-monitoring.
+type UserWorkloadSpec struct {
+	// Enabled renders the user-workload Alertmanager config from the integration secrets
+	// and managed-namespaces ConfigMap in openshift-user-workload-monitoring. Defaults to
+	// false. Changes take effect when the operator restarts.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// AlertmanagerConfigSelection selects AlertmanagerConfig resources to aggregate. A resource
+// is selected if it is in one of the namespaces or matches the selector.
+type AlertmanagerConfigSelection struct {
+	// Namespaces whose AlertmanagerConfigs are aggregated. AlertmanagerConfigs are only
+	// cached in these namespaces, so changes take effect when the operator restarts.
+	// +listType=set
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Selector aggregates AlertmanagerConfigs with matching labels in any namespace.
+	// Setting or removing it takes effect when the operator restarts.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// ReadinessSpec tunes the cluster readiness engine.
+type ReadinessSpec struct {
+	// MaxClusterAgeMinutes is the cluster age after which it is declared ready regardless
+	// of ClusterOperator state. Defaults to MAX_CLUSTER_AGE_MINUTES or 90.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClusterAgeMinutes *int32 `json:"maxClusterAgeMinutes,omitempty"`
+
+	// RequeueIntervalSeconds is how often readiness is re-evaluated while the cluster
+	// is not ready. Defaults to 30.
+	// +kubebuilder:validation:Minimum=5
+	// +optional
+	RequeueIntervalSeconds *int32 `json:"requeueIntervalSeconds,omitempty"`
+
+	// SettlingWindowMinutes bounds how long paging is withheld after the cluster resumes
+	// from hibernation. Defaults to SETTLING_WINDOW_MINUTES or 30.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SettlingWindowMinutes *int32 `json:"settlingWindowMinutes,omitempty"`
+}
+
+// FeatureToggles enables or disables optional operator behaviour.
+type FeatureToggles struct {
+	// ResumeSettling withholds paging while the cluster settles after resuming from
+	// hibernation. Defaults to true.
+	// +optional
+	ResumeSettling *bool `json:"resumeSettling,omitempty"`
+
+	// CompactRoutes merges routes that differ only in the value of one label, such as
+	// the routes for each managed namespace, into a single route wherever that doesn't
+	// change where alerts are routed. Defaults to false.
+	// +optional
+	CompactRoutes *bool `json:"compactRoutes,omitempty"`
+
+	// RoutingCoverage periodically routes the alerts of all PrometheusRules through
+	// alertmanager-main and reports those falling to the default null receiver and the
+	// silences matching no alert, in the operator log and metrics. Defaults to false.
+	// +optional
+	RoutingCoverage *bool `json:"routingCoverage,omitempty"`
+}
+
+// ConfigureAlertmanagerStatus reports what the operator observed and applied.
+type ConfigureAlertmanagerStatus struct {
+	// ObservedGeneration is the most recent generation observed by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ManagedConfigStatus reports the outcome for alertmanager-main.
+	ManagedConfigStatus `json:",inline"`
+
+	// ObservedInputs are the effective settings and source objects used by the most recent reconcile.
+	// +optional
+	ObservedInputs ObservedInputs `json:"observedInputs,omitempty"`
+
+	// UserWorkload reports the outcome for alertmanager-user-workload, if it is managed.
+	// +optional
+	UserWorkload *ManagedConfigStatus `json:"userWorkload,omitempty"`
+}
+
+// ManagedConfigStatus reports the outcome of rendering and writing one Alertmanager config.
+type ManagedConfigStatus struct {
+	// Conditions describe the outcome of the most recent reconcile.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastAppliedHash is the SHA-256 of the alertmanager.yaml last written.
+	// +optional
+	LastAppliedHash string `json:"lastAppliedHash,omitempty"`
+
+	// RenderedHash is the SHA-256 of the alertmanager.yaml rendered by the most recent
+	// reconcile, whether or not it was written.
+	// +optional
+	RenderedHash string `json:"renderedHash,omitempty"`
+
+	// LastWriteTime is when a changed config was last written.
+	// +optional
+	LastWriteTime *metav1.Time `json:"lastWriteTime,omitempty"`
+
+	// ActiveIntegrations lists the receivers configured by the most recent reconcile,
+	// e.g. PagerDuty, GoAlert or DeadMansSnitch.
+	// +listType=set
+	// +optional
+	ActiveIntegrations []string `json:"activeIntegrations,omitempty"`
+
+	// LastError is the error encountered by the most recent reconcile, if any.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ObservedInputs are the effective settings, after defaulting, and the source objects
+// the operator read to render the Alertmanager config.
+type ObservedInputs struct {
+	Profile                         EnvironmentProfile `json:"profile,omitempty"`
+	ManagedNamespace                string             `json:"managedNamespace,omitempty"`
+	MaxClusterAgeMinutes            int32              `json:"maxClusterAgeMinutes,omitempty"`
+	ReadinessRequeueIntervalSeconds int32              `json:"readinessRequeueIntervalSeconds,omitempty"`
+	SettlingWindowMinutes           int32              `json:"settlingWindowMinutes,omitempty"`
+	ResumeSettling                  bool               `json:"resumeSettling"`
+	CompactRoutes                   bool               `json:"compactRoutes"`
+	RoutingCoverage                 bool               `json:"routingCoverage"`
+	DriftPolicy                     DriftPolicy        `json:"driftPolicy,omitempty"`
+	OutputMode                      OutputMode         `json:"outputMode,omitempty"`
+	// ClusterType is the cluster type in effect, whether detected or overridden.
+	ClusterType ClusterType `json:"clusterType,omitempty"`
+
+	// Sources lists the Secrets and ConfigMaps that were present in the managed namespace.
+	// +optional
+	Sources []ObservedSource `json:"sources,omitempty"`
+
+	// AlertmanagerConfigs lists the AlertmanagerConfig resources that were aggregated.
+	// +optional
+	AlertmanagerConfigs []ObservedSource `json:"alertmanagerConfigs,omitempty"`
+
+	// NamespaceSources reports how each source of routed namespaces was used.
+	// +optional
+	NamespaceSources []NamespaceSource `json:"namespaceSources,omitempty"`
+}
+
+// NamespaceSource reports how one source of routed namespaces was used.
+type NamespaceSource struct {
+	// Name is the namespace ConfigMap, or ManagementCluster for the
+	// ManagementCluster.AdditionalNamespaces of managed-namespaces.
+	Name  string               `json:"name"`
+	State NamespaceSourceState `json:"state"`
+	// Namespaces is the number of namespace expressions the source contributed.
+	Namespaces int32 `json:"namespaces"`
+}
+
+// ObservedSource identifies a source object and the version that was read.
+type ObservedSource struct {
+	Kind            string `json:"kind"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster,shortName=camo
+//+kubebuilder:printcolumn:name="Profile",type=string,JSONPath=`.status.observedInputs.profile`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Applied",type=string,JSONPath=`.status.conditions[?(@.type=="ConfigApplied")].status`
+//+kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=`.status.conditions[?(@.type=="Degraded")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ConfigureAlertmanager is the singleton configuration for configure-alertmanager-operator.
+// Only the resource named "cluster" is used.
+type ConfigureAlertmanager struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConfigureAlertmanagerSpec   `json:"spec,omitempty"`
+	Status ConfigureAlertmanagerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ConfigureAlertmanagerList contains a list of ConfigureAlertmanager
+type ConfigureAlertmanagerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConfigureAlertmanager `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConfigureAlertmanager{}, &ConfigureAlertmanagerList{})
+}
@@ -0,0 +1,335 @@
+//go:build !ignore_autogenerated
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertmanagerConfigSelection) DeepCopyInto(out *AlertmanagerConfigSelection) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertmanagerConfigSelection.
+func (in *AlertmanagerConfigSelection) DeepCopy() *AlertmanagerConfigSelection {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertmanagerConfigSelection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutomationRoute) DeepCopyInto(out *AutomationRoute) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Continue != nil {
+		in, out := &in.Continue, &out.Continue
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutomationRoute.
+func (in *AutomationRoute) DeepCopy() *AutomationRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(AutomationRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigureAlertmanager) DeepCopyInto(out *ConfigureAlertmanager) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigureAlertmanager.
+func (in *ConfigureAlertmanager) DeepCopy() *ConfigureAlertmanager {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigureAlertmanager)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConfigureAlertmanager) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigureAlertmanagerList) DeepCopyInto(out *ConfigureAlertmanagerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConfigureAlertmanager, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigureAlertmanagerList.
+func (in *ConfigureAlertmanagerList) DeepCopy() *ConfigureAlertmanagerList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigureAlertmanagerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConfigureAlertmanagerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigureAlertmanagerSpec) DeepCopyInto(out *ConfigureAlertmanagerSpec) {
+	*out = *in
+	in.Readiness.DeepCopyInto(&out.Readiness)
+	in.Features.DeepCopyInto(&out.Features)
+	in.AlertmanagerConfigs.DeepCopyInto(&out.AlertmanagerConfigs)
+	out.UserWorkload = in.UserWorkload
+	if in.AutomationRoutes != nil {
+		in, out := &in.AutomationRoutes, &out.AutomationRoutes
+		*out = make([]AutomationRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigureAlertmanagerSpec.
+func (in *ConfigureAlertmanagerSpec) DeepCopy() *ConfigureAlertmanagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigureAlertmanagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigureAlertmanagerStatus) DeepCopyInto(out *ConfigureAlertmanagerStatus) {
+	*out = *in
+	in.ManagedConfigStatus.DeepCopyInto(&out.ManagedConfigStatus)
+	in.ObservedInputs.DeepCopyInto(&out.ObservedInputs)
+	if in.UserWorkload != nil {
+		in, out := &in.UserWorkload, &out.UserWorkload
+		*out = new(ManagedConfigStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigureAlertmanagerStatus.
+func (in *ConfigureAlertmanagerStatus) DeepCopy() *ConfigureAlertmanagerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigureAlertmanagerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeatureToggles) DeepCopyInto(out *FeatureToggles) {
+	*out = *in
+	if in.ResumeSettling != nil {
+		in, out := &in.ResumeSettling, &out.ResumeSettling
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CompactRoutes != nil {
+		in, out := &in.CompactRoutes, &out.CompactRoutes
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RoutingCoverage != nil {
+		in, out := &in.RoutingCoverage, &out.RoutingCoverage
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureToggles.
+func (in *FeatureToggles) DeepCopy() *FeatureToggles {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureToggles)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedConfigStatus) DeepCopyInto(out *ManagedConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastWriteTime != nil {
+		in, out := &in.LastWriteTime, &out.LastWriteTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ActiveIntegrations != nil {
+		in, out := &in.ActiveIntegrations, &out.ActiveIntegrations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedConfigStatus.
+func (in *ManagedConfigStatus) DeepCopy() *ManagedConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceSource) DeepCopyInto(out *NamespaceSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceSource.
+func (in *NamespaceSource) DeepCopy() *NamespaceSource {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservedInputs) DeepCopyInto(out *ObservedInputs) {
+	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]ObservedSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.AlertmanagerConfigs != nil {
+		in, out := &in.AlertmanagerConfigs, &out.AlertmanagerConfigs
+		*out = make([]ObservedSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSources != nil {
+		in, out := &in.NamespaceSources, &out.NamespaceSources
+		*out = make([]NamespaceSource, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservedInputs.
+func (in *ObservedInputs) DeepCopy() *ObservedInputs {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservedInputs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservedSource) DeepCopyInto(out *ObservedSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservedSource.
+func (in *ObservedSource) DeepCopy() *ObservedSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservedSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessSpec) DeepCopyInto(out *ReadinessSpec) {
+	*out = *in
+	if in.MaxClusterAgeMinutes != nil {
+		in, out := &in.MaxClusterAgeMinutes, &out.MaxClusterAgeMinutes
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RequeueIntervalSeconds != nil {
+		in, out := &in.RequeueIntervalSeconds, &out.RequeueIntervalSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SettlingWindowMinutes != nil {
+		in, out := &in.SettlingWindowMinutes, &out.SettlingWindowMinutes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessSpec.
+func (in *ReadinessSpec) DeepCopy() *ReadinessSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserWorkloadSpec) DeepCopyInto(out *UserWorkloadSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserWorkloadSpec.
+func (in *UserWorkloadSpec) DeepCopy() *UserWorkloadSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UserWorkloadSpec)
+	in.DeepCopyInto(out)
+	return out
+}
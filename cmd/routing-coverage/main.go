@@ -0,0 +1,134 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command routing-coverage reports where the alerts of PrometheusRules are routed by an
+// Alertmanager config, which alerts that could page fall to the default null receiver, and
+// which routes to the null receiver match no alert.
+//
+//	oc extract -n openshift-monitoring secret/alertmanager-main --keys=alertmanager.yaml
+//	oc get prometheusrules -A -o yaml > rules.yaml
+//	go run ./cmd/routing-coverage -config alertmanager.yaml rules.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/openshift/configure-alertmanager-operator/pkg/coverage"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+func main() {
+	configPath := flag.String("config", "alertmanager.yaml", "The Alertmanager config to route alerts through.")
+	failOnUnrouted := flag.Bool("fail-on-unrouted", false, "Exit with status 2 if any alert that could page falls to the default null receiver.")
+	platformNamespaces := flag.String("platform-namespaces", `^(openshift-.*|kube-.*|default)$`, "Regular expression of the namespaces whose PrometheusRules the platform Prometheus evaluates. Those in other namespaces are user workloads.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] rules...\n\n"+
+			"Rules are files or directories of PrometheusRules in YAML or JSON, or - for stdin.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	report, err := run(*configPath, *platformNamespaces, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := report.WriteTable(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *failOnUnrouted && report.Counts()[coverage.OutcomeUnrouted] > 0 {
+		os.Exit(2)
+	}
+}
+
+func run(configPath, platformNamespaces string, rulePaths []string) (*coverage.Report, error) {
+	platform, err := regexp.Compile(platformNamespaces)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -platform-namespaces: %w", err)
+	}
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &alertmanager.Config{}
+	if err := yaml.Unmarshal(configBytes, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	var rules []monitoringv1.PrometheusRule
+	for _, path := range rulePaths {
+		pathRules, err := readRules(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, pathRules...)
+	}
+	return coverage.NewReport(cfg, coverage.AlertsFromPrometheusRules(rules, platform.MatchString))
+}
+
+// readRules reads the PrometheusRules in a file, in the YAML and JSON files of a directory
+// tree, or on stdin if path is -.
+func readRules(path string) ([]monitoringv1.PrometheusRule, error) {
+	if path == "-" {
+		return coverage.ReadPrometheusRules(os.Stdin)
+	}
+
+	var rules []monitoringv1.PrometheusRule
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if file != path {
+			switch filepath.Ext(file) {
+			case ".yaml", ".yml", ".json":
+			default:
+				return nil
+			}
+		}
+		fileRules, err := readRulesFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		rules = append(rules, fileRules...)
+		return nil
+	})
+	return rules, err
+}
+
+func readRulesFile(file string) ([]monitoringv1.PrometheusRule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return coverage.ReadPrometheusRules(f)
+}
@@ -18,15 +18,114 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	OperatorName       string = "configure-alertmanager-operator"
 	OperatorNamespace  string = "openshift-monitoring"
 	EnableOLMSkipRange string = "true"
+
+	// UserWorkloadNamespace holds the user-workload Alertmanager and the integration
+	// secrets and namespace ConfigMap it is rendered from.
+	UserWorkloadNamespace string = "openshift-user-workload-monitoring"
+)
+
+const (
+	maxClusterAgeKey     = "MAX_CLUSTER_AGE_MINUTES"
+	maxClusterAgeDefault = 90
+
+	settlingWindowKey     = "SETTLING_WINDOW_MINUTES"
+	settlingWindowDefault = 30
+
+	readinessRequeueIntervalDefault = 30 * time.Second
 )
 
-var isFedramp = false
+// Policies for out-of-band edits to the alertmanager-main secret.
+const (
+	// DriftPolicyOverwrite replaces manual edits with the rendered config.
+	DriftPolicyOverwrite = "Overwrite"
+	// DriftPolicyPreserveAndAlert leaves manual edits in place and reports them until they are reverted.
+	DriftPolicyPreserveAndAlert = "PreserveAndAlert"
+)
+
+// Output modes for the rendered Alertmanager config.
+const (
+	// OutputModeSecret writes the rendered config to the alertmanager-main secret.
+	OutputModeSecret = "Secret"
+	// OutputModeAlertmanagerConfig writes the rendered routes and receivers to an
+	// AlertmanagerConfig resource, leaving alertmanager-main to cluster-monitoring-operator.
+	OutputModeAlertmanagerConfig = "AlertmanagerConfig"
+)
+
+// Cluster types, as detected or set on the ConfigureAlertmanager resource.
+const (
+	// ClusterTypeStandard is an OSD, ROSA Classic or hosted cluster.
+	ClusterTypeStandard = "Standard"
+	// ClusterTypeManagementCluster is a HyperShift management cluster.
+	ClusterTypeManagementCluster = "ManagementCluster"
+)
+
+// Settings holds the operator's runtime behaviour. Defaults are read from environment
+// variables at startup and may be overridden by the ConfigureAlertmanager resource.
+type Settings struct {
+	// Fedramp removes cluster-identifying details from notifications.
+	Fedramp bool
+	// MaxClusterAgeMinutes is the age after which the cluster is declared ready regardless of ClusterOperator state.
+	MaxClusterAgeMinutes int
+	// ReadinessRequeueInterval is how often readiness is re-evaluated while the cluster is not ready.
+	ReadinessRequeueInterval time.Duration
+	// SettlingWindowMinutes bounds how long paging is withheld after the cluster resumes.
+	SettlingWindowMinutes int
+	// ResumeSettling enables the post-resume settling window.
+	ResumeSettling bool
+	// CompactRoutes merges equivalent routes in the rendered config.
+	CompactRoutes bool
+	// RoutingCoverage periodically reports where the alerts of PrometheusRules are routed.
+	RoutingCoverage bool
+	// DriftPolicy decides what happens to manual edits of alertmanager-main.
+	DriftPolicy string
+	// AlertmanagerConfigNamespaces are the namespaces whose AlertmanagerConfig resources are aggregated.
+	AlertmanagerConfigNamespaces []string
+	// AlertmanagerConfigSelector aggregates AlertmanagerConfig resources with matching labels in any namespace.
+	AlertmanagerConfigSelector *metav1.LabelSelector
+	// OutputMode decides where the rendered config is written.
+	OutputMode string
+	// ClusterType overrides cluster type detection when set.
+	ClusterType string
+	// AutomationRoutes add automation routes, or change the built-in ones with the same name.
+	AutomationRoutes []AutomationRoute
+}
+
+// AutomationRoute sends alerts with matching labels to an automation service configured
+// from a Secret in the managed namespace. Fields left unset on a route named like a
+// built-in one keep the built-in values.
+type AutomationRoute struct {
+	Name        string
+	MatchLabels map[string]string
+	SecretName  string
+	Continue    *bool
+}
+
+var (
+	settingsLock sync.RWMutex
+	defaults     = Settings{
+		MaxClusterAgeMinutes:     maxClusterAgeDefault,
+		ReadinessRequeueInterval: readinessRequeueIntervalDefault,
+		SettlingWindowMinutes:    settlingWindowDefault,
+		ResumeSettling:           true,
+		DriftPolicy:              DriftPolicyOverwrite,
+		OutputMode:               OutputModeSecret,
+	}
+	current             = defaults
+	managedNamespace    = OperatorNamespace
+	userWorkloadEnabled = false
+	// alertmanagerConfigNamespaces are the namespaces AlertmanagerConfigs are cached in
+	alertmanagerConfigNamespaces []string
+)
 
 // SetIsFedramp gets the value of fedramp
 func SetIsFedramp() error {
@@ -40,11 +139,118 @@ func SetIsFedramp() error {
 		return fmt.Errorf("invalid value for FedRAMP environment variable. %w", err)
 	}
 
-	isFedramp = fedrampBool
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	defaults.Fedramp = fedrampBool
+	current.Fedramp = fedrampBool
 	return nil
 }
 
 // IsFedramp returns value of isFedramp var
 func IsFedramp() bool {
-	return isFedramp
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return current.Fedramp
+}
+
+// LoadDefaults reads the default Settings from environment variables.
+func LoadDefaults() error {
+	if err := SetIsFedramp(); err != nil {
+		return err
+	}
+	maxClusterAge, err := getEnvInt(maxClusterAgeKey, maxClusterAgeDefault)
+	if err != nil {
+		return err
+	}
+	settlingWindow, err := getEnvInt(settlingWindowKey, settlingWindowDefault)
+	if err != nil {
+		return err
+	}
+
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	defaults.MaxClusterAgeMinutes = maxClusterAge
+	defaults.SettlingWindowMinutes = settlingWindow
+	current = defaults
+	return nil
+}
+
+// DefaultSettings returns the Settings derived from environment variables and built-in defaults.
+func DefaultSettings() Settings {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return defaults
+}
+
+// GetSettings returns the Settings currently in effect.
+func GetSettings() Settings {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return current
+}
+
+// SetSettings replaces the Settings currently in effect.
+func SetSettings(settings Settings) {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	current = settings
+}
+
+// ManagedNamespace returns the namespace holding the integration secrets, namespace
+// ConfigMaps and the alertmanager-main secret.
+func ManagedNamespace() string {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return managedNamespace
+}
+
+// SetManagedNamespace sets the managed namespace. It must be called before the manager
+// starts, since the cache is scoped to this namespace.
+func SetManagedNamespace(namespace string) {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	managedNamespace = namespace
+}
+
+// UserWorkloadEnabled returns true if the operator manages the user-workload Alertmanager config.
+func UserWorkloadEnabled() bool {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return userWorkloadEnabled
+}
+
+// SetUserWorkloadEnabled enables management of the user-workload Alertmanager config. It must
+// be called before the manager starts, since the cache is scoped to the managed namespaces.
+func SetUserWorkloadEnabled(enabled bool) {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	userWorkloadEnabled = enabled
+}
+
+// AlertmanagerConfigNamespaces returns the namespaces AlertmanagerConfigs are cached in, with
+// metav1.NamespaceAll for every namespace. It is empty if none were selected at startup.
+func AlertmanagerConfigNamespaces() []string {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return alertmanagerConfigNamespaces
+}
+
+// SetAlertmanagerConfigNamespaces sets the namespaces AlertmanagerConfigs are cached in. It must
+// be called before the manager starts, since the cache is scoped to these namespaces.
+func SetAlertmanagerConfigNamespaces(namespaces []string) {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	alertmanagerConfigNamespaces = namespaces
+}
+
+func getEnvInt(key string, def int) (int, error) {
+	strVal := os.Getenv(key)
+	if strVal == "" {
+		return def, nil
+	}
+	intVal, err := strconv.Atoi(strVal)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for env var: %s=%s (expected int): %v", key, strVal, err)
+	}
+	return intVal, nil
 }
@@ -0,0 +1,381 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/go-logr/logr"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	monitoringv1beta1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1beta1"
+	amlabels "github.com/prometheus/alertmanager/pkg/labels"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+const (
+	// outputAlertmanagerConfigName names the AlertmanagerConfig, and the Secret holding its
+	// credentials, written in the AlertmanagerConfig output mode.
+	outputAlertmanagerConfigName = config.OperatorName
+
+	// alertmanagerNameMain is the Alertmanager resource that renders alertmanager-main.
+	alertmanagerNameMain = "main"
+)
+
+// invalidSecretKeyChars matches characters that aren't allowed in Secret keys.
+var invalidSecretKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// convertToAlertmanagerConfig converts a rendered config into an AlertmanagerConfig in
+// namespace, and a Secret holding the routing keys and webhook URLs it references.
+//
+// prometheus-operator adds the AlertmanagerConfig's route under the root route of
+// alertmanager-main with continue forced on, so global settings are left to the base
+// config. The PagerDuty URL is set on each receiver instead. Templates and TLS settings
+// can't be expressed and are an error.
+func convertToAlertmanagerConfig(amconfig *alertmanager.Config, namespace string) (*monitoringv1beta1.AlertmanagerConfig, *corev1.Secret, error) {
+	if len(amconfig.Templates) > 0 {
+		return nil, nil, fmt.Errorf("templates are not supported in an AlertmanagerConfig")
+	}
+	if amconfig.Route == nil {
+		return nil, nil, fmt.Errorf("config has no route")
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: outputAlertmanagerConfigName, Namespace: namespace},
+		Data:       map[string][]byte{},
+	}
+	// secretRef stores value in the Secret and returns a reference to it.
+	secretRef := func(receiver, kind string, i int, value string) *monitoringv1beta1.SecretKeySelector {
+		key := fmt.Sprintf("%s.%s.%d", invalidSecretKeyChars.ReplaceAllString(receiver, "_"), kind, i)
+		secret.Data[key] = []byte(value)
+		return &monitoringv1beta1.SecretKeySelector{Name: secret.Name, Key: key}
+	}
+
+	amc := &monitoringv1beta1.AlertmanagerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: outputAlertmanagerConfigName, Namespace: namespace},
+	}
+
+	route, err := convertRoute(amconfig.Route)
+	if err != nil {
+		return nil, nil, err
+	}
+	amc.Spec.Route = route
+
+	pagerdutyURL := ""
+	if amconfig.Global != nil {
+		pagerdutyURL = amconfig.Global.PagerdutyURL
+	}
+	for _, receiver := range amconfig.Receivers {
+		out := monitoringv1beta1.Receiver{Name: receiver.Name}
+		for i, pd := range receiver.PagerdutyConfigs {
+			httpConfig, err := convertHttpConfig(pd.HttpConfig)
+			if err != nil {
+				return nil, nil, fmt.Errorf("receiver %q: %w", receiver.Name, err)
+			}
+			sendResolved := pd.VSendResolved
+			pdConfig := monitoringv1beta1.PagerDutyConfig{
+				SendResolved: &sendResolved,
+				RoutingKey:   secretRef(receiver.Name, "pagerduty", i, pd.RoutingKey),
+				Client:       stringPtr(pd.Client),
+				ClientURL:    stringPtr(pd.ClientURL),
+				Description:  stringPtr(pd.Description),
+				Severity:     stringPtr(pd.Severity),
+				Class:        stringPtr(pd.Class),
+				Group:        stringPtr(pd.Group),
+				Component:    stringPtr(pd.Component),
+				HTTPConfig:   httpConfig,
+			}
+			if url := cmp.Or(pd.URL, pagerdutyURL); url != "" {
+				pdURL := monitoringv1beta1.URL(url)
+				pdConfig.URL = &pdURL
+			}
+			for _, key := range slices.Sorted(maps.Keys(pd.Details)) {
+				pdConfig.Details = append(pdConfig.Details, monitoringv1beta1.KeyValue{Key: key, Value: pd.Details[key]})
+			}
+			out.PagerDutyConfigs = append(out.PagerDutyConfigs, pdConfig)
+		}
+		for i, webhook := range receiver.WebhookConfigs {
+			httpConfig, err := convertHttpConfig(webhook.HttpConfig)
+			if err != nil {
+				return nil, nil, fmt.Errorf("receiver %q: %w", receiver.Name, err)
+			}
+			sendResolved := webhook.VSendResolved
+			out.WebhookConfigs = append(out.WebhookConfigs, monitoringv1beta1.WebhookConfig{
+				SendResolved: &sendResolved,
+				URLSecret:    secretRef(receiver.Name, "webhook", i, webhook.URL),
+				HTTPConfig:   httpConfig,
+			})
+		}
+		amc.Spec.Receivers = append(amc.Spec.Receivers, out)
+	}
+
+	for _, rule := range amconfig.InhibitRules {
+		amc.Spec.InhibitRules = append(amc.Spec.InhibitRules, monitoringv1beta1.InhibitRule{
+			SourceMatch: append(convertMatchers(rule.SourceMatch, monitoringv1beta1.MatchEqual), convertMatchers(rule.SourceMatchRE, monitoringv1beta1.MatchRegexp)...),
+			TargetMatch: append(convertMatchers(rule.TargetMatch, monitoringv1beta1.MatchEqual), convertMatchers(rule.TargetMatchRE, monitoringv1beta1.MatchRegexp)...),
+			Equal:       rule.Equal,
+		})
+	}
+	return amc, secret, nil
+}
+
+// convertRoute converts a route and its children. Match, match_re and matchers are all
+// expressed as AlertmanagerConfig matchers.
+func convertRoute(in *alertmanager.Route) (*monitoringv1beta1.Route, error) {
+	out := &monitoringv1beta1.Route{
+		Receiver: in.Receiver,
+		GroupBy:  in.GroupByStr,
+		Continue: in.Continue,
+		Matchers: append(convertMatchers(in.Match, monitoringv1beta1.MatchEqual), convertMatchers(in.MatchRE, monitoringv1beta1.MatchRegexp)...),
+	}
+	for _, s := range in.Matchers {
+		matcher, err := amlabels.ParseMatcher(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", s, err)
+		}
+		out.Matchers = append(out.Matchers, monitoringv1beta1.Matcher{Name: matcher.Name, Value: matcher.Value, MatchType: monitoringv1beta1.MatchType(matcher.Type.String())})
+	}
+	if in.GroupWait != "" {
+		groupWait := monitoringv1.NonEmptyDuration(in.GroupWait)
+		out.GroupWait = &groupWait
+	}
+	if in.GroupInterval != "" {
+		groupInterval := monitoringv1.NonEmptyDuration(in.GroupInterval)
+		out.GroupInterval = &groupInterval
+	}
+	if in.RepeatInterval != "" {
+		repeatInterval := monitoringv1.NonEmptyDuration(in.RepeatInterval)
+		out.RepeatInterval = &repeatInterval
+	}
+
+	for _, child := range in.Routes {
+		converted, err := convertRoute(child)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(converted)
+		if err != nil {
+			return nil, err
+		}
+		out.Routes = append(out.Routes, apiextensionsv1.JSON{Raw: raw})
+	}
+	return out, nil
+}
+
+// convertMatchers converts a label map into matchers of the given type, sorted by label name.
+func convertMatchers(in map[string]string, matchType monitoringv1beta1.MatchType) []monitoringv1beta1.Matcher {
+	var out []monitoringv1beta1.Matcher
+	for _, name := range slices.Sorted(maps.Keys(in)) {
+		out = append(out, monitoringv1beta1.Matcher{Name: name, Value: in[name], MatchType: matchType})
+	}
+	return out
+}
+
+// convertHttpConfig converts the proxy setting of an HTTP config, the only one the operator uses.
+func convertHttpConfig(in alertmanager.HttpConfig) (*monitoringv1beta1.HTTPConfig, error) {
+	if in.TLSConfig != (alertmanager.TLSConfig{}) {
+		return nil, fmt.Errorf("tls_config is not supported in an AlertmanagerConfig")
+	}
+	if in.ProxyURL == "" {
+		return nil, nil
+	}
+	return &monitoringv1beta1.HTTPConfig{ProxyConfig: monitoringv1.ProxyConfig{ProxyURL: &in.ProxyURL}}, nil
+}
+
+// stringPtr returns a pointer to s, or nil if s is empty.
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// alertmanagerConfigOutputLabels returns the labels that make the main Alertmanager in
+// namespace select the generated AlertmanagerConfig. It is an error if the Alertmanager
+// doesn't select AlertmanagerConfigs in namespace, or would restrict them to alerts from
+// that namespace, since the generated routes cover alerts from every namespace.
+func (r *SecretReconciler) alertmanagerConfigOutputLabels(ctx context.Context, namespace string) (map[string]string, error) {
+	am := &monitoringv1.Alertmanager{}
+	if err := r.apiReader().Get(ctx, client.ObjectKey{Namespace: namespace, Name: alertmanagerNameMain}, am); err != nil {
+		return nil, fmt.Errorf("unable to read Alertmanager %s/%s: %w", namespace, alertmanagerNameMain, err)
+	}
+	if am.Spec.AlertmanagerConfigSelector == nil {
+		return nil, fmt.Errorf("alertmanager %s/%s does not select any AlertmanagerConfigs", namespace, alertmanagerNameMain)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(am.Spec.AlertmanagerConfigSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alertmanagerConfigSelector on Alertmanager %s/%s: %w", namespace, alertmanagerNameMain, err)
+	}
+	selected := maps.Clone(am.Spec.AlertmanagerConfigSelector.MatchLabels)
+	if selected == nil {
+		selected = map[string]string{}
+	}
+	if !selector.Matches(labels.Set(selected)) {
+		return nil, fmt.Errorf("alertmanagerConfigSelector on Alertmanager %s/%s can't be satisfied by its matchLabels", namespace, alertmanagerNameMain)
+	}
+
+	if am.Spec.AlertmanagerConfigNamespaceSelector != nil {
+		namespaceSelector, err := metav1.LabelSelectorAsSelector(am.Spec.AlertmanagerConfigNamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alertmanagerConfigNamespaceSelector on Alertmanager %s/%s: %w", namespace, alertmanagerNameMain, err)
+		}
+		ns := &corev1.Namespace{}
+		if err := r.apiReader().Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+			return nil, fmt.Errorf("unable to read Namespace %s: %w", namespace, err)
+		}
+		if !namespaceSelector.Matches(labels.Set(ns.Labels)) {
+			return nil, fmt.Errorf("alertmanager %s/%s does not select AlertmanagerConfigs in namespace %s", namespace, alertmanagerNameMain, namespace)
+		}
+	}
+
+	switch am.Spec.AlertmanagerConfigMatcherStrategy.Type {
+	case monitoringv1.NoneConfigMatcherStrategyType, monitoringv1.OnNamespaceExceptForAlertmanagerNamespaceConfigMatcherStrategyType:
+	default:
+		return nil, fmt.Errorf("alertmanager %s/%s restricts AlertmanagerConfigs to alerts from their own namespace; set alertmanagerConfigMatcherStrategy to None", namespace, alertmanagerNameMain)
+	}
+	return selected, nil
+}
+
+// writeAlertmanagerConfigOutput writes the rendered config as an AlertmanagerConfig, and
+// its credentials Secret, in the target namespace instead of writing the target Secret.
+func (r *SecretReconciler) writeAlertmanagerConfigOutput(ctx context.Context, reqLogger logr.Logger, target alertmanagerTarget, amconfig *alertmanager.Config) error {
+	selectorLabels, err := r.alertmanagerConfigOutputLabels(ctx, target.namespace)
+	if err != nil {
+		return err
+	}
+	amc, secret, err := convertToAlertmanagerConfig(amconfig, target.namespace)
+	if err != nil {
+		return fmt.Errorf("unable to convert the alertmanager config to an AlertmanagerConfig: %w", err)
+	}
+	amc.Labels = selectorLabels
+
+	// Write the credentials first so the AlertmanagerConfig never references missing keys.
+	if err := r.Client.Update(ctx, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to write %s secret: %w", secret.Name, err)
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return fmt.Errorf("failed to write %s secret: %w", secret.Name, err)
+		}
+	}
+
+	existing := &monitoringv1beta1.AlertmanagerConfig{}
+	err = r.Client.Get(ctx, client.ObjectKeyFromObject(amc), existing)
+	switch {
+	case errors.IsNotFound(err):
+		err = r.Client.Create(ctx, amc)
+	case err == nil:
+		existing.Labels = amc.Labels
+		existing.Spec = amc.Spec
+		err = r.Client.Update(ctx, existing)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write AlertmanagerConfig %s/%s: %w", amc.Namespace, amc.Name, err)
+	}
+
+	reqLogger.Info("INFO: AlertmanagerConfig successfully updated", "name", amc.Name, "namespace", amc.Namespace)
+	return r.releaseAlertmanagerSecret(ctx, reqLogger, target)
+}
+
+// releaseAlertmanagerSecret hands the target Secret back to cluster-monitoring-operator after
+// switching to the AlertmanagerConfig output mode. If the operator wrote it, its config is
+// replaced with a minimal base config, since prometheus-operator would otherwise add the
+// AlertmanagerConfig's routes alongside the operator's own and send every notification twice.
+func (r *SecretReconciler) releaseAlertmanagerSecret(ctx context.Context, reqLogger logr.Logger, target alertmanagerTarget) error {
+	secret, _ := r.currentAlertManagerConfig(ctx, target)
+	if secret == nil {
+		return nil
+	}
+	if _, written := secret.Annotations[annotationConfigHash]; !written {
+		return nil
+	}
+
+	base, err := yaml.Marshal(createBaseAlertManagerConfig())
+	if err != nil {
+		return err
+	}
+	secret.Data["alertmanager.yaml"] = base
+	delete(secret.Annotations, annotationConfigHash)
+	delete(secret.Annotations, annotationLastChange)
+	if err := r.Client.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to release %s secret: %w", secret.Name, err)
+	}
+	reqLogger.Info("INFO: Replaced the operator's config with a base config", "name", secret.Name, "namespace", secret.Namespace)
+	return nil
+}
+
+// deleteAlertmanagerConfigOutput removes the AlertmanagerConfig and Secret written in the
+// AlertmanagerConfig output mode, so they aren't merged with a config written to the Secret.
+func (r *SecretReconciler) deleteAlertmanagerConfigOutput(ctx context.Context, reqLogger logr.Logger, target alertmanagerTarget) error {
+	objectMeta := metav1.ObjectMeta{Name: outputAlertmanagerConfigName, Namespace: target.namespace}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: target.namespace, Name: outputAlertmanagerConfigName}, &monitoringv1beta1.AlertmanagerConfig{}); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// Nothing to delete, or the AlertmanagerConfig CRD isn't installed
+			return nil
+		}
+		return fmt.Errorf("failed to get AlertmanagerConfig %s/%s: %w", target.namespace, outputAlertmanagerConfigName, err)
+	}
+	objects := []struct {
+		kind string
+		obj  client.Object
+	}{
+		{monitoringv1beta1.AlertmanagerConfigKind, &monitoringv1beta1.AlertmanagerConfig{ObjectMeta: objectMeta}},
+		{"Secret", &corev1.Secret{ObjectMeta: objectMeta}},
+	}
+	for _, o := range objects {
+		if err := r.Client.Delete(ctx, o.obj); err != nil {
+			if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return fmt.Errorf("failed to delete %s %s/%s: %w", o.kind, objectMeta.Namespace, objectMeta.Name, err)
+		}
+		reqLogger.Info("INFO: Deleted output of the AlertmanagerConfig output mode", "kind", o.kind, "name", objectMeta.Name, "namespace", objectMeta.Namespace)
+	}
+	return nil
+}
+
+// createBaseAlertManagerConfig creates the minimal config left in alertmanager-main when
+// the rendered config is written to an AlertmanagerConfig.
+func createBaseAlertManagerConfig() *alertmanager.Config {
+	return &alertmanager.Config{
+		Global: &alertmanager.GlobalConfig{
+			ResolveTimeout: "5m",
+		},
+		Route: &alertmanager.Route{
+			Receiver:   receiverNull,
+			GroupByStr: []string{"namespace"},
+		},
+		Receivers: []*alertmanager.Receiver{{Name: receiverNull}},
+		Templates: []string{},
+	}
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	monitoringv1beta1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// namespaceLabel is the alert label AlertmanagerConfig routes are scoped by.
+const namespaceLabel = "namespace"
+
+// alertmanagerConfigSecretResync is how often alertmanager-main is re-rendered while aggregated
+// AlertmanagerConfigs read Secrets. Those Secrets can be in any namespace, so they are neither
+// cached nor watched, and a rotated key is only picked up by the next reconcile.
+const alertmanagerConfigSecretResync = 5 * time.Minute
+
+// selectedAlertmanagerConfigs returns the AlertmanagerConfigs selected by the operator
+// settings, sorted by namespace and name. Nothing is listed if no selection is configured.
+func (r *SecretReconciler) selectedAlertmanagerConfigs(ctx context.Context, settings config.Settings) ([]*monitoringv1beta1.AlertmanagerConfig, error) {
+	if len(settings.AlertmanagerConfigNamespaces) == 0 && settings.AlertmanagerConfigSelector == nil {
+		return nil, nil
+	}
+
+	selector := labels.Nothing()
+	if settings.AlertmanagerConfigSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(settings.AlertmanagerConfigSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AlertmanagerConfig selector: %w", err)
+		}
+	}
+
+	amcList := &monitoringv1beta1.AlertmanagerConfigList{}
+	if err := r.Client.List(ctx, amcList); err != nil {
+		if meta.IsNoMatchError(err) {
+			// The AlertmanagerConfig CRD is not installed
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to list AlertmanagerConfigs: %w", err)
+	}
+
+	selected := []*monitoringv1beta1.AlertmanagerConfig{}
+	for i := range amcList.Items {
+		amc := &amcList.Items[i]
+		if slices.Contains(settings.AlertmanagerConfigNamespaces, amc.Namespace) || selector.Matches(labels.Set(amc.Labels)) {
+			selected = append(selected, amc)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].Namespace != selected[j].Namespace {
+			return selected[i].Namespace < selected[j].Namespace
+		}
+		return selected[i].Name < selected[j].Name
+	})
+	return selected, nil
+}
+
+// aggregateAlertmanagerConfigs adds the routes and receivers of the selected AlertmanagerConfigs
+// to a copy of the generated config. Each AlertmanagerConfig becomes one top-level route, ahead
+// of the generated routes, that only matches alerts from its own namespace and always continues,
+// so it can add notifications but never take alerts away from the operator's routes.
+//
+// AlertmanagerConfigs that can't be translated, or that would make the config invalid, are
+// skipped with a Warning Event. The aggregated AlertmanagerConfigs are returned for status.
+func (r *SecretReconciler) aggregateAlertmanagerConfigs(ctx context.Context, reqLogger logr.Logger, generated *alertmanager.Config, clusterProxy string) (*alertmanager.Config, []*monitoringv1beta1.AlertmanagerConfig) {
+	selected, err := r.selectedAlertmanagerConfigs(ctx, config.GetSettings())
+	if err != nil {
+		reqLogger.Error(err, "Unable to select AlertmanagerConfigs, none will be aggregated")
+	}
+	if len(selected) == 0 {
+		metrics.UpdateAlertmanagerConfigsMetric(0, 0)
+		return generated, nil
+	}
+
+	merged := *generated
+	route := *generated.Route
+	merged.Route = &route
+	merged.Receivers = slices.Clone(generated.Receivers)
+
+	aggregated := []*monitoringv1beta1.AlertmanagerConfig{}
+	rejected := 0
+	amcRoutes := []*alertmanager.Route{}
+	for _, amc := range selected {
+		amcRoute, receivers, err := r.translateAlertmanagerConfig(ctx, amc, clusterProxy)
+		if err == nil && amcRoute == nil {
+			// Receivers without a route can't be reached
+			continue
+		}
+		if err == nil {
+			candidate := merged
+			candidateRoute := route
+			candidateRoute.Routes = append(slices.Clone(amcRoutes), amcRoute)
+			candidate.Route = &candidateRoute
+			candidate.Receivers = append(slices.Clone(merged.Receivers), receivers...)
+			err = validateAlertManagerConfig(reqLogger, &candidate)
+		}
+		if err != nil {
+			reqLogger.Error(err, "ERROR: Skipping AlertmanagerConfig", "namespace", amc.Namespace, "name", amc.Name)
+			r.recordEvent(amc, corev1.EventTypeWarning, eventReasonAlertmanagerConfigRejected, eventActionAggregateAlertmanagerConfigs,
+				fmt.Sprintf("AlertmanagerConfig was not added to %s/%s. Error: %v", config.ManagedNamespace(), secretNameAlertmanager, err))
+			rejected++
+			continue
+		}
+		amcRoutes = append(amcRoutes, amcRoute)
+		merged.Receivers = append(merged.Receivers, receivers...)
+		aggregated = append(aggregated, amc)
+	}
+
+	route.Routes = append(amcRoutes, generated.Route.Routes...)
+	reqLogger.Info("INFO: Aggregated AlertmanagerConfigs", "aggregated", len(aggregated), "selected", len(selected))
+	metrics.UpdateAlertmanagerConfigsMetric(len(aggregated), rejected)
+	return &merged, aggregated
+}
+
+// translateAlertmanagerConfig converts an AlertmanagerConfig into a route and receivers.
+// Receiver names are prefixed with namespace/name/ as prometheus-operator does, so they
+// can't collide with the operator's receivers or another AlertmanagerConfig's. The route
+// is nil if the AlertmanagerConfig has none.
+func (r *SecretReconciler) translateAlertmanagerConfig(ctx context.Context, amc *monitoringv1beta1.AlertmanagerConfig, clusterProxy string) (*alertmanager.Route, []*alertmanager.Receiver, error) {
+	if len(amc.Spec.InhibitRules) > 0 || len(amc.Spec.TimeIntervals) > 0 {
+		return nil, nil, fmt.Errorf("inhibitRules and timeIntervals are not supported")
+	}
+	if amc.Spec.Route == nil {
+		return nil, nil, nil
+	}
+
+	receiverNames := []string{}
+	receivers := []*alertmanager.Receiver{}
+	for _, receiver := range amc.Spec.Receivers {
+		translated, err := r.translateReceiver(ctx, amc, receiver, clusterProxy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("receiver %q: %w", receiver.Name, err)
+		}
+		receiverNames = append(receiverNames, receiver.Name)
+		receivers = append(receivers, translated)
+	}
+
+	route, err := translateRoute(amc, *amc.Spec.Route, receiverNames, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return route, receivers, nil
+}
+
+// alertmanagerConfigReceiverName is the name an AlertmanagerConfig receiver is given in alertmanager.yaml.
+func alertmanagerConfigReceiverName(amc *monitoringv1beta1.AlertmanagerConfig, receiver string) string {
+	return fmt.Sprintf("%s/%s/%s", amc.Namespace, amc.Name, receiver)
+}
+
+// translateRoute converts an AlertmanagerConfig route and its children. The top-level route
+// is scoped to the AlertmanagerConfig's namespace: any namespace matcher is replaced with an
+// equality matcher on the namespace, and continue is forced on.
+func translateRoute(amc *monitoringv1beta1.AlertmanagerConfig, in monitoringv1beta1.Route, receiverNames []string, topLevel bool) (*alertmanager.Route, error) {
+	if len(in.MuteTimeIntervals) > 0 || len(in.ActiveTimeIntervals) > 0 {
+		return nil, fmt.Errorf("route time intervals are not supported")
+	}
+
+	out := &alertmanager.Route{
+		GroupByStr: in.GroupBy,
+		Continue:   in.Continue,
+	}
+	if in.Receiver != "" {
+		if !slices.Contains(receiverNames, in.Receiver) {
+			return nil, fmt.Errorf("route references undefined receiver %q", in.Receiver)
+		}
+		out.Receiver = alertmanagerConfigReceiverName(amc, in.Receiver)
+	}
+	if in.GroupWait != nil {
+		out.GroupWait = string(*in.GroupWait)
+	}
+	if in.GroupInterval != nil {
+		out.GroupInterval = string(*in.GroupInterval)
+	}
+	if in.RepeatInterval != nil {
+		out.RepeatInterval = string(*in.RepeatInterval)
+	}
+
+	if topLevel {
+		out.Continue = true
+		out.Matchers = append(out.Matchers, monitoringv1beta1.Matcher{Name: namespaceLabel, Value: amc.Namespace, MatchType: monitoringv1beta1.MatchEqual}.String())
+	}
+	for _, matcher := range in.Matchers {
+		if topLevel && matcher.Name == namespaceLabel {
+			continue
+		}
+		if matcher.MatchType == "" {
+			matcher.MatchType = monitoringv1beta1.MatchEqual
+		}
+		if err := matcher.Validate(); err != nil {
+			return nil, err
+		}
+		out.Matchers = append(out.Matchers, matcher.String())
+	}
+
+	children, err := in.ChildRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid child routes: %w", err)
+	}
+	for _, child := range children {
+		translated, err := translateRoute(amc, child, receiverNames, false)
+		if err != nil {
+			return nil, err
+		}
+		out.Routes = append(out.Routes, translated)
+	}
+	return out, nil
+}
+
+// translateReceiver converts an AlertmanagerConfig receiver. Only PagerDuty and webhook
+// configs are supported, matching the receivers the operator renders itself; notifications
+// are sent through the cluster proxy like the operator's own.
+func (r *SecretReconciler) translateReceiver(ctx context.Context, amc *monitoringv1beta1.AlertmanagerConfig, in monitoringv1beta1.Receiver, clusterProxy string) (*alertmanager.Receiver, error) {
+	supported := monitoringv1beta1.Receiver{Name: in.Name, PagerDutyConfigs: in.PagerDutyConfigs, WebhookConfigs: in.WebhookConfigs}
+	if !apiequality.Semantic.DeepEqual(in, supported) {
+		return nil, fmt.Errorf("only pagerdutyConfigs and webhookConfigs are supported")
+	}
+
+	out := &alertmanager.Receiver{Name: alertmanagerConfigReceiverName(amc, in.Name)}
+	for _, pd := range in.PagerDutyConfigs {
+		if pd.ServiceKey != nil || pd.HTTPConfig != nil || pd.Timeout != nil || pd.Source != nil ||
+			len(pd.PagerDutyImageConfigs) > 0 || len(pd.PagerDutyLinkConfigs) > 0 {
+			return nil, fmt.Errorf("pagerdutyConfigs only support routingKey, url, client, clientURL, description, severity, class, group, component and details")
+		}
+		if pd.RoutingKey == nil {
+			return nil, fmt.Errorf("pagerdutyConfigs require a routingKey")
+		}
+		routingKey, err := r.alertmanagerConfigSecretValue(ctx, amc, pd.RoutingKey)
+		if err != nil {
+			return nil, err
+		}
+		pdConfig := &alertmanager.PagerdutyConfig{
+			NotifierConfig: alertmanager.NotifierConfig{VSendResolved: pd.SendResolved == nil || *pd.SendResolved},
+			RoutingKey:     routingKey,
+			Client:         stringValue(pd.Client),
+			ClientURL:      stringValue(pd.ClientURL),
+			Description:    stringValue(pd.Description),
+			Severity:       stringValue(pd.Severity),
+			Class:          stringValue(pd.Class),
+			Group:          stringValue(pd.Group),
+			Component:      stringValue(pd.Component),
+			HttpConfig:     render.HTTPConfig(clusterProxy),
+		}
+		if pd.URL != nil {
+			pdConfig.URL = string(*pd.URL)
+		}
+		if len(pd.Details) > 0 {
+			pdConfig.Details = map[string]string{}
+			for _, detail := range pd.Details {
+				pdConfig.Details[detail.Key] = detail.Value
+			}
+		}
+		out.PagerdutyConfigs = append(out.PagerdutyConfigs, pdConfig)
+	}
+
+	for _, webhook := range in.WebhookConfigs {
+		if webhook.HTTPConfig != nil || webhook.Timeout != nil || webhook.Payload != nil || webhook.MaxAlerts != 0 {
+			return nil, fmt.Errorf("webhookConfigs only support url and urlSecret")
+		}
+		url := ""
+		switch {
+		case webhook.URLSecret != nil:
+			var err error
+			if url, err = r.alertmanagerConfigSecretValue(ctx, amc, webhook.URLSecret); err != nil {
+				return nil, err
+			}
+		case webhook.URL != nil:
+			url = *webhook.URL
+		default:
+			return nil, fmt.Errorf("webhookConfigs require a url or urlSecret")
+		}
+		out.WebhookConfigs = append(out.WebhookConfigs, &alertmanager.WebhookConfig{
+			NotifierConfig: alertmanager.NotifierConfig{VSendResolved: webhook.SendResolved == nil || *webhook.SendResolved},
+			URL:            url,
+			HttpConfig:     render.HTTPConfig(clusterProxy),
+		})
+	}
+	return out, nil
+}
+
+// requeueForSecretRotation returns result, requeued within alertmanagerConfigSecretResync if any
+// of the aggregated AlertmanagerConfigs reads a Secret.
+func requeueForSecretRotation(result reconcile.Result, aggregated []*monitoringv1beta1.AlertmanagerConfig) reconcile.Result {
+	if !slices.ContainsFunc(aggregated, readsSecrets) {
+		return result
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > alertmanagerConfigSecretResync {
+		result.RequeueAfter = alertmanagerConfigSecretResync
+	}
+	return result
+}
+
+// readsSecrets returns true if a receiver of amc takes a value from a Secret.
+func readsSecrets(amc *monitoringv1beta1.AlertmanagerConfig) bool {
+	for _, receiver := range amc.Spec.Receivers {
+		for _, pd := range receiver.PagerDutyConfigs {
+			if pd.RoutingKey != nil {
+				return true
+			}
+		}
+		for _, webhook := range receiver.WebhookConfigs {
+			if webhook.URLSecret != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// alertmanagerConfigSecretValue reads a key from a Secret in the AlertmanagerConfig's namespace.
+// Secrets outside the managed namespace aren't cached, so they are read from the API server.
+func (r *SecretReconciler) alertmanagerConfigSecretValue(ctx context.Context, amc *monitoringv1beta1.AlertmanagerConfig, selector *monitoringv1beta1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.apiReader().Get(ctx, client.ObjectKey{Namespace: amc.Namespace, Name: selector.Name}, secret); err != nil {
+		return "", fmt.Errorf("unable to read Secret %s/%s: %w", amc.Namespace, selector.Name, err)
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %q", amc.Namespace, selector.Name, selector.Key)
+	}
+	return string(value), nil
+}
+
+// apiReader returns the reader for objects outside the cache, falling back to the Client.
+func (r *SecretReconciler) apiReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
+}
+
+// stringValue returns the string p points to, or "" if p is nil.
+func stringValue(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"maps"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+)
+
+const (
+	// automationRouteCAD is the built-in route sending alerts to CAD, the event-based automation service
+	automationRouteCAD = "cad"
+	// receivers of automation routes other than the built-in ones are named after the route
+	receiverAutomationPrefix = render.ReceiverAutomationPrefix
+
+	secretKeyAutomationPD      = "PAGERDUTY_KEY" // #nosec G101
+	secretKeyAutomationWebhook = "WEBHOOK_URL"
+)
+
+// automationRoute sends alerts with matching labels to an automation service, such as CAD,
+// instead of, or as well as, paging for them.
+type automationRoute struct {
+	name        string
+	receiver    string
+	matchLabels map[string]string
+	secretName  string
+	// secretKeyPD is the key of the PagerDuty routing key in the Secret
+	secretKeyPD string
+	continues   bool
+}
+
+// builtinAutomationRoutes are configured without a ConfigureAlertmanager resource.
+var builtinAutomationRoutes = []automationRoute{
+	{
+		name:        automationRouteCAD,
+		receiver:    receiverCADPagerduty,
+		matchLabels: map[string]string{routeCADLabel: routeCADLabelValue},
+		secretName:  secretNameCADPD,
+		secretKeyPD: secretKeyCADPD,
+	},
+}
+
+// automationRoutes returns the built-in automation routes, changed by the configured routes
+// with the same name, followed by the other configured routes, in order. Configured routes
+// without labels to match or a Secret are left out.
+func automationRoutes(reqLogger logr.Logger, configured []config.AutomationRoute) []automationRoute {
+	routes := make([]automationRoute, 0, len(builtinAutomationRoutes)+len(configured))
+	for _, builtin := range builtinAutomationRoutes {
+		route := builtin
+		route.matchLabels = maps.Clone(builtin.matchLabels)
+		routes = append(routes, route)
+	}
+
+	for _, c := range configured {
+		i := -1
+		for j := range routes {
+			if routes[j].name == c.Name {
+				i = j
+			}
+		}
+		if i < 0 {
+			if len(c.MatchLabels) == 0 || c.SecretName == "" {
+				reqLogger.Info("WARNING: Skipping automation route without matchLabels or secretName", "Route", c.Name)
+				continue
+			}
+			routes = append(routes, automationRoute{name: c.Name, receiver: receiverAutomationPrefix + c.Name, secretKeyPD: secretKeyAutomationPD})
+			i = len(routes) - 1
+		}
+		if len(c.MatchLabels) > 0 {
+			routes[i].matchLabels = maps.Clone(c.MatchLabels)
+		}
+		if c.SecretName != "" {
+			routes[i].secretName = c.SecretName
+		}
+		if c.Continue != nil {
+			routes[i].continues = *c.Continue
+		}
+	}
+	return routes
+}
+
+// isAutomationSecretName returns true for the Secrets the automation routes are configured from.
+func isAutomationSecretName(settings config.Settings, name string) bool {
+	for _, route := range automationRoutes(logr.Discard(), settings.AutomationRoutes) {
+		if route.secretName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readAutomationRoutes returns the automation routes whose Secret holds a PagerDuty routing
+// key or a webhook URL.
+func (r *SecretReconciler) readAutomationRoutes(reqLogger logr.Logger, secretList *corev1.SecretList, namespace string) []render.AutomationRoute {
+	var ready []render.AutomationRoute
+	for _, route := range automationRoutes(reqLogger, config.GetSettings().AutomationRoutes) {
+		if !secretInList(reqLogger, route.secretName, secretList) {
+			reqLogger.Info("INFO: Automation route secret does not exist", "Route", route.name, "Secret", route.secretName)
+			continue
+		}
+		pagerdutyRoutingKey := readSecretKey(r, route.secretName, namespace, route.secretKeyPD)
+		webhookURL := readSecretKey(r, route.secretName, namespace, secretKeyAutomationWebhook)
+		if pagerdutyRoutingKey == "" && webhookURL == "" {
+			reqLogger.Info("INFO: Automation route secret exists but configuration is empty", "Route", route.name, "Secret", route.secretName)
+			continue
+		}
+		ready = append(ready, route.rendered(pagerdutyRoutingKey, webhookURL))
+	}
+	return ready
+}
+
+// rendered returns the route to render with the routing key and webhook URL read from its Secret.
+func (route automationRoute) rendered(pagerdutyRoutingKey, webhookURL string) render.AutomationRoute {
+	return render.AutomationRoute{
+		Name:                route.name,
+		Receiver:            route.receiver,
+		MatchLabels:         maps.Clone(route.matchLabels),
+		Continue:            route.continues,
+		PagerDutyRoutingKey: pagerdutyRoutingKey,
+		WebhookURL:          webhookURL,
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
+)
+
+// How the cluster type was determined, as reported by the camo_cluster_type metric.
+const (
+	// set on the ConfigureAlertmanager resource
+	clusterTypeSourceOverride = "Override"
+	// the Infrastructure name has the management cluster prefix
+	clusterTypeSourceInfrastructure = "Infrastructure"
+	// the ClusterVersion carries the HyperShift cluster-type annotation
+	clusterTypeSourceClusterVersion = "ClusterVersion"
+	// neither of the above identify a management cluster
+	clusterTypeSourceDefault = "Default"
+	// detection failed, so the cluster is assumed to be a management cluster
+	clusterTypeSourceDetectionFailed = "DetectionFailed"
+
+	// HyperShift management clusters have infrastructure names like "hs-mc-<cluster-id>-<hash>"
+	managementClusterInfrastructurePrefix = "hs-mc"
+)
+
+// clusterProfile is the type of cluster the operator runs on.
+type clusterProfile struct {
+	clusterType string
+	source      string
+}
+
+func (p clusterProfile) isManagementCluster() bool {
+	return p.clusterType == config.ClusterTypeManagementCluster
+}
+
+// clusterProfileDetector determines the cluster type. A detected type is cached until
+// invalidate is called, when the Infrastructure or ClusterVersion change; failed or
+// incomplete detections are retried on the next call.
+type clusterProfileDetector struct {
+	mu     sync.Mutex
+	cached *clusterProfile
+}
+
+// profile returns the cluster type set on the ConfigureAlertmanager resource, or else the
+// detected one. If detection fails the cluster is assumed to be a management cluster, since
+// it's better to monitor MC namespaces on a non-MC cluster than miss alerts on an actual MC.
+func (d *clusterProfileDetector) profile(ctx context.Context, c client.Client) (clusterProfile, error) {
+	var (
+		profile clusterProfile
+		err     error
+	)
+	if override := config.GetSettings().ClusterType; override != "" {
+		profile = clusterProfile{clusterType: override, source: clusterTypeSourceOverride}
+	} else {
+		d.mu.Lock()
+		if d.cached == nil {
+			var final bool
+			profile, final, err = detectClusterProfile(ctx, c)
+			if final {
+				d.cached = &profile
+			}
+		} else {
+			profile = *d.cached
+		}
+		d.mu.Unlock()
+	}
+
+	metrics.UpdateClusterTypeMetric(profile.clusterType, profile.source)
+	return profile, err
+}
+
+// invalidate makes the next call to profile detect the cluster type again.
+func (d *clusterProfileDetector) invalidate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cached = nil
+}
+
+// detectClusterProfile checks whether the cluster is a HyperShift management cluster by its
+// Infrastructure name, falling back to the cluster-type annotation on the ClusterVersion.
+// A ClusterVersion that can't be read doesn't identify a management cluster, but the result
+// isn't final, so the ClusterVersion is checked again by the next detection.
+func detectClusterProfile(ctx context.Context, c client.Client) (profile clusterProfile, final bool, err error) {
+	failed := clusterProfile{clusterType: config.ClusterTypeManagementCluster, source: clusterTypeSourceDetectionFailed}
+
+	var infra configv1.Infrastructure
+	if err := c.Get(ctx, client.ObjectKey{Name: "cluster"}, &infra); err != nil {
+		return failed, false, err
+	}
+	if strings.HasPrefix(infra.Status.InfrastructureName, managementClusterInfrastructurePrefix) {
+		return clusterProfile{clusterType: config.ClusterTypeManagementCluster, source: clusterTypeSourceInfrastructure}, true, nil
+	}
+
+	standard := clusterProfile{clusterType: config.ClusterTypeStandard, source: clusterTypeSourceDefault}
+	var version configv1.ClusterVersion
+	if err := c.Get(ctx, client.ObjectKey{Name: clusterVersionName}, &version); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "Unable to get ClusterVersion, assuming it doesn't identify a management cluster")
+			return standard, false, nil
+		}
+		return standard, true, nil
+	}
+	if clusterType, ok := version.Annotations[clusterTypeLabelKey]; ok {
+		if clusterType == clusterTypeManagement {
+			return clusterProfile{clusterType: config.ClusterTypeManagementCluster, source: clusterTypeSourceClusterVersion}, true, nil
+		}
+		return clusterProfile{clusterType: config.ClusterTypeStandard, source: clusterTypeSourceClusterVersion}, true, nil
+	}
+	return standard, true, nil
+}
+
+// enqueueOnClusterProfileChange forgets the detected cluster type when the Infrastructure
+// or ClusterVersion change, and reconciles the Alertmanager config with the new type.
+func (r *SecretReconciler) enqueueOnClusterProfileChange(ctx context.Context, obj client.Object) []reconcile.Request {
+	r.clusterProfile.invalidate()
+	return enqueueAlertmanagerSecret(ctx, obj)
+}
+
+// clusterProfilePredicate passes Infrastructure and ClusterVersion events that can change
+// the cluster type, and ClusterVersion spec changes, which can change the cluster ID.
+var clusterProfilePredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		switch oldObj := e.ObjectOld.(type) {
+		case *configv1.Infrastructure:
+			newObj, ok := e.ObjectNew.(*configv1.Infrastructure)
+			return !ok || oldObj.Status.InfrastructureName != newObj.Status.InfrastructureName
+		case *configv1.ClusterVersion:
+			return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() ||
+				e.ObjectOld.GetAnnotations()[clusterTypeLabelKey] != e.ObjectNew.GetAnnotations()[clusterTypeLabelKey]
+		}
+		return true
+	},
+}
@@ -0,0 +1,368 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// annotationLastChange is set on alertmanager-main to the JSON-encoded configDiff of the
+// most recent write that changed alertmanager.yaml.
+const annotationLastChange = "configure-alertmanager-operator.openshift.io/last-change"
+
+// redactedValue replaces secret values in a configDiff.
+const redactedValue = "<redacted>"
+
+// maxMatcherValueLength bounds matcher values in route descriptions. The namespace
+// regexes can be thousands of characters long.
+const maxMatcherValueLength = 64
+
+// redactedFields are receiver fields holding credentials. Routing keys and webhook URLs
+// (GoAlert, Dead Man's Snitch) grant the ability to page, so their values are never
+// included in a configDiff.
+var redactedFields = []string{"routing_key", "service_key", "url", "proxy_url"}
+
+// configDiff describes how a write changed alertmanager.yaml. Receivers are identified by
+// name and routes and inhibit rules by a one-line description; secret values are redacted.
+type configDiff struct {
+	// Time is when the change was written, in RFC 3339 format.
+	Time string `json:"time,omitempty"`
+	// Created is true if alertmanager-main had no parseable config before the write.
+	Created bool `json:"created,omitempty"`
+
+	ReceiversAdded   []string         `json:"receiversAdded,omitempty"`
+	ReceiversRemoved []string         `json:"receiversRemoved,omitempty"`
+	ReceiversChanged []receiverChange `json:"receiversChanged,omitempty"`
+
+	RoutesAdded   []string `json:"routesAdded,omitempty"`
+	RoutesRemoved []string `json:"routesRemoved,omitempty"`
+	// RoutesReordered is true if the same routes are evaluated in a different order.
+	RoutesReordered bool `json:"routesReordered,omitempty"`
+
+	InhibitRulesAdded   []string `json:"inhibitRulesAdded,omitempty"`
+	InhibitRulesRemoved []string `json:"inhibitRulesRemoved,omitempty"`
+
+	GlobalChanged    bool `json:"globalChanged,omitempty"`
+	TemplatesChanged bool `json:"templatesChanged,omitempty"`
+}
+
+// receiverChange lists the fields that changed in a receiver present before and after a write.
+type receiverChange struct {
+	Name    string   `json:"name"`
+	Changes []string `json:"changes"`
+}
+
+// diffConfigs computes the changes from previous to current. previous may be nil.
+func diffConfigs(previous, current *alertmanager.Config) *configDiff {
+	if previous == nil {
+		return &configDiff{
+			Created:        true,
+			ReceiversAdded: receiverNames(current),
+		}
+	}
+
+	diff := &configDiff{
+		GlobalChanged:    !reflect.DeepEqual(previous.Global, current.Global),
+		TemplatesChanged: !slices.Equal(previous.Templates, current.Templates),
+	}
+
+	before := receiversByName(previous)
+	after := receiversByName(current)
+	for _, name := range receiverNames(current) {
+		if _, ok := before[name]; !ok {
+			diff.ReceiversAdded = append(diff.ReceiversAdded, name)
+			continue
+		}
+		if changes := diffFields(flattenFields(before[name]), flattenFields(after[name])); len(changes) > 0 {
+			diff.ReceiversChanged = append(diff.ReceiversChanged, receiverChange{Name: name, Changes: changes})
+		}
+	}
+	for _, name := range receiverNames(previous) {
+		if _, ok := after[name]; !ok {
+			diff.ReceiversRemoved = append(diff.ReceiversRemoved, name)
+		}
+	}
+
+	routesBefore := describeRoutes(previous.Route)
+	routesAfter := describeRoutes(current.Route)
+	diff.RoutesAdded, diff.RoutesRemoved = diffLists(routesBefore, routesAfter)
+	if len(diff.RoutesAdded) == 0 && len(diff.RoutesRemoved) == 0 && !slices.Equal(routesBefore, routesAfter) {
+		diff.RoutesReordered = true
+	}
+
+	diff.InhibitRulesAdded, diff.InhibitRulesRemoved = diffLists(describeInhibitRules(previous.InhibitRules), describeInhibitRules(current.InhibitRules))
+	return diff
+}
+
+// summary describes the diff in one line, for Events.
+func (d *configDiff) summary() string {
+	if d.Created {
+		return fmt.Sprintf("Alertmanager config created with receivers: %s", strings.Join(d.ReceiversAdded, ", "))
+	}
+	return "Alertmanager config updated: " + strings.Join(d.changes(), "; ")
+}
+
+// changes describes each kind of change in the diff.
+func (d *configDiff) changes() []string {
+	changes := []string{}
+	if len(d.ReceiversAdded) > 0 {
+		changes = append(changes, fmt.Sprintf("receivers added: %s", strings.Join(d.ReceiversAdded, ", ")))
+	}
+	if len(d.ReceiversRemoved) > 0 {
+		changes = append(changes, fmt.Sprintf("receivers removed: %s", strings.Join(d.ReceiversRemoved, ", ")))
+	}
+	if len(d.ReceiversChanged) > 0 {
+		names := make([]string, 0, len(d.ReceiversChanged))
+		for _, rc := range d.ReceiversChanged {
+			names = append(names, rc.Name)
+		}
+		changes = append(changes, fmt.Sprintf("receivers changed: %s", strings.Join(names, ", ")))
+	}
+	if len(d.RoutesAdded) > 0 || len(d.RoutesRemoved) > 0 {
+		changes = append(changes, fmt.Sprintf("routes: %d added, %d removed", len(d.RoutesAdded), len(d.RoutesRemoved)))
+	}
+	if d.RoutesReordered {
+		changes = append(changes, "routes reordered")
+	}
+	if len(d.InhibitRulesAdded) > 0 || len(d.InhibitRulesRemoved) > 0 {
+		changes = append(changes, fmt.Sprintf("inhibit rules: %d added, %d removed", len(d.InhibitRulesAdded), len(d.InhibitRulesRemoved)))
+	}
+	if d.GlobalChanged {
+		changes = append(changes, "global settings changed")
+	}
+	if d.TemplatesChanged {
+		changes = append(changes, "templates changed")
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "formatting changed")
+	}
+	return changes
+}
+
+// annotation returns the diff encoded for annotationLastChange.
+func (d *configDiff) annotation() string {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// receiverNames returns the names of the receivers in a config.
+func receiverNames(amconfig *alertmanager.Config) []string {
+	names := make([]string, 0, len(amconfig.Receivers))
+	for _, receiver := range amconfig.Receivers {
+		if receiver != nil {
+			names = append(names, receiver.Name)
+		}
+	}
+	return names
+}
+
+// receiversByName indexes the receivers in a config by name.
+func receiversByName(amconfig *alertmanager.Config) map[string]*alertmanager.Receiver {
+	receivers := map[string]*alertmanager.Receiver{}
+	for _, receiver := range amconfig.Receivers {
+		if receiver != nil {
+			receivers[receiver.Name] = receiver
+		}
+	}
+	return receivers
+}
+
+// flattenFields returns the leaf fields of v keyed by their path, e.g.
+// "pagerduty_configs[0].severity". Secret values are redacted.
+func flattenFields(v interface{}) map[string]string {
+	fields := map[string]string{}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return fields
+	}
+	flattenInto(fields, "", generic)
+	return fields
+}
+
+func flattenInto(fields map[string]string, path string, v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenInto(fields, childPath, child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			flattenInto(fields, fmt.Sprintf("%s[%d]", path, i), child)
+		}
+	default:
+		field := path[strings.LastIndex(path, ".")+1:]
+		if slices.Contains(redactedFields, field) {
+			// Keep a fingerprint so a changed secret still shows up as a change
+			fields[path] = redactedValue + " " + hashBytes([]byte(fmt.Sprint(value)))[:8]
+			return
+		}
+		fields[path] = fmt.Sprint(value)
+	}
+}
+
+// diffFields describes the differences between two sets of flattened fields.
+func diffFields(before, after map[string]string) []string {
+	changes := []string{}
+	for path, newValue := range after {
+		oldValue, ok := before[path]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s added: %s", path, redact(newValue)))
+		case oldValue != newValue:
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", path, redact(oldValue), redact(newValue)))
+		}
+	}
+	for path, oldValue := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, fmt.Sprintf("%s removed: %s", path, redact(oldValue)))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// redact drops the fingerprint from a redacted value so it is not exposed in a diff.
+func redact(value string) string {
+	if strings.HasPrefix(value, redactedValue) {
+		return redactedValue
+	}
+	return value
+}
+
+// describeRoutes returns a description of every route below the root, in evaluation
+// order. Nested routes are prefixed with their parent's description.
+func describeRoutes(root *alertmanager.Route) []string {
+	descriptions := []string{}
+	if root == nil {
+		return descriptions
+	}
+	var walk func(prefix string, route *alertmanager.Route)
+	walk = func(prefix string, route *alertmanager.Route) {
+		for _, child := range route.Routes {
+			if child == nil {
+				continue
+			}
+			description := prefix + describeRoute(child)
+			descriptions = append(descriptions, description)
+			walk(description+" > ", child)
+		}
+	}
+	walk("", root)
+	return descriptions
+}
+
+// describeRoute describes a route as receiver{matchers} followed by any non-default settings.
+func describeRoute(route *alertmanager.Route) string {
+	description := route.Receiver + "{" + describeMatchers(route.Match, route.MatchRE) + "}"
+	if len(route.Matchers) > 0 {
+		description += "[" + strings.Join(route.Matchers, ",") + "]"
+	}
+	if route.Continue {
+		description += " continue"
+	}
+	if len(route.GroupByStr) > 0 {
+		description += " group_by=" + strings.Join(route.GroupByStr, ",")
+	}
+	if route.GroupWait != "" {
+		description += " group_wait=" + route.GroupWait
+	}
+	if route.GroupInterval != "" {
+		description += " group_interval=" + route.GroupInterval
+	}
+	if route.RepeatInterval != "" {
+		description += " repeat_interval=" + route.RepeatInterval
+	}
+	return description
+}
+
+// describeInhibitRules describes each inhibit rule as {source} -> {target} equal[labels].
+func describeInhibitRules(rules []*alertmanager.InhibitRule) []string {
+	descriptions := []string{}
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		descriptions = append(descriptions, fmt.Sprintf("{%s} -> {%s} equal[%s]",
+			describeMatchers(rule.SourceMatch, rule.SourceMatchRE),
+			describeMatchers(rule.TargetMatch, rule.TargetMatchRE),
+			strings.Join(rule.Equal, ",")))
+	}
+	return descriptions
+}
+
+// describeMatchers renders equality and regex matchers in PromQL style, sorted by label.
+func describeMatchers(match, matchRE map[string]string) string {
+	matchers := make([]string, 0, len(match)+len(matchRE))
+	for label, value := range match {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", label, value))
+	}
+	for label, value := range matchRE {
+		if len(value) > maxMatcherValueLength {
+			// Keep a fingerprint so different long regexes still compare as different
+			value = fmt.Sprintf("%s...(%s)", value[:maxMatcherValueLength], hashBytes([]byte(value))[:8])
+		}
+		matchers = append(matchers, fmt.Sprintf("%s=~%q", label, value))
+	}
+	sort.Strings(matchers)
+	return strings.Join(matchers, ",")
+}
+
+// diffLists returns the entries only in after, and only in before. Duplicates are
+// compared by count.
+func diffLists(before, after []string) (added, removed []string) {
+	counts := map[string]int{}
+	for _, entry := range before {
+		counts[entry]++
+	}
+	for _, entry := range after {
+		if counts[entry] > 0 {
+			counts[entry]--
+			continue
+		}
+		added = append(added, entry)
+	}
+	remaining := map[string]int{}
+	for _, entry := range after {
+		remaining[entry]++
+	}
+	for _, entry := range before {
+		if remaining[entry] > 0 {
+			remaining[entry]--
+			continue
+		}
+		removed = append(removed, entry)
+	}
+	return added, removed
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/coverage"
+	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
+)
+
+// routingCoverageInterval is how often the routing coverage report runs while it is enabled.
+const routingCoverageInterval = time.Hour
+
+// routingCoverageCheckInterval is how often spec.features.routingCoverage is checked, so the
+// first report runs soon after the operator starts or the feature is enabled.
+const routingCoverageCheckInterval = time.Minute
+
+// runRoutingCoverage reports the routing coverage of alertmanager-main once
+// spec.features.routingCoverage is enabled, then every routingCoverageInterval while it stays
+// enabled, until ctx is done.
+func (r *SecretReconciler) runRoutingCoverage(ctx context.Context) error {
+	reqLogger := log.WithName("routing_coverage")
+	ticker := time.NewTicker(routingCoverageCheckInterval)
+	defer ticker.Stop()
+	var lastRun time.Time
+	for {
+		enabled := config.GetSettings().RoutingCoverage
+		if routingCoverageDue(enabled, lastRun, time.Now()) {
+			lastRun = time.Now()
+			if _, err := r.reportRoutingCoverage(ctx, reqLogger); err != nil {
+				reqLogger.Error(err, "ERROR: Unable to report routing coverage")
+			}
+		} else if !enabled {
+			lastRun = time.Time{}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// routingCoverageDue is true if the report is enabled and hasn't run since it was enabled,
+// or last ran routingCoverageInterval ago.
+func routingCoverageDue(enabled bool, lastRun, now time.Time) bool {
+	return enabled && (lastRun.IsZero() || now.Sub(lastRun) >= routingCoverageInterval)
+}
+
+// reportRoutingCoverage routes the alerts of all PrometheusRules through alertmanager-main,
+// exports the outcomes as metrics and logs the alerts that could page falling to the default
+// null receiver and the suppression routes matching no alert.
+func (r *SecretReconciler) reportRoutingCoverage(ctx context.Context, reqLogger logr.Logger) (*coverage.Report, error) {
+	// PrometheusRules are read cluster-wide, outside the cache scoped to the managed namespace
+	ruleList := &monitoringv1.PrometheusRuleList{}
+	if err := r.apiReader().List(ctx, ruleList); err != nil {
+		return nil, fmt.Errorf("unable to list PrometheusRules: %w", err)
+	}
+
+	platformNamespaces, err := listNamespaceMetadata(ctx, r.Client, client.MatchingLabels{coverage.PlatformNamespaceLabel: "true"})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list platform namespaces: %w", err)
+	}
+	platform := map[string]bool{}
+	for _, namespace := range platformNamespaces {
+		platform[namespace.Name] = true
+	}
+
+	target := platformTarget()
+	_, amconfig := r.currentAlertManagerConfig(ctx, target)
+	if amconfig == nil {
+		return nil, fmt.Errorf("unable to read the config in %s/%s", target.namespace, target.secretName)
+	}
+	report, err := coverage.NewReport(amconfig, coverage.AlertsFromPrometheusRules(ruleList.Items, func(namespace string) bool { return platform[namespace] }))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := report.Counts()
+	outcomes := make(map[string]int, len(coverage.Outcomes))
+	for _, outcome := range coverage.Outcomes {
+		outcomes[string(outcome)] = counts[outcome]
+	}
+	metrics.UpdateRoutingCoverageMetrics(outcomes, len(report.UnusedSuppressions))
+
+	for _, row := range report.Rows {
+		if row.Outcome == coverage.OutcomeUnrouted {
+			reqLogger.Info("WARNING: Alert that could page falls to the default null receiver", "Alert", row.Alert.Name, "Namespace", row.Alert.Namespace)
+		}
+	}
+	for _, route := range report.UnusedSuppressions {
+		reqLogger.Info("WARNING: Route to the null receiver matches no alert", "Route", route)
+	}
+	reqLogger.Info("INFO: Routing coverage reported", "Alerts", len(report.Rows), "Unrouted", counts[coverage.OutcomeUnrouted], "UnusedSuppressions", len(report.UnusedSuppressions))
+	return report, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Annotations on alertmanager-main used to detect and control manual edits.
+const (
+	// annotationConfigHash holds the SHA-256 of the alertmanager.yaml the operator last wrote.
+	annotationConfigHash = "configure-alertmanager-operator.openshift.io/config-hash"
+	// annotationPaused stops the operator writing alertmanager-main for as long as it is present.
+	annotationPaused = "configure-alertmanager-operator.openshift.io/paused"
+)
+
+// Reasons reported on the ConfigApplied and Ready conditions when the write is skipped.
+const (
+	conditionReasonManagementPaused = "ManagementPaused"
+	conditionReasonDriftPreserved   = "DriftPreserved"
+)
+
+// checkManualEdits looks for edits to the target secret made outside the operator and
+// applies the drift policy. It returns a condition reason and message if the rendered
+// config should not be written, or empty strings if the write should go ahead.
+//
+// An edit is detected when the hash of alertmanager.yaml no longer matches the
+// annotationConfigHash stamped by the last write. Secrets written before the annotation
+// was introduced have no hash and are never considered drifted.
+func (r *SecretReconciler) checkManualEdits(ctx context.Context, reqLogger logr.Logger, target alertmanagerTarget, amconfig *alertmanager.Config) (string, string) {
+	secret, currentConfig := r.currentAlertManagerConfig(ctx, target)
+	if secret == nil {
+		target.updateDriftMetrics(false, false)
+		return "", ""
+	}
+
+	if _, paused := secret.Annotations[annotationPaused]; paused {
+		message := fmt.Sprintf("Management of %s/%s is paused; remove the %s annotation to resume", secret.Namespace, secret.Name, annotationPaused)
+		reqLogger.Info("INFO: Alertmanager config management is paused, not writing "+secret.Name, "annotation", annotationPaused)
+		target.updateDriftMetrics(false, true)
+		r.recordEvent(secret, corev1.EventTypeNormal, eventReasonManagementPaused, eventActionDetectDrift, message)
+		return conditionReasonManagementPaused, message
+	}
+
+	appliedHash, ok := secret.Annotations[annotationConfigHash]
+	currentHash := hashBytes(secret.Data["alertmanager.yaml"])
+	if !ok || appliedHash == currentHash {
+		delete(r.driftedHashes, target)
+		target.updateDriftMetrics(false, false)
+		return "", ""
+	}
+
+	// Describe the manual edit relative to the config the operator would write.
+	// Both sides are parsed from YAML so defaults applied on unmarshal don't show up as changes.
+	var rendered *alertmanager.Config
+	if amconfigbyte, err := yaml.Marshal(amconfig); err == nil {
+		rendered = parseAlertManagerConfig(amconfigbyte)
+	}
+	edit := "unparseable alertmanager.yaml"
+	if currentConfig != nil && rendered != nil {
+		edit = strings.Join(diffConfigs(rendered, currentConfig).changes(), "; ")
+	}
+
+	policy := config.GetSettings().DriftPolicy
+	newDrift := r.driftedHashes[target] != currentHash
+	if r.driftedHashes == nil {
+		r.driftedHashes = map[alertmanagerTarget]string{}
+	}
+	r.driftedHashes[target] = currentHash
+	if newDrift {
+		target.incDriftDetected(policy)
+	}
+
+	if policy == config.DriftPolicyPreserveAndAlert {
+		message := fmt.Sprintf("%s/%s was edited outside the operator (%s); preserving the edit until it is reverted or the drift policy is changed", secret.Namespace, secret.Name, edit)
+		reqLogger.Info("WARNING: Alertmanager config was edited outside the operator, preserving it", "policy", policy, "edit", edit)
+		target.updateDriftMetrics(true, false)
+		r.recordEvent(secret, corev1.EventTypeWarning, eventReasonConfigDriftDetected, eventActionDetectDrift, message)
+		return conditionReasonDriftPreserved, message
+	}
+
+	message := fmt.Sprintf("%s/%s was edited outside the operator (%s); overwriting the edit with the rendered config", secret.Namespace, secret.Name, edit)
+	reqLogger.Info("WARNING: Alertmanager config was edited outside the operator, overwriting it", "policy", policy, "edit", edit)
+	target.updateDriftMetrics(false, false)
+	r.recordEvent(secret, corev1.EventTypeWarning, eventReasonConfigDriftDetected, eventActionDetectDrift, message)
+	return "", ""
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Event reasons recorded by the Secret controller.
+const (
+	eventReasonClusterTypeDetectionFailure = "ClusterTypeDetectionFailure"
+	eventReasonConfigValidationFailure     = "AlertmanagerConfigValidationFailure"
+	eventReasonConfigUpdated               = "AlertmanagerConfigUpdated"
+	eventReasonIntegrationEnabled          = "IntegrationEnabled"
+	eventReasonIntegrationDisabled         = "IntegrationDisabled"
+	eventReasonConfigDriftDetected         = "AlertmanagerConfigDriftDetected"
+	eventReasonManagementPaused            = "AlertmanagerConfigManagementPaused"
+	eventReasonOverridesRejected           = "AlertmanagerOverridesRejected"
+	eventReasonAlertmanagerConfigRejected  = "AlertmanagerConfigRejected"
+	eventReasonNamespaceEntryRejected      = "NamespaceEntryRejected"
+)
+
+// Event actions recorded by the Secret controller.
+const (
+	eventActionDetectClusterType            = "DetectClusterType"
+	eventActionValidateConfig               = "ValidateConfig"
+	eventActionWriteConfig                  = "WriteConfig"
+	eventActionDetectDrift                  = "DetectDrift"
+	eventActionMergeOverrides               = "MergeOverrides"
+	eventActionAggregateAlertmanagerConfigs = "AggregateAlertmanagerConfigs"
+	eventActionParseNamespaces              = "ParseNamespaces"
+)
+
+// Integrations reported in ConfigureAlertmanager status.activeIntegrations.
+const (
+	integrationPagerDuty        = "PagerDuty"
+	integrationAutomation       = "Automation"
+	integrationGoAlert          = "GoAlert"
+	integrationGoAlertHeartbeat = "GoAlertHeartbeat"
+	integrationDeadMansSnitch   = "DeadMansSnitch"
+	integrationOCMAgent         = "OCMAgent"
+)
+
+// maxEventNoteLength is the longest note the events API accepts.
+const maxEventNoteLength = 1024
+
+// eventSubject fills in obj from the cache so the Event carries its UID. The object is
+// returned unchanged if it can't be read, e.g. because it was deleted.
+func (r *SecretReconciler) eventSubject(ctx context.Context, obj client.Object) client.Object {
+	_ = r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	return obj
+}
+
+// alertmanagerSecret returns the alertmanager-main Secret as the subject of an Event.
+func (r *SecretReconciler) alertmanagerSecret(ctx context.Context) client.Object {
+	return r.eventSubject(ctx, platformTarget().secret())
+}
+
+// recordEvent records an Event about obj. The recorder deduplicates repeated Events
+// into a series, so this is safe to call on every reconcile.
+func (r *SecretReconciler) recordEvent(obj client.Object, eventType, reason, action, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	if len(message) > maxEventNoteLength {
+		message = message[:maxEventNoteLength-3] + "..."
+	}
+	r.Recorder.Eventf(obj, nil, eventType, reason, action, "%s", message)
+}
+
+// recordConfigChangeEvents records Events for integrations enabled or disabled by a
+// config write to target, and a summary of the change to alertmanager.yaml.
+func (r *SecretReconciler) recordConfigChangeEvents(ctx context.Context, target alertmanagerTarget, previous, current *alertmanager.Config, diff *configDiff) {
+	before := integrationsInConfig(previous)
+	after := integrationsInConfig(current)
+	for _, integration := range after {
+		if !slices.Contains(before, integration) {
+			r.recordEvent(r.eventSubject(ctx, integrationSource(target.namespace, integration)), corev1.EventTypeNormal, eventReasonIntegrationEnabled, eventActionWriteConfig,
+				fmt.Sprintf("%s integration enabled in %s", integration, target))
+		}
+	}
+	for _, integration := range before {
+		if !slices.Contains(after, integration) {
+			r.recordEvent(r.eventSubject(ctx, integrationSource(target.namespace, integration)), corev1.EventTypeNormal, eventReasonIntegrationDisabled, eventActionWriteConfig,
+				fmt.Sprintf("%s integration disabled in %s", integration, target))
+		}
+	}
+
+	r.recordEvent(r.eventSubject(ctx, target.secret()), corev1.EventTypeNormal, eventReasonConfigUpdated, eventActionWriteConfig,
+		diff.summary())
+}
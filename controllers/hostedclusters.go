@@ -0,0 +1,81 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+)
+
+const (
+	// HyperShift labels the namespace of each HostedControlPlane with this key
+	hostedControlPlaneLabelKey = "hypershift.openshift.io/hosted-control-plane"
+
+	// label on a HostedControlPlane namespace holding the OCM ID of the hosted cluster
+	hostedClusterIDLabelKey = "api.openshift.com/id"
+)
+
+// listHostedControlPlanes returns the HostedControlPlane namespaces on a management cluster,
+// sorted by namespace. Namespaces without a hosted cluster ID label are skipped, since their
+// alerts couldn't be attributed to a cluster; they are still routed if managed-namespaces
+// lists them.
+func (r *SecretReconciler) listHostedControlPlanes(ctx context.Context, reqLogger logr.Logger) ([]render.HostedControlPlane, error) {
+	namespaces, err := listNamespaceMetadata(ctx, r.Client, client.HasLabels{hostedControlPlaneLabelKey})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list HostedControlPlane namespaces: %w", err)
+	}
+
+	hostedControlPlanes := []render.HostedControlPlane{}
+	for _, namespace := range namespaces {
+		clusterID := namespace.Labels[hostedClusterIDLabelKey]
+		if clusterID == "" {
+			reqLogger.Info("WARNING: HostedControlPlane namespace has no hosted cluster ID, not routing its alerts",
+				"namespace", namespace.Name, "label", hostedClusterIDLabelKey)
+			continue
+		}
+		hostedControlPlanes = append(hostedControlPlanes, render.HostedControlPlane{Namespace: namespace.Name, ClusterID: clusterID})
+	}
+	reqLogger.Info("INFO: Discovered HostedControlPlane namespaces", "Count", len(hostedControlPlanes))
+	return hostedControlPlanes, nil
+}
+
+// hostedControlPlanePredicate only passes events for namespaces that are, or were, a
+// HostedControlPlane namespace, and label changes that can affect routing.
+var hostedControlPlanePredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return isHostedControlPlaneNamespace(e.Object) },
+	DeleteFunc: func(e event.DeleteEvent) bool { return isHostedControlPlaneNamespace(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if !isHostedControlPlaneNamespace(e.ObjectOld) && !isHostedControlPlaneNamespace(e.ObjectNew) {
+			return false
+		}
+		return e.ObjectOld.GetLabels()[hostedClusterIDLabelKey] != e.ObjectNew.GetLabels()[hostedClusterIDLabelKey] ||
+			isHostedControlPlaneNamespace(e.ObjectOld) != isHostedControlPlaneNamespace(e.ObjectNew)
+	},
+	GenericFunc: func(e event.GenericEvent) bool { return isHostedControlPlaneNamespace(e.Object) },
+}
+
+func isHostedControlPlaneNamespace(obj client.Object) bool {
+	_, ok := obj.GetLabels()[hostedControlPlaneLabelKey]
+	return ok
+}
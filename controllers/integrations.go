@@ -0,0 +1,332 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Integration is a service alerts are sent to, configured from Secrets or ConfigMaps in the
+// namespace of the Alertmanager config. An integration is added by implementing Integration,
+// with Parse returning an implementation of render.Integration, and adding it to
+// integrationRegistry. The render.Integration can be defined alongside it.
+type Integration interface {
+	// Name identifies the integration in status.activeIntegrations and Events.
+	Name() string
+	// Sources are the Secrets and ConfigMaps the integration is configured from, by name.
+	// Changes to them are reconciled. The first is the subject of the integration's Events.
+	Sources() []client.Object
+	// GatedOnReadiness is true if the integration is left out until the cluster is ready,
+	// to avoid noise while it is installed.
+	GatedOnReadiness() bool
+	// Parse reads the integration's settings from its sources in namespace, to render its
+	// routes and receivers. It returns nil if the integration isn't configured.
+	Parse(r *SecretReconciler, reqLogger logr.Logger, namespace string, secretList *corev1.SecretList, cmList *corev1.ConfigMapList) render.Integration
+	// OwnsReceiver is true for the receivers the integration adds to alertmanager.yaml.
+	OwnsReceiver(receiver string) bool
+	// UpdateMetrics reports the integration's metrics once the config was rendered.
+	UpdateMetrics(secretList *corev1.SecretList, amconfig *alertmanager.Config)
+}
+
+// integrationRegistry lists the integrations, in the order their routes are added to
+// alertmanager.yaml and they are reported in. That is render.IntegrationOrder, shared with
+// the rendering scenarios, followed by any integration not named there.
+var integrationRegistry = inIntegrationOrder(
+	deadMansSnitchIntegration{},
+	ocmAgentIntegration{},
+	automationIntegration{},
+	pagerdutyIntegration{},
+	goalertIntegration{},
+	goalertHeartbeatIntegration{},
+)
+
+// inIntegrationOrder sorts integrations by the position of their names in
+// render.IntegrationOrder. Integrations not named there keep their order, after the others.
+func inIntegrationOrder(integrations ...Integration) []Integration {
+	position := func(integration Integration) int {
+		if i := slices.Index(render.IntegrationOrder, integration.Name()); i >= 0 {
+			return i
+		}
+		return len(render.IntegrationOrder)
+	}
+	slices.SortStableFunc(integrations, func(a, b Integration) int {
+		return position(a) - position(b)
+	})
+	return integrations
+}
+
+// parseIntegrations returns the integrations configured in namespace. Integrations gated on
+// readiness are left out until the cluster is ready.
+func (r *SecretReconciler) parseIntegrations(reqLogger logr.Logger, namespace string, secretList *corev1.SecretList, cmList *corev1.ConfigMapList, clusterReady bool) []render.Integration {
+	var configured []render.Integration
+	for _, integration := range integrationRegistry {
+		if integration.GatedOnReadiness() && !clusterReady {
+			reqLogger.Info("INFO: Cluster is not ready; skipping integration", "Integration", integration.Name())
+			continue
+		}
+		if c := integration.Parse(r, reqLogger, namespace, secretList, cmList); c != nil {
+			reqLogger.Info("INFO: Configuring integration", "Integration", integration.Name())
+			configured = append(configured, c)
+		}
+	}
+	return configured
+}
+
+// isIntegrationSourceName returns true for the Secrets and ConfigMaps integrations are configured from.
+func isIntegrationSourceName(name string) bool {
+	for _, integration := range integrationRegistry {
+		for _, source := range integration.Sources() {
+			if source.GetName() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// integrationSource returns the object an integration is configured from in namespace.
+func integrationSource(namespace, name string) client.Object {
+	for _, integration := range integrationRegistry {
+		if integration.Name() == name {
+			source := integration.Sources()[0]
+			source.SetNamespace(namespace)
+			return source
+		}
+	}
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+// integrationsInConfig lists the integrations with a receiver in the given config.
+func integrationsInConfig(amconfig *alertmanager.Config) []string {
+	integrations := []string{}
+	if amconfig == nil {
+		return integrations
+	}
+	for _, integration := range integrationRegistry {
+		for _, receiver := range amconfig.Receivers {
+			if receiver != nil && integration.OwnsReceiver(receiver.Name) {
+				integrations = append(integrations, integration.Name())
+				break
+			}
+		}
+	}
+	return integrations
+}
+
+// updateIntegrationMetrics reports the metrics of every integration.
+func updateIntegrationMetrics(secretList *corev1.SecretList, amconfig *alertmanager.Config) {
+	metrics.UpdateAlertmanagerSecretMetric(secretInList(logr.Discard(), secretNameAlertmanager, secretList))
+	for _, integration := range integrationRegistry {
+		integration.UpdateMetrics(secretList, amconfig)
+	}
+}
+
+// secretMetrics returns whether the named Secret exists and, if alertmanager-main exists
+// too, whether amconfig has the receiver configured from it.
+func secretMetrics(secretList *corev1.SecretList, name string, amconfig *alertmanager.Config, receiver string) (exists bool, inConfig bool) {
+	exists = secretInList(logr.Discard(), name, secretList)
+	if !exists || amconfig == nil || !secretInList(logr.Discard(), secretNameAlertmanager, secretList) {
+		return exists, false
+	}
+	return exists, slices.ContainsFunc(amconfig.Receivers, func(r *alertmanager.Receiver) bool { return r != nil && r.Name == receiver })
+}
+
+func secretSource(name string) client.Object {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+// pagerdutyIntegration pages SRE through PagerDuty for alerts from the managed namespaces.
+// Teams owning namespaces can have their own PagerDuty service.
+type pagerdutyIntegration struct{}
+
+func (pagerdutyIntegration) Name() string { return integrationPagerDuty }
+func (pagerdutyIntegration) Sources() []client.Object {
+	return []client.Object{secretSource(secretNamePD), secretSource(secretNamePDTeams)}
+}
+func (pagerdutyIntegration) GatedOnReadiness() bool { return true }
+
+func (pagerdutyIntegration) Parse(r *SecretReconciler, reqLogger logr.Logger, namespace string, secretList *corev1.SecretList, _ *corev1.ConfigMapList) render.Integration {
+	if !secretInList(reqLogger, secretNamePD, secretList) {
+		reqLogger.Info("INFO: Pager Duty secret does not exist")
+		return nil
+	}
+	routingKey := readSecretKey(r, secretNamePD, namespace, secretKeyPD)
+	if routingKey == "" {
+		return nil
+	}
+	return render.PagerDuty{RoutingKey: routingKey, TeamRoutingKeys: r.readTeamRoutingKeys(reqLogger, secretList, namespace)}
+}
+
+func (pagerdutyIntegration) OwnsReceiver(receiver string) bool {
+	return receiver == receiverPagerduty
+}
+
+func (pagerdutyIntegration) UpdateMetrics(secretList *corev1.SecretList, amconfig *alertmanager.Config) {
+	metrics.UpdatePagerDutySecretMetrics(secretMetrics(secretList, secretNamePD, amconfig, receiverPagerduty))
+}
+
+// automationIntegration sends alerts with matching labels to automation services such as CAD.
+type automationIntegration struct{}
+
+func (automationIntegration) Name() string { return integrationAutomation }
+
+func (automationIntegration) Sources() []client.Object {
+	var sources []client.Object
+	for _, route := range automationRoutes(logr.Discard(), config.GetSettings().AutomationRoutes) {
+		sources = append(sources, secretSource(route.secretName))
+	}
+	return sources
+}
+
+func (automationIntegration) GatedOnReadiness() bool { return true }
+
+func (automationIntegration) Parse(r *SecretReconciler, reqLogger logr.Logger, namespace string, secretList *corev1.SecretList, _ *corev1.ConfigMapList) render.Integration {
+	routes := r.readAutomationRoutes(reqLogger, secretList, namespace)
+	if len(routes) == 0 {
+		return nil
+	}
+	return render.Automation{Routes: routes}
+}
+
+func (automationIntegration) OwnsReceiver(receiver string) bool {
+	return receiver == receiverCADPagerduty || strings.HasPrefix(receiver, receiverAutomationPrefix)
+}
+
+func (automationIntegration) UpdateMetrics(*corev1.SecretList, *alertmanager.Config) {}
+
+// goalertIntegration sends alerts from the managed namespaces to GoAlert, paging for the
+// high severities only.
+type goalertIntegration struct{}
+
+func (goalertIntegration) Name() string { return integrationGoAlert }
+func (goalertIntegration) Sources() []client.Object {
+	return []client.Object{secretSource(secretNameGoalert)}
+}
+func (goalertIntegration) GatedOnReadiness() bool { return true }
+
+func (goalertIntegration) Parse(r *SecretReconciler, reqLogger logr.Logger, namespace string, secretList *corev1.SecretList, _ *corev1.ConfigMapList) render.Integration {
+	if !secretInList(reqLogger, secretNameGoalert, secretList) {
+		reqLogger.Info("INFO: Goalert secret does not exist")
+		return nil
+	}
+	lowURL := readSecretKey(r, secretNameGoalert, namespace, secretKeyGoalertLow)
+	highURL := readSecretKey(r, secretNameGoalert, namespace, secretKeyGoalertHigh)
+	if lowURL == "" || highURL == "" {
+		reqLogger.Info("INFO: Not configuring GoAlert receivers")
+		return nil
+	}
+	return render.GoAlert{LowURL: lowURL, HighURL: highURL}
+}
+
+func (goalertIntegration) OwnsReceiver(receiver string) bool {
+	return receiver == receiverGoAlertLow || receiver == receiverGoAlertHigh
+}
+
+func (goalertIntegration) UpdateMetrics(secretList *corev1.SecretList, amconfig *alertmanager.Config) {
+	metrics.UpdateGoAlertSecretMetrics(secretMetrics(secretList, secretNameGoalert, amconfig, receiverGoAlertLow))
+}
+
+// goalertHeartbeatIntegration reports that Alertmanager is up to a GoAlert heartbeat monitor.
+type goalertHeartbeatIntegration struct{}
+
+func (goalertHeartbeatIntegration) Name() string { return integrationGoAlertHeartbeat }
+func (goalertHeartbeatIntegration) Sources() []client.Object {
+	return []client.Object{secretSource(secretNameGoalert)}
+}
+func (goalertHeartbeatIntegration) GatedOnReadiness() bool { return true }
+
+func (goalertHeartbeatIntegration) Parse(r *SecretReconciler, reqLogger logr.Logger, namespace string, secretList *corev1.SecretList, _ *corev1.ConfigMapList) render.Integration {
+	if !secretInList(reqLogger, secretNameGoalert, secretList) {
+		return nil
+	}
+	url := readSecretKey(r, secretNameGoalert, namespace, secretKeyGoalertHeartbeat)
+	if url == "" {
+		reqLogger.Info("INFO: Not configuring GoAlert Heartbeat receivers")
+		return nil
+	}
+	return render.GoAlertHeartbeat{URL: url}
+}
+
+func (goalertHeartbeatIntegration) OwnsReceiver(receiver string) bool {
+	return receiver == receiverGoAlertHeartbeat
+}
+
+func (goalertHeartbeatIntegration) UpdateMetrics(*corev1.SecretList, *alertmanager.Config) {}
+
+// deadMansSnitchIntegration reports that Alertmanager is up to Dead Man's Snitch. It isn't
+// gated on readiness, so an installing cluster is known to be alive.
+type deadMansSnitchIntegration struct{}
+
+func (deadMansSnitchIntegration) Name() string { return integrationDeadMansSnitch }
+func (deadMansSnitchIntegration) Sources() []client.Object {
+	return []client.Object{secretSource(secretNameDMS)}
+}
+func (deadMansSnitchIntegration) GatedOnReadiness() bool { return false }
+
+func (deadMansSnitchIntegration) Parse(r *SecretReconciler, reqLogger logr.Logger, namespace string, secretList *corev1.SecretList, _ *corev1.ConfigMapList) render.Integration {
+	if !secretInList(reqLogger, secretNameDMS, secretList) {
+		reqLogger.Info("INFO: Dead Man's Snitch secret does not exist")
+		return nil
+	}
+	url := readSecretKey(r, secretNameDMS, namespace, secretKeyDMS)
+	if url == "" {
+		return nil
+	}
+	return render.DeadMansSnitch{URL: url}
+}
+
+func (deadMansSnitchIntegration) OwnsReceiver(receiver string) bool {
+	return receiver == receiverWatchdog
+}
+
+func (deadMansSnitchIntegration) UpdateMetrics(secretList *corev1.SecretList, amconfig *alertmanager.Config) {
+	metrics.UpdateDMSSecretMetrics(secretMetrics(secretList, secretNameDMS, amconfig, receiverWatchdog))
+}
+
+// ocmAgentIntegration sends managed notifications to OCM Agent, which sends them to the customer.
+type ocmAgentIntegration struct{}
+
+func (ocmAgentIntegration) Name() string { return integrationOCMAgent }
+func (ocmAgentIntegration) Sources() []client.Object {
+	return []client.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: cmNameOcmAgent}}}
+}
+func (ocmAgentIntegration) GatedOnReadiness() bool { return false }
+
+func (ocmAgentIntegration) Parse(r *SecretReconciler, reqLogger logr.Logger, namespace string, _ *corev1.SecretList, cmList *corev1.ConfigMapList) render.Integration {
+	url := r.readOCMAgentServiceURLFromConfig(reqLogger, cmList, namespace)
+	if url == "" {
+		return nil
+	}
+	return render.OCMAgent{URL: url}
+}
+
+func (ocmAgentIntegration) OwnsReceiver(receiver string) bool {
+	return receiver == receiverOCMAgent
+}
+
+func (ocmAgentIntegration) UpdateMetrics(*corev1.SecretList, *alertmanager.Config) {}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+)
+
+const (
+	// Namespaces labelled "true" with this key are routed like those in managed-namespaces
+	managedAlertingLabelKey = "openshift.io/managed-alerting"
+
+	// optional label on a managed-alerting namespace: the lowest alert severity that pages
+	managedAlertingSeverityLabelKey = "openshift.io/managed-alerting-severity"
+
+	// optional label on a managed-alerting namespace: the team that owns its alerts
+	managedAlertingTeamLabelKey = "openshift.io/managed-alerting-team"
+)
+
+// namespaceListGVK lists Namespaces as metadata only
+var namespaceListGVK = corev1.SchemeGroupVersion.WithKind("NamespaceList")
+
+// listNamespaceMetadata lists the metadata of Namespaces matching the options, so only
+// metadata is cached.
+func listNamespaceMetadata(ctx context.Context, c client.Client, opts ...client.ListOption) ([]metav1.PartialObjectMetadata, error) {
+	namespaces := &metav1.PartialObjectMetadataList{}
+	namespaces.SetGroupVersionKind(namespaceListGVK)
+	if err := c.List(ctx, namespaces, opts...); err != nil {
+		return nil, err
+	}
+	sort.Slice(namespaces.Items, func(i, j int) bool {
+		return namespaces.Items[i].Name < namespaces.Items[j].Name
+	})
+	return namespaces.Items, nil
+}
+
+// discoverNamespaces returns the namespaces labelled for managed alerting. Those without a
+// severity or team label are returned as regular expressions to add to the ConfigMap
+// namespaces; the others need routes of their own. An unknown severity is ignored.
+func (r *SecretReconciler) discoverNamespaces(ctx context.Context, reqLogger logr.Logger) (namespaceList []string, discovered []render.Namespace, err error) {
+	namespaces, err := listNamespaceMetadata(ctx, r.Client, client.MatchingLabels{managedAlertingLabelKey: "true"})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to list managed-alerting namespaces: %w", err)
+	}
+
+	for _, namespace := range namespaces {
+		severity := namespace.Labels[managedAlertingSeverityLabelKey]
+		if severity != "" && !slices.Contains(render.PagingSeverities, severity) {
+			reqLogger.Info("WARNING: Ignoring unknown severity on managed-alerting namespace",
+				"namespace", namespace.Name, "severity", severity, "valid", render.PagingSeverities)
+			severity = ""
+		}
+		team := namespace.Labels[managedAlertingTeamLabelKey]
+		if severity == "" && team == "" {
+			namespaceList = append(namespaceList, "^"+regexp.QuoteMeta(namespace.Name)+"$")
+			continue
+		}
+		discovered = append(discovered, render.Namespace{Name: namespace.Name, MinSeverity: severity, Team: team})
+	}
+	reqLogger.Info("INFO: Discovered managed-alerting namespaces", "Count", len(namespaces))
+	return namespaceList, discovered, nil
+}
+
+// managedAlertingPredicate passes events for namespaces that are, or were, labelled for
+// managed alerting, when a label that affects routing changes.
+var managedAlertingPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return isManagedAlertingNamespace(e.Object) },
+	DeleteFunc: func(e event.DeleteEvent) bool { return isManagedAlertingNamespace(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if !isManagedAlertingNamespace(e.ObjectOld) && !isManagedAlertingNamespace(e.ObjectNew) {
+			return false
+		}
+		for _, key := range []string{managedAlertingLabelKey, managedAlertingSeverityLabelKey, managedAlertingTeamLabelKey} {
+			if e.ObjectOld.GetLabels()[key] != e.ObjectNew.GetLabels()[key] {
+				return true
+			}
+		}
+		return false
+	},
+	GenericFunc: func(e event.GenericEvent) bool { return isManagedAlertingNamespace(e.Object) },
+}
+
+func isManagedAlertingNamespace(obj client.Object) bool {
+	return obj.GetLabels()[managedAlertingLabelKey] == "true"
+}
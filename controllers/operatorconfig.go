@@ -0,0 +1,412 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	monitoringv1beta1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1beta1"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/configure-alertmanager-operator/api/v1alpha1"
+	"github.com/openshift/configure-alertmanager-operator/config"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// getOperatorConfig returns the ConfigureAlertmanager singleton, or nil if it does not
+// exist or the CRD is not installed.
+func getOperatorConfig(ctx context.Context, c client.Reader) (*v1alpha1.ConfigureAlertmanager, error) {
+	operatorConfig := &v1alpha1.ConfigureAlertmanager{}
+	err := c.Get(ctx, client.ObjectKey{Name: v1alpha1.ConfigureAlertmanagerName}, operatorConfig)
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return operatorConfig, nil
+}
+
+// LoadManagedNamespace returns the managed namespace from the ConfigureAlertmanager
+// singleton, falling back to the operator namespace. It is called once at startup,
+// before the cache is created, so c should be an uncached reader.
+func LoadManagedNamespace(ctx context.Context, c client.Reader) (string, error) {
+	operatorConfig, err := getOperatorConfig(ctx, c)
+	if err != nil {
+		return config.OperatorNamespace, err
+	}
+	if operatorConfig == nil || operatorConfig.Spec.ManagedNamespace == "" {
+		return config.OperatorNamespace, nil
+	}
+	return operatorConfig.Spec.ManagedNamespace, nil
+}
+
+// LoadUserWorkloadEnabled returns whether the ConfigureAlertmanager singleton enables
+// management of the user-workload Alertmanager. Like LoadManagedNamespace, it is called
+// once at startup because it decides which namespaces are cached.
+func LoadUserWorkloadEnabled(ctx context.Context, c client.Reader) (bool, error) {
+	operatorConfig, err := getOperatorConfig(ctx, c)
+	if err != nil || operatorConfig == nil {
+		return false, err
+	}
+	return operatorConfig.Spec.UserWorkload.Enabled, nil
+}
+
+// LoadAlertmanagerConfigNamespaces returns the namespaces to cache AlertmanagerConfigs in for
+// the selection on the ConfigureAlertmanager singleton. Like LoadManagedNamespace, it is called
+// once at startup because it decides which namespaces are cached.
+func LoadAlertmanagerConfigNamespaces(ctx context.Context, c client.Reader) ([]string, error) {
+	operatorConfig, err := getOperatorConfig(ctx, c)
+	if err != nil || operatorConfig == nil {
+		return nil, err
+	}
+	return alertmanagerConfigNamespaces(operatorConfig.Spec.AlertmanagerConfigs), nil
+}
+
+// alertmanagerConfigNamespaces returns the namespaces the selected AlertmanagerConfigs can be
+// in: every namespace for a label selector, otherwise the selected namespaces.
+func alertmanagerConfigNamespaces(selection v1alpha1.AlertmanagerConfigSelection) []string {
+	if selection.Selector != nil {
+		return []string{metav1.NamespaceAll}
+	}
+	namespaces := slices.Clone(selection.Namespaces)
+	sort.Strings(namespaces)
+	return slices.Compact(namespaces)
+}
+
+// settingsFromOperatorConfig overlays the fields set on the ConfigureAlertmanager
+// resource onto the default Settings read from environment variables.
+func settingsFromOperatorConfig(defaults config.Settings, operatorConfig *v1alpha1.ConfigureAlertmanager) config.Settings {
+	settings := defaults
+	if operatorConfig == nil {
+		return settings
+	}
+	spec := operatorConfig.Spec
+
+	switch spec.Profile {
+	case v1alpha1.ProfileFedRAMP:
+		settings.Fedramp = true
+	case v1alpha1.ProfileStandard:
+		settings.Fedramp = false
+	}
+	if spec.Readiness.MaxClusterAgeMinutes != nil {
+		settings.MaxClusterAgeMinutes = int(*spec.Readiness.MaxClusterAgeMinutes)
+	}
+	if spec.Readiness.RequeueIntervalSeconds != nil {
+		settings.ReadinessRequeueInterval = time.Duration(*spec.Readiness.RequeueIntervalSeconds) * time.Second
+	}
+	if spec.Readiness.SettlingWindowMinutes != nil {
+		settings.SettlingWindowMinutes = int(*spec.Readiness.SettlingWindowMinutes)
+	}
+	if spec.Features.ResumeSettling != nil {
+		settings.ResumeSettling = *spec.Features.ResumeSettling
+	}
+	if spec.Features.CompactRoutes != nil {
+		settings.CompactRoutes = *spec.Features.CompactRoutes
+	}
+	if spec.Features.RoutingCoverage != nil {
+		settings.RoutingCoverage = *spec.Features.RoutingCoverage
+	}
+	if spec.DriftPolicy != "" {
+		settings.DriftPolicy = string(spec.DriftPolicy)
+	}
+	if len(spec.AlertmanagerConfigs.Namespaces) > 0 {
+		settings.AlertmanagerConfigNamespaces = spec.AlertmanagerConfigs.Namespaces
+	}
+	if spec.AlertmanagerConfigs.Selector != nil {
+		settings.AlertmanagerConfigSelector = spec.AlertmanagerConfigs.Selector
+	}
+	if spec.OutputMode != "" {
+		settings.OutputMode = string(spec.OutputMode)
+	}
+	if spec.ClusterType != "" {
+		settings.ClusterType = string(spec.ClusterType)
+	}
+	for _, route := range spec.AutomationRoutes {
+		settings.AutomationRoutes = append(settings.AutomationRoutes, config.AutomationRoute{
+			Name: route.Name, MatchLabels: route.MatchLabels, SecretName: route.SecretName, Continue: route.Continue,
+		})
+	}
+	return settings
+}
+
+// applyOperatorConfig reads the ConfigureAlertmanager singleton and makes its settings
+// effective for this reconcile. Errors reading the resource leave the defaults in effect.
+func (r *SecretReconciler) applyOperatorConfig(ctx context.Context, reqLogger logr.Logger) *v1alpha1.ConfigureAlertmanager {
+	operatorConfig, err := getOperatorConfig(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Unable to read ConfigureAlertmanager, using defaults")
+	}
+	if operatorConfig != nil && operatorConfig.Spec.ManagedNamespace != "" && operatorConfig.Spec.ManagedNamespace != config.ManagedNamespace() {
+		reqLogger.Info("INFO: ConfigureAlertmanager managedNamespace changed; restart the operator to apply it",
+			"requested", operatorConfig.Spec.ManagedNamespace, "effective", config.ManagedNamespace())
+	}
+	if operatorConfig != nil && operatorConfig.Spec.UserWorkload.Enabled != config.UserWorkloadEnabled() {
+		reqLogger.Info("INFO: ConfigureAlertmanager userWorkload.enabled changed; restart the operator to apply it",
+			"requested", operatorConfig.Spec.UserWorkload.Enabled, "effective", config.UserWorkloadEnabled())
+	}
+
+	if operatorConfig != nil && !slices.Equal(alertmanagerConfigNamespaces(operatorConfig.Spec.AlertmanagerConfigs), config.AlertmanagerConfigNamespaces()) {
+		reqLogger.Info("INFO: ConfigureAlertmanager alertmanagerConfigs namespaces changed; restart the operator to apply them",
+			"requested", alertmanagerConfigNamespaces(operatorConfig.Spec.AlertmanagerConfigs), "effective", config.AlertmanagerConfigNamespaces())
+	}
+
+	config.SetSettings(settingsFromOperatorConfig(config.DefaultSettings(), operatorConfig))
+	return operatorConfig
+}
+
+// observedInputs describes the effective settings, the source objects present in the
+// managed namespace and the AlertmanagerConfigs that were aggregated.
+func observedInputs(settings config.Settings, secretList *corev1.SecretList, cmList *corev1.ConfigMapList, alertmanagerConfigs []*monitoringv1beta1.AlertmanagerConfig) v1alpha1.ObservedInputs {
+	profile := v1alpha1.ProfileStandard
+	if settings.Fedramp {
+		profile = v1alpha1.ProfileFedRAMP
+	}
+
+	inputs := v1alpha1.ObservedInputs{
+		Profile:                         profile,
+		ManagedNamespace:                config.ManagedNamespace(),
+		MaxClusterAgeMinutes:            int32(settings.MaxClusterAgeMinutes),
+		ReadinessRequeueIntervalSeconds: int32(settings.ReadinessRequeueInterval / time.Second),
+		SettlingWindowMinutes:           int32(settings.SettlingWindowMinutes),
+		ResumeSettling:                  settings.ResumeSettling,
+		CompactRoutes:                   settings.CompactRoutes,
+		RoutingCoverage:                 settings.RoutingCoverage,
+		DriftPolicy:                     v1alpha1.DriftPolicy(settings.DriftPolicy),
+		OutputMode:                      v1alpha1.OutputMode(settings.OutputMode),
+	}
+
+	for _, secret := range secretList.Items {
+		if isSourceName(secret.Name) || isAutomationSecretName(settings, secret.Name) {
+			inputs.Sources = append(inputs.Sources, v1alpha1.ObservedSource{Kind: "Secret", Name: secret.Name, ResourceVersion: secret.ResourceVersion})
+		}
+	}
+	for _, cm := range cmList.Items {
+		if isSourceName(cm.Name) {
+			inputs.Sources = append(inputs.Sources, v1alpha1.ObservedSource{Kind: "ConfigMap", Name: cm.Name, ResourceVersion: cm.ResourceVersion})
+		}
+	}
+	sort.Slice(inputs.Sources, func(i, j int) bool {
+		if inputs.Sources[i].Kind != inputs.Sources[j].Kind {
+			return inputs.Sources[i].Kind < inputs.Sources[j].Kind
+		}
+		return inputs.Sources[i].Name < inputs.Sources[j].Name
+	})
+	for _, amc := range alertmanagerConfigs {
+		inputs.AlertmanagerConfigs = append(inputs.AlertmanagerConfigs, v1alpha1.ObservedSource{
+			Kind: monitoringv1beta1.AlertmanagerConfigKind, Namespace: amc.Namespace, Name: amc.Name, ResourceVersion: amc.ResourceVersion,
+		})
+	}
+	return inputs
+}
+
+// isSourceName returns true for the Secrets and ConfigMaps the Alertmanager config is rendered from,
+// other than the Secrets of automation routes.
+func isSourceName(name string) bool {
+	switch name {
+	case secretNameGoalert, secretNamePD, secretNamePDTeams, secretNameDMS,
+		cmNameOcmAgent, cmNameManagedNamespaces, cmNameOCPNamespaces, cmNameOverrides:
+		return true
+	}
+	return false
+}
+
+// configHash returns the SHA-256 of the rendered alertmanager.yaml.
+func configHash(amconfig *alertmanager.Config) string {
+	amconfigbyte, err := yaml.Marshal(amconfig)
+	if err != nil {
+		return ""
+	}
+	return hashBytes(amconfigbyte)
+}
+
+// hashBytes returns the hex-encoded SHA-256 of b.
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// reconcileOutcome collects what a single Reconcile observed and did, so it can be
+// reported on the ConfigureAlertmanager status when Reconcile returns.
+type reconcileOutcome struct {
+	// operatorConfig is the ConfigureAlertmanager singleton, or nil if it does not exist.
+	operatorConfig *v1alpha1.ConfigureAlertmanager
+	inputs         v1alpha1.ObservedInputs
+	clusterReady   bool
+	integrations   []string
+	// renderedHash is set once the config has been rendered.
+	renderedHash string
+	// validationErr is set if the rendered config failed validation.
+	validationErr error
+	// writeAttempted and written track the write to the Alertmanager secret.
+	writeAttempted bool
+	written        bool
+	// writeSkipReason and writeSkipMessage explain why the write was deliberately skipped,
+	// e.g. because management is paused.
+	writeSkipReason  string
+	writeSkipMessage string
+	// err is the most recent error encountered; the reconcile may have continued past it.
+	err error
+}
+
+// updateOperatorConfigStatus reports the outcome of a reconcile of alertmanager-main on the
+// ConfigureAlertmanager singleton.
+func (r *SecretReconciler) updateOperatorConfigStatus(ctx context.Context, reqLogger logr.Logger, outcome *reconcileOutcome) {
+	r.updateStatus(ctx, reqLogger, outcome.operatorConfig, func(status *v1alpha1.ConfigureAlertmanagerStatus, generation int64) {
+		setOperatorConfigStatus(status, generation, outcome, metav1.Now())
+	})
+}
+
+// updateUserWorkloadStatus reports the outcome of a reconcile of alertmanager-user-workload
+// in the userWorkload status of the ConfigureAlertmanager singleton.
+func (r *SecretReconciler) updateUserWorkloadStatus(ctx context.Context, reqLogger logr.Logger, outcome *reconcileOutcome) {
+	r.updateStatus(ctx, reqLogger, outcome.operatorConfig, func(status *v1alpha1.ConfigureAlertmanagerStatus, generation int64) {
+		if status.UserWorkload == nil {
+			status.UserWorkload = &v1alpha1.ManagedConfigStatus{}
+		}
+		setManagedConfigStatus(status.UserWorkload, generation, outcome, userWorkloadTarget().String(), metav1.Now())
+	})
+}
+
+// updateStatus applies set to the status of the ConfigureAlertmanager singleton, creating the
+// singleton with an empty spec if it does not exist yet. The status is only written if set
+// changed it.
+func (r *SecretReconciler) updateStatus(ctx context.Context, reqLogger logr.Logger, operatorConfig *v1alpha1.ConfigureAlertmanager, set func(status *v1alpha1.ConfigureAlertmanagerStatus, generation int64)) {
+	if operatorConfig == nil {
+		operatorConfig = &v1alpha1.ConfigureAlertmanager{
+			ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ConfigureAlertmanagerName},
+		}
+		if err := r.Client.Create(ctx, operatorConfig); err != nil {
+			if meta.IsNoMatchError(err) {
+				// The CRD is not installed, so there is nowhere to report status
+				return
+			}
+			if !errors.IsAlreadyExists(err) {
+				reqLogger.Error(err, "Failed to create ConfigureAlertmanager")
+				return
+			}
+			if err := r.Client.Get(ctx, client.ObjectKeyFromObject(operatorConfig), operatorConfig); err != nil {
+				reqLogger.Error(err, "Failed to get ConfigureAlertmanager")
+				return
+			}
+		}
+	}
+
+	previous := operatorConfig.Status.DeepCopy()
+	set(&operatorConfig.Status, operatorConfig.Generation)
+	if equality.Semantic.DeepEqual(previous, &operatorConfig.Status) {
+		return
+	}
+
+	// Best effort status update - don't fail reconciliation if the status can't be written
+	if err := r.Client.Status().Update(ctx, operatorConfig); err != nil {
+		reqLogger.Error(err, "Failed to update ConfigureAlertmanager status")
+	}
+}
+
+// setOperatorConfigStatus translates a reconcile outcome for alertmanager-main into status
+// fields and conditions. The user-workload status is dropped once it is no longer managed.
+func setOperatorConfigStatus(status *v1alpha1.ConfigureAlertmanagerStatus, generation int64, outcome *reconcileOutcome, now metav1.Time) {
+	status.ObservedGeneration = generation
+	status.ObservedInputs = outcome.inputs
+	if !config.UserWorkloadEnabled() {
+		status.UserWorkload = nil
+	}
+
+	appliedTo := platformTarget().String()
+	if outcome.inputs.OutputMode == v1alpha1.OutputModeAlertmanagerConfig {
+		appliedTo = fmt.Sprintf("AlertmanagerConfig %s/%s", config.ManagedNamespace(), outputAlertmanagerConfigName)
+	}
+	setManagedConfigStatus(&status.ManagedConfigStatus, generation, outcome, appliedTo, now)
+}
+
+// setManagedConfigStatus translates a reconcile outcome into the status and conditions of the
+// config written to appliedTo. ConfigValid and ConfigApplied are left unchanged if the
+// reconcile returned before rendering or writing the config, since the previously applied
+// config is still in place.
+func setManagedConfigStatus(status *v1alpha1.ManagedConfigStatus, generation int64, outcome *reconcileOutcome, appliedTo string, now metav1.Time) {
+	status.LastError = ""
+	if outcome.err != nil {
+		status.LastError = outcome.err.Error()
+	}
+
+	setCondition := func(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: generation,
+		})
+	}
+
+	if outcome.renderedHash != "" {
+		status.RenderedHash = outcome.renderedHash
+		status.ActiveIntegrations = outcome.integrations
+		if outcome.validationErr != nil {
+			setCondition(v1alpha1.ConditionConfigValid, metav1.ConditionFalse, "ValidationFailed", outcome.validationErr.Error())
+		} else {
+			setCondition(v1alpha1.ConditionConfigValid, metav1.ConditionTrue, "Valid", "Rendered config passed Alertmanager validation")
+		}
+	}
+
+	if outcome.written {
+		if status.LastWriteTime == nil || status.LastAppliedHash != outcome.renderedHash {
+			status.LastWriteTime = &now
+		}
+		status.LastAppliedHash = outcome.renderedHash
+		setCondition(v1alpha1.ConditionConfigApplied, metav1.ConditionTrue, "Applied", "Alertmanager config written to "+appliedTo)
+	} else if outcome.writeSkipReason != "" {
+		setCondition(v1alpha1.ConditionConfigApplied, metav1.ConditionFalse, outcome.writeSkipReason, outcome.writeSkipMessage)
+	} else if outcome.writeAttempted {
+		message := "Alertmanager config was not written"
+		if outcome.err != nil {
+			message = outcome.err.Error()
+		}
+		setCondition(v1alpha1.ConditionConfigApplied, metav1.ConditionFalse, "ApplyFailed", message)
+	}
+
+	if outcome.err != nil {
+		setCondition(v1alpha1.ConditionDegraded, metav1.ConditionTrue, "ReconcileError", outcome.err.Error())
+	} else {
+		setCondition(v1alpha1.ConditionDegraded, metav1.ConditionFalse, "AsExpected", "Reconcile completed without errors")
+	}
+
+	switch {
+	case outcome.writeSkipReason != "":
+		setCondition(v1alpha1.ConditionReady, metav1.ConditionFalse, outcome.writeSkipReason, outcome.writeSkipMessage)
+	case !outcome.written:
+		setCondition(v1alpha1.ConditionReady, metav1.ConditionFalse, "ConfigNotApplied", "The Alertmanager config was not applied by the most recent reconcile")
+	case !outcome.clusterReady:
+		setCondition(v1alpha1.ConditionReady, metav1.ConditionFalse, "ClusterNotReady", "Config applied; paging integrations are withheld until the cluster is ready")
+	default:
+		setCondition(v1alpha1.ConditionReady, metav1.ConditionTrue, "Ready", "Config applied with paging integrations enabled")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+const (
+	// cmNameOverrides and secretNameOverrides hold partial Alertmanager config merged into the
+	// generated config. Receivers with credentials belong in the Secret.
+	cmNameOverrides     = "alertmanager-overrides"
+	secretNameOverrides = cmNameOverrides
+
+	// keyOverrides is the ConfigMap and Secret key holding the overrides.
+	keyOverrides = "overrides.yaml"
+)
+
+// overridesFragment is the overrides read from one source object.
+type overridesFragment struct {
+	// description identifies the source object in errors, e.g. "ConfigMap alertmanager-overrides".
+	description string
+	overrides   *alertmanager.ConfigOverrides
+}
+
+// overridesSources returns the alertmanager-overrides ConfigMap and Secret that exist, in that order.
+func overridesSources(secretList *corev1.SecretList, cmList *corev1.ConfigMapList) []client.Object {
+	sources := []client.Object{}
+	for i := range cmList.Items {
+		if cmList.Items[i].Name == cmNameOverrides {
+			sources = append(sources, &cmList.Items[i])
+		}
+	}
+	for i := range secretList.Items {
+		if secretList.Items[i].Name == secretNameOverrides {
+			sources = append(sources, &secretList.Items[i])
+		}
+	}
+	return sources
+}
+
+// readOverrides parses the overrides held by each source. Unknown fields are rejected so
+// unsupported receiver types aren't silently dropped.
+func readOverrides(sources []client.Object) ([]overridesFragment, error) {
+	fragments := []overridesFragment{}
+	for _, source := range sources {
+		var data []byte
+		description := ""
+		switch obj := source.(type) {
+		case *corev1.ConfigMap:
+			data = []byte(obj.Data[keyOverrides])
+			description = "ConfigMap " + obj.Name
+		case *corev1.Secret:
+			data = obj.Data[keyOverrides]
+			description = "Secret " + obj.Name
+		}
+		overrides := &alertmanager.ConfigOverrides{}
+		if err := yaml.UnmarshalStrict(data, overrides); err != nil {
+			return nil, fmt.Errorf("unable to parse %s key %s: %w", description, keyOverrides, err)
+		}
+		fragments = append(fragments, overridesFragment{description: description, overrides: overrides})
+	}
+	return fragments, nil
+}
+
+// mergeOverrides returns a copy of the generated config with the overrides merged in.
+// The generated config takes precedence:
+//   - override receivers are added, but may not reuse the name of any other receiver;
+//   - prepended routes go before the generated routes, appended routes after them, in
+//     the order the fragments are given;
+//   - inhibit rules and templates are added after the generated ones.
+//
+// The generated config is not modified.
+func mergeOverrides(generated *alertmanager.Config, fragments []overridesFragment) (*alertmanager.Config, error) {
+	merged := *generated
+	route := *generated.Route
+	merged.Route = &route
+	merged.Receivers = slices.Clone(generated.Receivers)
+	merged.InhibitRules = slices.Clone(generated.InhibitRules)
+	merged.Templates = slices.Clone(generated.Templates)
+
+	definedBy := map[string]string{}
+	for _, receiver := range generated.Receivers {
+		definedBy[receiver.Name] = "the operator"
+	}
+
+	prepend := []*alertmanager.Route{}
+	appended := []*alertmanager.Route{}
+	for _, fragment := range fragments {
+		for _, receiver := range fragment.overrides.Receivers {
+			if receiver == nil || receiver.Name == "" {
+				return nil, fmt.Errorf("%s: receivers must have a name", fragment.description)
+			}
+			if source, ok := definedBy[receiver.Name]; ok {
+				return nil, fmt.Errorf("%s: receiver %q is already defined by %s", fragment.description, receiver.Name, source)
+			}
+			definedBy[receiver.Name] = fragment.description
+			merged.Receivers = append(merged.Receivers, receiver)
+		}
+		prepend = append(prepend, fragment.overrides.Routes.Prepend...)
+		appended = append(appended, fragment.overrides.Routes.Append...)
+		merged.InhibitRules = append(merged.InhibitRules, fragment.overrides.InhibitRules...)
+		for _, template := range fragment.overrides.Templates {
+			if !slices.Contains(merged.Templates, template) {
+				merged.Templates = append(merged.Templates, template)
+			}
+		}
+	}
+
+	route.Routes = make([]*alertmanager.Route, 0, len(prepend)+len(generated.Route.Routes)+len(appended))
+	route.Routes = append(route.Routes, prepend...)
+	route.Routes = append(route.Routes, generated.Route.Routes...)
+	route.Routes = append(route.Routes, appended...)
+	return &merged, nil
+}
+
+// applyOverrides merges the alertmanager-overrides ConfigMap and Secret into the generated
+// config. If the overrides can't be parsed or merged, or the merged config fails validation,
+// the overrides are rejected as a whole: a Warning Event is recorded against each source and
+// the generated config is returned unchanged along with the error, so a bad override never
+// blocks paging.
+func (r *SecretReconciler) applyOverrides(reqLogger logr.Logger, generated *alertmanager.Config, secretList *corev1.SecretList, cmList *corev1.ConfigMapList) (*alertmanager.Config, error) {
+	sources := overridesSources(secretList, cmList)
+	if len(sources) == 0 {
+		metrics.UpdateAlertmanagerOverridesMetric(false)
+		return generated, nil
+	}
+
+	fragments, err := readOverrides(sources)
+	var merged *alertmanager.Config
+	if err == nil {
+		merged, err = mergeOverrides(generated, fragments)
+	}
+	if err == nil {
+		err = validateAlertManagerConfig(reqLogger, merged)
+	}
+	if err != nil {
+		err = fmt.Errorf("alertmanager overrides rejected: %w", err)
+		reqLogger.Error(err, "ERROR: Ignoring alertmanager overrides")
+		metrics.UpdateAlertmanagerOverridesMetric(true)
+		for _, source := range sources {
+			r.recordEvent(source, corev1.EventTypeWarning, eventReasonOverridesRejected, eventActionMergeOverrides,
+				fmt.Sprintf("Overrides were not merged into the Alertmanager config; the generated config is used instead. Error: %v", err))
+		}
+		return generated, err
+	}
+
+	reqLogger.Info("INFO: Merged alertmanager overrides", "sources", len(fragments))
+	metrics.UpdateAlertmanagerOverridesMetric(false)
+	return merged, nil
+}
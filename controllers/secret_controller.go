@@ -18,9 +18,13 @@ package controllers
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"net/url"
+	"regexp"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -28,31 +32,36 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
+	monitoringv1beta1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1beta1"
 	amconfig "github.com/prometheus/alertmanager/config"
 
+	"github.com/openshift/configure-alertmanager-operator/api/v1alpha1"
 	"github.com/openshift/configure-alertmanager-operator/config"
 	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
 	"github.com/openshift/configure-alertmanager-operator/pkg/readiness"
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+	"github.com/openshift/configure-alertmanager-operator/pkg/routing"
 	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
 )
 
 var log = logf.Log.WithName("secret_controller")
 
-type receiverType int64
-
 const (
-	// Defining receiver types to be used in subroute decision making
-	GoAlert receiverType = iota
-	Pagerduty
-
 	// Endpoint for "low" alerts for GoAlert. These will not page support personnel
 	secretKeyGoalertLow = "GOALERT_URL_LOW" //#nosec G101
 
@@ -71,6 +80,15 @@ const (
 
 	cmKeyOCPNamespaces = "managed_namespaces.yaml"
 
+	// Sources of routed namespaces, as reported by metrics and status
+	namespaceSourceManaged           = cmNameManagedNamespaces
+	namespaceSourceOCP               = cmNameOCPNamespaces
+	namespaceSourceManagementCluster = "ManagementCluster"
+
+	// Namespace lists in a *-namespaces configMap, as reported by metrics and Events
+	namespaceListNamespaces           = "Resources.Namespace"
+	namespaceListAdditionalNamespaces = "Resources.ManagementCluster.AdditionalNamespaces"
+
 	// Secret containing URLs for GoAlert
 	secretNameGoalert = "goalert-secret"
 
@@ -82,6 +100,9 @@ const (
 
 	secretNameAlertmanager = "alertmanager-main"
 
+	// Secret containing the user-workload Alertmanager config, in the user-workload monitoring namespace
+	secretNameUserWorkloadAlertmanager = "alertmanager-user-workload"
+
 	cmNameManagedNamespaces = "managed-namespaces"
 
 	cmNameOCPNamespaces = "ocp-namespaces"
@@ -89,51 +110,25 @@ const (
 	// ClusterVersion object name for cluster version and type detection
 	clusterVersionName = "version"
 
-	// High alerts for GoAlert. These alerts will page
-	receiverGoAlertHigh = "goalert-high"
-
-	// Low alerts for GoAlert. These alerts will not page
-	receiverGoAlertLow = "goalert"
-
-	// GoAlert cluster heartbeat monitor
-	receiverGoAlertHeartbeat = "goalert-heartbeat"
-
-	// anything routed to "null" receiver does not get routed to PD or GoAlert
-	receiverNull = "null"
+	// Receivers rendered by pkg/render
+	receiverGoAlertHigh      = render.ReceiverGoAlertHigh
+	receiverGoAlertLow       = render.ReceiverGoAlertLow
+	receiverGoAlertHeartbeat = render.ReceiverGoAlertHeartbeat
+	receiverNull             = render.ReceiverNull
+	receiverMakeItWarning    = render.ReceiverMakeItWarning
+	receiverMakeItError      = render.ReceiverMakeItError
+	receiverMakeItCritical   = render.ReceiverMakeItCritical
+	receiverPagerduty        = render.ReceiverPagerDuty
+	receiverWatchdog         = render.ReceiverWatchdog
+	receiverOCMAgent         = render.ReceiverOCMAgent
 
-	// anything routed to "make-it-warning" receiver has severity=warning
-	receiverMakeItWarning = "make-it-warning"
-
-	// anything routed to "make-it-error" receiver has severity=error
-	receiverMakeItError = "make-it-error"
-
-	// anything routed to "make-it-critical" receiver has severity=critical
-	receiverMakeItCritical = "make-it-critical"
-
-	// anything routed to "pagerduty" will alert/notify SREP
-	receiverPagerduty = "pagerduty"
 	// anything routed to "cad-pagerduty" routes alerts to separate PagerDuty integration
 	receiverCADPagerduty = "cad-pagerduty"
 
-	// anything going to Dead Man's Snitch (watchdog)
-	receiverWatchdog = "watchdog"
-
-	// the default receiver used by the route used for pagerduty
-	defaultReceiver = receiverNull
-
 	// alert label used to identify CAD alerts to be routed to event-based automation service
 	routeCADLabel      = "route_to_cad"
 	routeCADLabelValue = "true"
 
-	// global config for PagerdutyURL
-	pagerdutyURL = "https://events.pagerduty.com/v2/enqueue"
-
-	// anything routed to "ocmagent" will not alert/notify SREP and will be handled by OCM Agent
-	receiverOCMAgent = "ocmagent"
-
-	// alert label used for identifying OCM Agent-bound alerts
-	managedNotificationLabel = "send_managed_notification"
-
 	// service name for the OCM Agent service
 	ocmAgentService = "ocm-agent"
 	// namespace for the OCM Agent service
@@ -154,23 +149,101 @@ const (
 	clusterTypeManagement = "management-cluster"
 )
 
-var defaultNamespaces = []string{
-	alertmanager.PDRegexOS,
-	alertmanager.PDRegexLP,
-	alertmanager.PDRegexKube,
+// alertmanagerTarget is a Secret holding an Alertmanager config written by the operator.
+type alertmanagerTarget struct {
+	namespace  string
+	secretName string
+	// userWorkload is set for the user-workload Alertmanager, which reports its own metrics.
+	userWorkload bool
 }
 
+// platformTarget is the alertmanager-main secret in the managed namespace.
+func platformTarget() alertmanagerTarget {
+	return alertmanagerTarget{namespace: config.ManagedNamespace(), secretName: secretNameAlertmanager}
+}
+
+// userWorkloadTarget is the alertmanager-user-workload secret.
+func userWorkloadTarget() alertmanagerTarget {
+	return alertmanagerTarget{namespace: config.UserWorkloadNamespace, secretName: secretNameUserWorkloadAlertmanager, userWorkload: true}
+}
+
+func (t alertmanagerTarget) String() string {
+	return t.namespace + "/" + t.secretName
+}
+
+// secret returns an empty Secret with the target's name and namespace.
+func (t alertmanagerTarget) secret() *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: t.secretName, Namespace: t.namespace}}
+}
+
+// updateValidationMetric records whether the most recent write to the target succeeded.
+func (t alertmanagerTarget) updateValidationMetric(valid bool) {
+	if t.userWorkload {
+		metrics.UpdateUserWorkloadConfigValidationMetric(valid)
+		return
+	}
+	metrics.UpdateAlertmanagerConfigValidationMetric(valid)
+}
+
+// updateDriftMetrics records drift and pausing of alertmanager-main. The user-workload
+// secret reports them on the ConfigureAlertmanager status and in Events only.
+func (t alertmanagerTarget) updateDriftMetrics(drifted bool, paused bool) {
+	if !t.userWorkload {
+		metrics.UpdateConfigDriftMetrics(drifted, paused)
+	}
+}
+
+// incDriftDetected counts a newly detected manual edit to alertmanager-main.
+func (t alertmanagerTarget) incDriftDetected(policy string) {
+	if !t.userWorkload {
+		metrics.IncConfigDriftDetected(policy)
+	}
+}
+
+// errConfigInvalid is returned by writeAlertManagerConfig when the rendered config fails validation.
+var errConfigInvalid = stderrors.New("alertmanager config validation failed")
+
+// defaultManagedNamespaces and defaultOCPNamespaces are routed in place of the
+// managed-namespaces and ocp-namespaces configMaps if they are missing or can't be parsed.
+var (
+	defaultManagedNamespaces = []string{
+		alertmanager.PDRegexOS,
+		alertmanager.PDRegexLP,
+	}
+	defaultOCPNamespaces = []string{
+		alertmanager.PDRegexKube,
+		alertmanager.PDRegexOS,
+	}
+)
+
 // SecretReconciler reconciles a Secret object
 type SecretReconciler struct {
 	Client    client.Client
 	Scheme    *runtime.Scheme
 	Readiness readiness.Interface
+	// Recorder records Events against the alertmanager-main Secret and its source objects.
+	Recorder events.EventRecorder
+	// APIReader reads objects outside the cache, such as Secrets referenced by
+	// AlertmanagerConfigs in other namespaces. The Client is used if it is nil.
+	APIReader client.Reader
+	// clusterProfile detects and caches the cluster type.
+	clusterProfile clusterProfileDetector
+	// driftedHashes are the hashes of the most recent manual edit to each target secret, so
+	// each edit is only counted once.
+	driftedHashes map[alertmanagerTarget]string
 }
 
 //+kubebuilder:rbac:groups=managed.openshift.io,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=managed.openshift.io,resources=secrets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=managed.openshift.io,resources=secrets/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=configurealertmanagers,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=managed.openshift.io,resources=configurealertmanagers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=alertmanagerconfigs,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=alertmanagers,verbs=get
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -182,36 +255,43 @@ type SecretReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.2/pkg/reconcile
 func (r *SecretReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	if request.Namespace != config.OperatorNamespace {
+	if request.Namespace == config.UserWorkloadNamespace && config.UserWorkloadEnabled() {
+		return r.reconcileUserWorkload(ctx, request)
+	}
+	if request.Namespace != config.ManagedNamespace() {
 		return reconcile.Result{}, nil
 	}
 	reqLogger := log.WithValues("Request.Name", request.Name)
 	reqLogger.Info("Reconciling Object")
 
+	// Apply operator settings from the ConfigureAlertmanager resource before anything reads them,
+	// including the filter below, since the automation routes name some of the source Secrets.
+	operatorConfig := r.applyOperatorConfig(ctx, reqLogger)
+
 	// This operator is only interested in the secrets, configMaps, and ClusterVersion listed below. Skip reconciling for all other objects.
 	// TODO: Filter these with a predicate instead
 	switch request.Name {
-	case secretNameGoalert:
-	case secretNamePD:
-	case secretNameCADPD:
-	case secretNameDMS:
 	case secretNameAlertmanager:
-	case cmNameOcmAgent:
 	case cmNameManagedNamespaces:
 	case cmNameOCPNamespaces:
-	case clusterVersionName: // ClusterVersion object - triggers reconcile when cluster type changes
+	case cmNameOverrides: // and secretNameOverrides, which shares the name
 	default:
-		reqLogger.Info("Skip reconcile: No changes detected to alertmanager secrets.")
-		return reconcile.Result{}, nil
+		if !isIntegrationSourceName(request.Name) {
+			reqLogger.Info("Skip reconcile: No changes detected to alertmanager secrets.")
+			return reconcile.Result{}, nil
+		}
 	}
 	reqLogger.Info("DEBUG: Started reconcile loop")
 
-	// Determine and log cluster type early for visibility
-	readMC, err := r.isManagementCluster()
+	// Report the outcome of this reconcile on the ConfigureAlertmanager status when we return.
+	outcome := &reconcileOutcome{operatorConfig: operatorConfig}
+	defer r.updateOperatorConfigStatus(ctx, reqLogger, outcome)
+
+	// Determine and log cluster type early for visibility. Detection failures default to a
+	// management cluster.
+	profile, err := r.clusterProfile.profile(ctx, r.Client)
+	readMC := profile.isManagementCluster()
 	if err != nil {
-		// Default to true (management cluster) for safety - better to monitor MC namespaces
-		// on a non-MC cluster (false positive) than miss critical alerts on an actual MC
-		readMC = true
 		reqLogger.Error(err, "CRITICAL: Unable to determine cluster type - defaulting to MANAGEMENT CLUSTER behavior for safety",
 			"default_behavior", "monitoring_mc_namespaces",
 			"action_required", "investigate this error immediately - file issue at https://github.com/openshift/configure-alertmanager-operator/issues if not already reported")
@@ -219,14 +299,33 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		reqLogger.Error(err, "Stack trace for cluster type determination failure", "stack", string(debug.Stack()))
 		// Generate a Kubernetes event to alert SRE
 		r.recordClusterTypeDetectionEvent(err)
+		outcome.err = fmt.Errorf("unable to determine cluster type: %w", err)
+	}
+	reqLogger.Info("Cluster type determined", "clusterType", profile.clusterType, "source", profile.source)
+
+	// Discover namespaces labelled for managed alerting and, on a management cluster, page for
+	// each hosted cluster with its own ID
+	labelledNamespaces, discoveredNamespaces, err := r.discoverNamespaces(ctx, reqLogger)
+	namespaces := render.Namespaces{Labelled: discoveredNamespaces}
+	if err != nil {
+		reqLogger.Error(err, "Unable to discover managed-alerting namespaces")
+		outcome.err = err
+	}
+	if readMC {
+		namespaces.HostedControlPlanes, err = r.listHostedControlPlanes(ctx, reqLogger)
+		if err != nil {
+			reqLogger.Error(err, "Unable to discover hosted clusters")
+			outcome.err = err
+		}
 	}
-	reqLogger.Info("Cluster type determined", "isManagementCluster", readMC)
 
 	clusterReady, err := r.Readiness.IsReady()
 	if err != nil {
 		reqLogger.Error(err, "Error determining cluster readiness.")
+		outcome.err = fmt.Errorf("error determining cluster readiness: %w", err)
 		return r.Readiness.Result(), err
 	}
+	outcome.clusterReady = clusterReady
 
 	// Get a list of all relevant objects in the `openshift-monitoring` namespace.
 	// This is used for determining which secrets and configMaps are present so that the necessary
@@ -238,20 +337,23 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 	err = r.Client.List(context.TODO(), secretList, opts...)
 	if err != nil {
 		reqLogger.Error(err, "Unable to list secrets")
+		outcome.err = fmt.Errorf("unable to list secrets: %w", err)
 	}
 
 	cmList := &corev1.ConfigMapList{}
 	err = r.Client.List(context.TODO(), cmList, opts...)
 	if err != nil {
 		reqLogger.Error(err, "Unable to list configMaps")
+		outcome.err = fmt.Errorf("unable to list configMaps: %w", err)
 	}
 
-	pagerdutyRoutingKey, cadPagerdutyRoutingKey, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := r.parseSecrets(reqLogger, secretList, request.Namespace, clusterReady)
-	osdNamespaces := r.parseConfigMaps(reqLogger, cmList, request.Namespace)
+	// Integrations are withheld until the cluster is ready, to avoid alert noise while it is
+	// still being installed and configured
+	integrations := r.parseIntegrations(reqLogger, request.Namespace, secretList, cmList, clusterReady)
+	osdNamespaces, namespaceTeams, namespaceSources := r.parseConfigMaps(reqLogger, cmList, request.Namespace, readMC, labelledNamespaces)
+	namespaces.Managed, namespaces.Teams = osdNamespaces, namespaceTeams
 	reqLogger.Info("DEBUG: Adding PagerDuty routes for the following namespaces", "Namespaces", osdNamespaces)
 
-	ocmAgentURL := r.readOCMAgentServiceURLFromConfig(reqLogger, cmList, request.Namespace)
-
 	clusterProxy, err := r.getClusterProxy()
 	if err != nil {
 		reqLogger.Error(err, "Unable to get cluster proxy")
@@ -268,740 +370,214 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		reqLogger.Error(err, "Error reading cluster region.")
 	}
 
-	alertmanagerconfig := createAlertManagerConfig(reqLogger,
-		pagerdutyRoutingKey,
-		cadPagerdutyRoutingKey,
-		goalertURLlow,
-		goalertURLhigh,
-		goalertURLheartbeat,
-		watchdogURL,
-		ocmAgentURL,
-		clusterID,
-		clusterRegion,
-		clusterProxy,
-		osdNamespaces)
-
-	// write the alertmanager Config
-	if err := writeAlertManagerConfig(ctx, r, reqLogger, alertmanagerconfig); err != nil {
-		reqLogger.Error(err, "Failed to write alertmanager config")
-		return reconcile.Result{}, err
-	}
-
-	// Update metrics after all reconcile operations are complete.
-	metrics.UpdateSecretsMetrics(secretList, alertmanagerconfig)
-	metrics.UpdateConfigMapMetrics(cmList)
-	reqLogger.Info("Finished reconcile for secret.")
-
-	// The readiness Result decides whether we should requeue, effectively "polling" the readiness logic.
-	return r.Readiness.Result(), nil
-}
-
-// SetupWithManager sets up the controller with the Manager.
-func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	client := mgr.GetClient()
-
-	r.Readiness = &readiness.Impl{Client: client}
-
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Secret{}).
-		Watches(&corev1.ConfigMap{}, &handler.EnqueueRequestForObject{}).
-		Watches(&configv1.ClusterVersion{}, &handler.EnqueueRequestForObject{}).
-		Complete(r)
-}
-
-func createSubroutes(namespaceList []string, receiver receiverType) *alertmanager.Route {
-
-	var receiverCommon, receiverCritical, receiverError, receiverWarning, receiverDefault string
-
-	switch receiver {
-	case GoAlert:
-		receiverCommon = receiverGoAlertLow
-		receiverCritical = receiverGoAlertHigh
-		receiverError = receiverGoAlertHigh
-		receiverWarning = receiverGoAlertLow
-		receiverDefault = receiverNull
-	case Pagerduty:
-		receiverCommon = receiverPagerduty
-		receiverCritical = receiverMakeItCritical
-		receiverError = receiverMakeItError
-		receiverWarning = receiverMakeItWarning
-		receiverDefault = defaultReceiver
-	default:
-		return nil
-	}
-
-	// order matters.
-	// these are sub-routes.  if any matches it will not continue processing.
-	// 1. route anything we consider critical to receiverCritical
-	// 2. route anything we want to silence to receiverNull
-	// 3. route anything that should be a warning to receiverWarning
-	// 4. route anything that should be an error to receiverError
-	// 5. route anything we want to go to receiverCommon
-	//
-	// the Route docs can be read at https://prometheus.io/docs/alerting/latest/configuration/#matcher
-	subroute := []*alertmanager.Route{
-		// Needed because we are now allowing DMS to continue to allow DMS and GoAlert Heartbeat to coexist. Now we just drop DMS.
-		// {Receiver: receiverNull, Match: map[string]string{"alertname": "SnitchHeartBeat", "severity": "deadman"}},
-		// Needed to drop GoAlert heartbeat alerts
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "Watchdog", "severity": "none"}},
-		// https://issues.redhat.com/browse/OSD-11298
-		// indications that master nodes have been terminated should be critical
-		// regex tests: https://regex101.com/r/Rn6F5A/1
-		{Receiver: receiverCritical, MatchRE: map[string]string{"name": "^.+-master-.*[0-9]+$"}, Match: map[string]string{"alertname": "MachineWithoutValidNode", "namespace": "openshift-machine-api"}},
-		{Receiver: receiverCritical, MatchRE: map[string]string{"name": "^.+-master-.*[0-9]+$"}, Match: map[string]string{"alertname": "MachineWithNoRunningPhase", "namespace": "openshift-machine-api"}},
-		// https://issues.redhat.com/browse/ROSAENG-420
-		{Receiver: receiverCritical, Match: map[string]string{"alertname": "etcdDatabaseQuotaLowSpace", "severity": "warning"}},
-		// Route CannotRetrieveUpdates to null, created CannotRetrieveUpdatesSRE in managed-cluster-config to address https://issues.redhat.com/browse/OSD-14149
-		// https://issues.redhat.com/browse/OCPBUGS-11636
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "CannotRetrieveUpdates"}},
-		// Silence anything intended for OCM Agent
-		// https://issues.redhat.com/browse/SDE-1315
-		{Receiver: receiverNull, Match: map[string]string{managedNotificationLabel: "true"}},
-		// https://issues.redhat.com/browse/OSD-1966
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "KubeQuotaExceeded"}},
-		// This will be renamed in release 4.5
-		// https://issues.redhat.com/browse/OSD-4017
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "KubeQuotaFullyUsed"}},
-		// TODO: Remove CPUThrottlingHigh entry after all OSD clusters upgrade to 4.6 and above version
-		// https://issues.redhat.com/browse/OSD-6351 based on https://bugzilla.redhat.com/show_bug.cgi?id=1843346
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "CPUThrottlingHigh"}},
-		// https://issues.redhat.com/browse/OSD-28223
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "NodeFilesystemSpaceFillingUp"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "NodeFilesystemFilesFillingUp"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "NodeFilesystemAlmostOutOfSpace"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "NodeFilesystemAlmostOutOfFiles"}},
-		// https://issues.redhat.com/browse/OSD-12379
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "NodeFileDescriptorLimit"}},
-		// https://issues.redhat.com/browse/OSD-2611
-		{Receiver: receiverNull, Match: map[string]string{"namespace": "openshift-customer-monitoring"}},
-		// https://issues.redhat.com/browse/OSD-3569
-		{Receiver: receiverNull, Match: map[string]string{"namespace": "openshift-operators"}},
-		// https://issues.redhat.com/browse/OSD-8337
-		{Receiver: receiverNull, Match: map[string]string{"namespace": "openshift-storage"}},
-		// https://issues.redhat.com/browse/OSD-8702
-		{Receiver: receiverNull, Match: map[string]string{"namespace": "openshift-compliance"}},
-		// https://issues.redhat.com/browse/OSD-8349
-		{Receiver: receiverNull, Match: map[string]string{"exported_namespace": "openshift-storage"}},
-		// https://issues.redhat.com/browse/OSD-6505
-		{Receiver: receiverNull, Match: map[string]string{"exported_namespace": "openshift-operators"}},
-		// https://issues.redhat.com/browse/OSD-7653
-		{Receiver: receiverNull, Match: map[string]string{"namespace": "openshift-operators-redhat"}},
-		// https://issues.redhat.com/browse/OSD-3629
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "CustomResourceDetected"}},
-		// https://issues.redhat.com/browse/OSD-3629
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ImagePruningDisabled"}},
-		// https://issues.redhat.com/browse/OSD-3794
-		{Receiver: receiverNull, Match: map[string]string{"severity": "info"}},
-		// https://issues.redhat.com/browse/OSD-27306
-		{Receiver: receiverNull, Match: map[string]string{"namespace": "openshift-user-workload-monitoring"}},
-		// https://issues.redhat.com/browse/OSD-19000 - Critical
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "KubePersistentVolumeFillingUp", "namespace": "openshift-logging"}},
-		// https://issues.redhat.com/browse/OSD-6598
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "PodDisruptionBudgetLimit"}},
-		// https://issues.redhat.com/browse/OSD-4373
-		{Receiver: receiverNull, MatchRE: map[string]string{"namespace": alertmanager.PDRegexLP}, Match: map[string]string{"alertname": "TargetDown"}},
-		// https://issues.redhat.com/browse/OSD-13306
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "KubeJobFailed"}},
-		// https://issues.redhat.com/browse/OSD-11273 - silence all elasticsearch alerts so we can handle only the ones that have extended logging support
-		// the list of alerts is pulled via
-		// ```
-		//  yq '.spec.groups[].rules[].alert | select( . != null) ' ../managed-cluster-config/resources/prometheusrules/fluentd_openshift-logging_collector.PrometheusRule.yaml | sort -u | awk '{print "{Receiver: receiverNull, Match: map[string]string{\"alertname\": \"" $1 "\", \"namespace\": \"openshift-logging\"}},"}'
-		// # for elasticsearch
-		// yq '.spec.groups[].rules[].alert | select( . != null) ' ../managed-cluster-config/resources/prometheusrules/elasticsearch_openshift-logging_elasticsearch-prometheus-rules.PrometheusRule.yaml | sort -u | awk '{print "{Receiver: receiverNull, Match: map[string]string{\"alertname\": \"" $1 "\", \"namespace\": \"openshift-logging\"}},"}'
-		// ```
-		// pass all the alerts that are SRE related to PD/GoAlert
-		{Receiver: receiverCommon, MatchRE: map[string]string{"alertname": "^.*SRE$"}, Match: map[string]string{"namespace": "openshift-logging"}},
-		// fluentd alerts
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "FluentDHighErrorRate", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "FluentDVeryHighErrorRate", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "FluentdNodeDown", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "FluentdNodeDown", "prometheus": "openshift-monitoring/k8s"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "FluentdQueueLengthIncreasing", "namespace": "openshift-logging"}}, //https://issues.redhat.com/browse/OSD-8403, https://issues.redhat.com/browse/OSD-8576
-		// elasticsearch alerts
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "AggregatedLoggingSystemCPUHigh", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchClusterNotHealthy", "namespace": "openshift-logging"}},   // this has happened last week
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchDiskSpaceRunningLow", "namespace": "openshift-logging"}}, // this has happened last week
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchHighFileDescriptorUsage", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchJVMHeapUseHigh", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchNodeDiskWatermarkReached", "namespace": "openshift-logging"}}, // this has happened last week
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchOperatorCSVNotSuccessful", "namespace": "openshift-logging"}}, // this has happened last week
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchProcessCPUHigh", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ElasticsearchWriteRequestsRejectionJumps", "namespace": "openshift-logging"}},
-		// END of https://issues.redhat.com/browse/OSD-11273
-		//
-		// https://issues.redhat.com/browse/OSD-17372 - silence all loki/vector alerts for none of them is in the support scope of extended logging support
-		// For detail explanation, please check https://issues.redhat.com/browse/OSD-17371
-		// vector alerts
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterLogForwarderRuntimeConfigurationMissingUnmatched", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterLogForwarderOutputErrorRate", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "CollectorNodeDown", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "CollectorHighErrorRate", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "CollectorVeryHighErrorRate", "namespace": "openshift-logging"}},
-		// loki alerts
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiRequestErrors", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiStackWriteRequestErrors", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiStackReadRequestErrors", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiRequestPanics", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiRequestLatency", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiTenantRateLimit", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiStorageSlowWrite", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiStorageSlowRead", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiWritePathHighLoad", "namespace": "openshift-logging"}},
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "LokiReadPathHighLoad", "namespace": "openshift-logging"}},
-		// END of https://issues.redhat.com/browse/OSD-17372
-		//
-		// Suppress the alerts and use HAProxyReloadFailSRE instead (openshift/managed-cluster-config#600)
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "HAProxyReloadFail", "severity": "critical"}},
-		// https://issues.redhat.com/browse/OHSS-2163
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "PrometheusRuleFailures"}},
-		// https://issues.redhat.com/browse/OSD-6215
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterOperatorDegraded", "name": "authentication", "reason": "IdentityProviderConfig_Error"}},
-		// https://issues.redhat.com/browse/OSD-6363
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterOperatorDegraded", "name": "authentication", "reason": "OAuthServerConfigObservation_Error"}},
-		//https://issues.redhat.com/browse/OSD-8320
-		// Sometimes only CLusterOperatorDown is firing, meaning the suppression set below in this file does not work
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "authentication", "reason": "IdentityProviderConfig_Error"}},
-		//https://issues.redhat.com/browse/OSD-8320
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "authentication", "reason": "OAuthServerConfigObservation_Error"}},
-		//https://issues.redhat.com/browse/OSD-7671
-		// might also be removed by https://issues.redhat.com/browse/OSD-11273
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "FluentdQueueLengthBurst", "namespace": "openshift-logging", "severity": "warning"}},
-		// https://issues.redhat.com/browse/OSD-9061
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterAutoscalerUnschedulablePods", "namespace": "openshift-machine-api"}},
-		// https://issues.redhat.com/browse/OSD-9062
-		{Receiver: receiverNull, Match: map[string]string{"severity": "alert"}},
-		// https://issues.redhat.com/browse/OSD-14071
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "MultipleDefaultStorageClasses", "namespace": "openshift-cluster-storage-operator"}},
-		// https://issues.redhat.com/browse/OSD-14857
-		{Receiver: receiverNull, MatchRE: map[string]string{"mountpoint": "/var/lib/ibmc-s3fs.*"}, Match: map[string]string{"alertname": "NodeFilesystemAlmostOutOfSpace", "severity": "critical"}},
-		// https://issues.redhat.com/browse/OSD-1922
-		{Receiver: receiverWarning, Match: map[string]string{"alertname": "KubeAPILatencyHigh", "severity": "critical"}},
-		// https://issues.redhat.com/browse/OSD-8983
-		{Receiver: receiverWarning, Match: map[string]string{"alertname": "etcdGRPCRequestsSlow", "namespace": "openshift-etcd"}},
-		// https://issues.redhat.com/browse/ROSAENG-59423
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "etcdMembersDown", "namespace": "openshift-etcd"}},
-		// https://issues.redhat.com/browse/OSD-10473
-		{Receiver: receiverWarning, Match: map[string]string{"alertname": "ExtremelyHighIndividualControlPlaneCPU", "namespace": "openshift-kube-apiserver"}},
-		// https://issues.redhat.com/browse/DVO-54
-		{Receiver: receiverWarning, Match: map[string]string{"severity": "critical", "namespace": "openshift-deployment-validation-operator"}},
-		// Ensure NodeClockNotSynchronising is routed to PD as a high alert
-		// https://issues.redhat.com/browse/OSD-8736
-		{Receiver: receiverError, Match: map[string]string{"alertname": "NodeClockNotSynchronising", "prometheus": "openshift-monitoring/k8s"}},
-		// fluentd: route any fluentd alert to PD/GoAlert
-		// https://issues.redhat.com/browse/OSD-3326
-		{Receiver: receiverCommon, Match: map[string]string{"job": "fluentd", "prometheus": "openshift-monitoring/k8s"}},
-		// elasticsearch: route any ES alert to PD
-		// https://issues.redhat.com/browse/OSD-3326
-		{Receiver: receiverCommon, Match: map[string]string{"cluster": "elasticsearch", "prometheus": "openshift-monitoring/k8s"}},
-		// https://issues.redhat.com/browse/OSD-20058
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "KubeAPIErrorBudgetBurn", "prometheus": "openshift-monitoring/k8s"}},
-		// Route HaproxyDown alert in cluster-ingress-operator to null
-		// use the SRE managed alerts instead, so that we can ignore the alert
-		// for non-default ingresscontroller in 4.13+ when user can control their own
-		// https://issues.redhat.com/browse/OSD-16014
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "HAProxyDown"}},
-		// Route CertificateIsAboutToExpire alert to null
-		// https://issues.redhat.com/browse/OSD-19439
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "CertificateIsAboutToExpire"}},
-		// Route ClusterOperatorDown for insights to null receiver https://issues.redhat.com/browse/OSD-19800
-		// Also needs to be silenced for FedRAMP until its made available in the environment https://issues.redhat.com/browse/OSD-13685
-		{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "insights"}},
-	}
-
-	if !config.IsFedramp() {
-		// Route ClusterOperatorDown for monitoring to null receiver https://issues.redhat.com/browse/OSD-19769
-		subroute = append(subroute,
-			&alertmanager.Route{Receiver: receiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "monitoring"}},
-		)
-	}
-
-	for _, namespace := range namespaceList {
-		if receiver == Pagerduty {
-			subroute = append(subroute, []*alertmanager.Route{
-				// https://issues.redhat.com/browse/OSD-3086
-				// https://issues.redhat.com/browse/OSD-5872
-				{Receiver: receiverCommon, MatchRE: map[string]string{"exported_namespace": namespace}, Match: map[string]string{"prometheus": "openshift-monitoring/k8s"}},
-				// general: route anything in core namespaces to PD
-				{Receiver: receiverCommon, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s"}},
-			}...)
-		}
-		// GoAlert config
-		if receiver == GoAlert {
-			subroute = append(subroute, []*alertmanager.Route{
-				{Receiver: receiverCritical, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s", "severity": "critical"}},
-				{Receiver: receiverError, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s", "severity": "error"}},
-				{Receiver: receiverWarning, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s", "severity": "warning"}},
-			}...)
-		}
-	}
-
-	return &alertmanager.Route{
-		Receiver: receiverDefault,
-		GroupByStr: []string{
-			"alertname",
-			"severity",
-		},
-		Continue: true,
-		Routes:   subroute,
-	}
-}
-
-// createOCMAgentRoute creates an AlertManager Route for OcmAgent in memory.
-func createOCMAgentRoute() *alertmanager.Route {
-	return &alertmanager.Route{
-		Receiver:       receiverOCMAgent,
-		Continue:       false,
-		Match:          map[string]string{managedNotificationLabel: "true"},
-		RepeatInterval: "10m",
-	}
-}
-
-// createOCMAgentReceiver creates an AlertManager Receiver for OCM Agent in memory.
-func createOCMAgentReceiver(ocmAgentURL string) []*alertmanager.Receiver {
-	if ocmAgentURL == "" {
-		return []*alertmanager.Receiver{}
-	}
-
-	ocmAgentConfig := &alertmanager.WebhookConfig{
-		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
-		URL:            ocmAgentURL,
-	}
-
-	return []*alertmanager.Receiver{
-		{
-			Name:           receiverOCMAgent,
-			WebhookConfigs: []*alertmanager.WebhookConfig{ocmAgentConfig},
-		},
-	}
-}
-
-// createPagerdutyConfig creates an AlertManager PagerdutyConfig for PagerDuty in memory.
-func createPagerdutyConfig(pagerdutyRoutingKey, clusterID, clusterRegion string, clusterProxy string) *alertmanager.PagerdutyConfig {
-	detailsMap := map[string]string{
-		"alert_name":   `{{ .CommonLabels.alertname }}`,
-		"link":         `{{ if .CommonAnnotations.runbook_url }}{{ .CommonAnnotations.runbook_url }}{{ else if .CommonAnnotations.link }}{{ .CommonAnnotations.link }}{{ else if .CommonLabels.link }}{{ .CommonLabels.link }}{{ else }}https://github.com/openshift/ops-sop/tree/master/v4/alerts/{{ .CommonLabels.alertname }}.md{{ end }}`,
-		"ocm_link":     fmt.Sprintf("https://console.redhat.com/openshift/details/%s", clusterID),
-		"num_firing":   `{{ .Alerts.Firing | len }}`,
-		"num_resolved": `{{ .Alerts.Resolved | len }}`,
-		"resolved":     `{{ template "pagerduty.default.instances" .Alerts.Resolved }}`,
-		"cluster_id":   clusterID,
-		"region":       clusterRegion,
-	}
-	clientURL := `{{ template "pagerduty.default.clientURL" . }}`
-
-	if config.IsFedramp() {
-		detailsMap["ocm_link"] = ``
-		detailsMap["resolved"] = ``
-		detailsMap["cluster_id"] = ``
-		detailsMap["region"] = ``
-		detailsMap["firing"] = ``
-
-		// The default value contains the cluster name which is considered sensitive
-		// information for FedRAMP, so setting it to "ROSA"
-		clientURL = "ROSA"
-	}
-
-	return &alertmanager.PagerdutyConfig{
-		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
-		RoutingKey:     pagerdutyRoutingKey,
-		Severity:       `{{ if .CommonLabels.severity }}{{ .CommonLabels.severity | toLower }}{{ else }}critical{{ end }}`,
-		ClientURL:      clientURL,
-		Description:    `{{ .CommonLabels.alertname }} {{ .CommonLabels.severity | toUpper }} ({{ len .Alerts }})`,
-		Details:        detailsMap,
-		HttpConfig:     createHttpConfig(clusterProxy),
-	}
-}
-
-func createCADPagerdutyReceivers(pagerdutyRoutingKey, clusterID, clusterRegion string, clusterProxy string) []*alertmanager.Receiver {
-	if pagerdutyRoutingKey == "" {
-		return []*alertmanager.Receiver{}
-	}
-
-	return []*alertmanager.Receiver{
-		{
-			Name:             receiverCADPagerduty,
-			PagerdutyConfigs: []*alertmanager.PagerdutyConfig{createPagerdutyConfig(pagerdutyRoutingKey, clusterID, clusterRegion, clusterProxy)},
-		},
-	}
-}
-
-// createPagerdutyReceivers creates an AlertManager Receiver for PagerDuty in memory.
-func createPagerdutyReceivers(pagerdutyRoutingKey, clusterID, clusterRegion string, clusterProxy string) []*alertmanager.Receiver {
-	if pagerdutyRoutingKey == "" {
-		return []*alertmanager.Receiver{}
-	}
-
-	receivers := []*alertmanager.Receiver{
-		{
-			Name:             receiverPagerduty,
-			PagerdutyConfigs: []*alertmanager.PagerdutyConfig{createPagerdutyConfig(pagerdutyRoutingKey, clusterID, clusterRegion, clusterProxy)},
-		},
-	}
-
-	// make-it-warning overrides the severity
-	pdconfig := createPagerdutyConfig(pagerdutyRoutingKey, clusterID, clusterRegion, clusterProxy)
-	pdconfig.Severity = "warning"
-	receivers = append(receivers, &alertmanager.Receiver{
-		Name:             receiverMakeItWarning,
-		PagerdutyConfigs: []*alertmanager.PagerdutyConfig{pdconfig},
-	})
-
-	// make-it-error overrides the severity
-	highpdconfig := createPagerdutyConfig(pagerdutyRoutingKey, clusterID, clusterRegion, clusterProxy)
-	highpdconfig.Severity = "error"
-	receivers = append(receivers, &alertmanager.Receiver{
-		Name:             receiverMakeItError,
-		PagerdutyConfigs: []*alertmanager.PagerdutyConfig{highpdconfig},
-	})
-
-	// make-it-critical overrides the severity
-	criticalpdconfig := createPagerdutyConfig(pagerdutyRoutingKey, clusterID, clusterRegion, clusterProxy)
-	criticalpdconfig.Severity = "critical"
-	receivers = append(receivers, &alertmanager.Receiver{
-		Name:             receiverMakeItCritical,
-		PagerdutyConfigs: []*alertmanager.PagerdutyConfig{criticalpdconfig},
+	alertmanagerconfig, warnings, err := render.Render(render.Inputs{
+		Cluster:      render.Cluster{ID: clusterID, Region: clusterRegion, Proxy: clusterProxy},
+		Profile:      render.Profile{FedRAMP: config.IsFedramp(), Management: readMC},
+		Integrations: integrations,
+		Namespaces:   namespaces,
 	})
-
-	return receivers
-}
-
-func createGoalertConfig(goalertURL, clusterProxy string) *alertmanager.WebhookConfig {
-
-	return &alertmanager.WebhookConfig{
-		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
-		URL:            goalertURL,
-		HttpConfig:     createHttpConfig(clusterProxy),
-	}
-}
-
-func createGoalertReceiver(goalertURL, goalertReceiverName, clusterProxy string) []*alertmanager.Receiver {
-	if goalertURL == "" {
-		return []*alertmanager.Receiver{}
-	}
-
-	receivers := []*alertmanager.Receiver{
-		{
-			Name:           goalertReceiverName,
-			WebhookConfigs: []*alertmanager.WebhookConfig{createGoalertConfig(goalertURL, clusterProxy)},
-		},
-	}
-
-	return receivers
-}
-
-// creatHeartbeatRoute creates an AlertManager Route for GoAlert Heartbeat in memory.
-func createHeartbeatRoute() *alertmanager.Route {
-	return &alertmanager.Route{
-		Receiver:       receiverGoAlertHeartbeat,
-		RepeatInterval: "5m",
-		Match:          map[string]string{"alertname": "Watchdog"},
-		Continue:       true,
-	}
-}
-
-// createWatchdogRoute creates an AlertManager Route for Watchdog (Dead Man's Snitch) in memory.
-func createWatchdogRoute() *alertmanager.Route {
-	return &alertmanager.Route{
-		Receiver:       receiverWatchdog,
-		RepeatInterval: "5m",
-		Match:          map[string]string{"alertname": "Watchdog"},
-		Continue:       true,
+	logRenderWarnings(reqLogger, warnings)
+	if err != nil {
+		reqLogger.Error(err, "Unable to render alertmanager config")
+		outcome.err = fmt.Errorf("unable to render alertmanager config: %w", err)
+		return reconcile.Result{}, err
 	}
-}
 
-func createCADPagerdutyRoute() *alertmanager.Route {
-	return &alertmanager.Route{
-		Match:    map[string]string{routeCADLabel: routeCADLabelValue},
-		Receiver: receiverCADPagerduty,
-	}
-}
+	// add routing owned by component teams in AlertmanagerConfig resources
+	alertmanagerconfig, aggregated := r.aggregateAlertmanagerConfigs(ctx, reqLogger, alertmanagerconfig, clusterProxy)
 
-func createHeartbeatReceivers(heartbeatURL string, clusterProxy string) []*alertmanager.Receiver {
-	if heartbeatURL == "" {
-		return []*alertmanager.Receiver{}
+	// merge SRE-supplied config fragments, falling back to the generated config if they are rejected
+	alertmanagerconfig, err = r.applyOverrides(reqLogger, alertmanagerconfig, secretList, cmList)
+	if err != nil {
+		outcome.err = err
 	}
 
-	heartbeatconfig := &alertmanager.WebhookConfig{
-		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
-		URL:            heartbeatURL,
-		HttpConfig:     createHttpConfig(clusterProxy),
+	// merge equivalent routes, such as those for each managed namespace, to keep the routing tree small
+	if config.GetSettings().CompactRoutes {
+		alertmanagerconfig.Route = routing.Compact(alertmanagerconfig.Route)
 	}
 
-	return []*alertmanager.Receiver{
-		{
-			Name:           receiverGoAlertHeartbeat,
-			WebhookConfigs: []*alertmanager.WebhookConfig{heartbeatconfig},
-		},
-	}
-}
+	outcome.inputs = observedInputs(config.GetSettings(), secretList, cmList, aggregated)
+	outcome.inputs.ClusterType = v1alpha1.ClusterType(profile.clusterType)
+	outcome.inputs.NamespaceSources = namespaceSources
+	outcome.integrations = integrationsInConfig(alertmanagerconfig)
+	outcome.renderedHash = configHash(alertmanagerconfig)
 
-// createWatchdogReceivers creates an AlertManager Receiver for Watchdog (Dead Man's Snitch) in memory.
-func createWatchdogReceivers(watchdogURL string, clusterProxy string) []*alertmanager.Receiver {
-	if watchdogURL == "" {
-		return []*alertmanager.Receiver{}
+	// write the alertmanager Config, unless manual edits to it should be left alone. In the
+	// AlertmanagerConfig output mode alertmanager-main belongs to cluster-monitoring-operator.
+	reason, message := "", ""
+	if config.GetSettings().OutputMode != config.OutputModeAlertmanagerConfig {
+		reason, message = r.checkManualEdits(ctx, reqLogger, platformTarget(), alertmanagerconfig)
 	}
-
-	snitchconfig := &alertmanager.WebhookConfig{
-		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
-		URL:            watchdogURL,
-		HttpConfig:     createHttpConfig(clusterProxy),
+	if reason != "" {
+		outcome.writeSkipReason = reason
+		outcome.writeSkipMessage = message
+	} else {
+		outcome.writeAttempted = true
+		if err := writeAlertManagerConfig(ctx, r, reqLogger, platformTarget(), alertmanagerconfig); err != nil {
+			reqLogger.Error(err, "Failed to write alertmanager config")
+			if stderrors.Is(err, errConfigInvalid) {
+				outcome.validationErr = err
+			}
+			outcome.err = err
+			return reconcile.Result{}, err
+		}
+		outcome.written = true
 	}
 
-	return []*alertmanager.Receiver{
-		{
-			Name:           receiverWatchdog,
-			WebhookConfigs: []*alertmanager.WebhookConfig{snitchconfig},
-		},
-	}
-}
+	// Update metrics after all reconcile operations are complete.
+	updateIntegrationMetrics(secretList, alertmanagerconfig)
+	metrics.UpdateConfigMapMetrics(cmList)
+	reqLogger.Info("Finished reconcile for secret.")
 
-// createHttpConfig creates a HttpConfig used for receivers that can accept that configuration
-func createHttpConfig(clusterProxy string) alertmanager.HttpConfig {
-	if clusterProxy == "" {
-		return alertmanager.HttpConfig{}
-	}
-	return alertmanager.HttpConfig{
-		ProxyURL:  clusterProxy,
-		TLSConfig: alertmanager.TLSConfig{},
-	}
+	// The readiness Result decides whether we should requeue, effectively "polling" the readiness logic.
+	// Secrets read by AlertmanagerConfigs aren't watched, so they are polled too.
+	return requeueForSecretRotation(r.Readiness.Result(), aggregated), nil
+}
+
+// enqueueAlertmanagerSecret maps a change to the ConfigureAlertmanager resource onto a
+// reconcile of the alertmanager-main secret, and the alertmanager-user-workload secret if
+// it is managed, so new settings are applied straight away.
+func enqueueAlertmanagerSecret(_ context.Context, _ client.Object) []reconcile.Request {
+	requests := []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Namespace: config.ManagedNamespace(),
+		Name:      secretNameAlertmanager,
+	}}}
+	if config.UserWorkloadEnabled() {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: config.UserWorkloadNamespace,
+			Name:      secretNameUserWorkloadAlertmanager,
+		}})
+	}
+	return requests
+}
+
+// clusterOperatorPredicate passes ClusterOperator updates that start or stop blocking
+// readiness, so a cluster resuming from hibernation is re-checked without a restart.
+var clusterOperatorPredicate = predicate.Funcs{
+	CreateFunc: func(event.CreateEvent) bool { return false },
+	DeleteFunc: func(event.DeleteEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCO, okOld := e.ObjectOld.(*configv1.ClusterOperator)
+		newCO, okNew := e.ObjectNew.(*configv1.ClusterOperator)
+		return !okOld || !okNew || readiness.IsBlocking(oldCO) != readiness.IsBlocking(newCO)
+	},
+	GenericFunc: func(event.GenericEvent) bool { return false },
 }
 
-// createAlertManagerConfig creates an AlertManager Config in memory based on the provided input parameters.
-func createAlertManagerConfig(reqLogger logr.Logger, pagerdutyRoutingKey, cadPagerdutyRoutingKey, goalertURLlow, goalertURLhigh, goalertURLheartbeat, watchdogURL, ocmAgentURL, clusterID, clusterRegion string, clusterProxy string, namespaceList []string) *alertmanager.Config {
-	routes := []*alertmanager.Route{}
-	receivers := []*alertmanager.Receiver{}
-
-	if watchdogURL != "" {
-		reqLogger.Info("INFO: Configuring a watchdog route and receiver")
-		routes = append(routes, createWatchdogRoute())
-		receivers = append(receivers, createWatchdogReceivers(watchdogURL, clusterProxy)...)
-	}
-
-	if ocmAgentURL != "" {
-		routes = append(routes, createOCMAgentRoute())
-		receivers = append(receivers, createOCMAgentReceiver(ocmAgentURL)...)
-	}
-
-	if cadPagerdutyRoutingKey != "" {
-		reqLogger.Info("INFO: Configuring a CAD PagerDuty route and receiver")
-		routes = append(routes, createCADPagerdutyRoute())
-		receivers = append(receivers, createCADPagerdutyReceivers(cadPagerdutyRoutingKey, clusterID, clusterRegion, clusterProxy)...)
-	}
-
-	if pagerdutyRoutingKey != "" {
-		reqLogger.Info("INFO: Configuring a PagerDuty route and receiver")
-		routes = append(routes, createSubroutes(namespaceList, Pagerduty))
-		receivers = append(receivers, createPagerdutyReceivers(pagerdutyRoutingKey, clusterID, clusterRegion, clusterProxy)...)
-	}
+// SetupWithManager sets up the controller with the Manager.
+func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	client := mgr.GetClient()
 
-	if goalertURLlow != "" && goalertURLhigh != "" {
-		reqLogger.Info("INFO: Configuring a GoAlert route and receiver")
-		routes = append(routes, createSubroutes(namespaceList, GoAlert))
-		receivers = append(receivers, createGoalertReceiver(goalertURLlow, receiverGoAlertLow, clusterProxy)...)
-		receivers = append(receivers, createGoalertReceiver(goalertURLhigh, receiverGoAlertHigh, clusterProxy)...)
-	} else {
-		reqLogger.Info("INFO: Not configuring GoAlert receivers")
-	}
+	r.Readiness = &readiness.Impl{Client: client, Recorder: r.Recorder}
 
-	if goalertURLheartbeat != "" {
-		reqLogger.Info("INFO: Configuring a GoAlert heartbeat route and receiver")
-		routes = append(routes, createHeartbeatRoute())
-		receivers = append(receivers, createHeartbeatReceivers(goalertURLheartbeat, clusterProxy)...)
-	} else {
-		reqLogger.Info("INFO: Not configuring GoAlert Heartbeat receivers")
-	}
-
-	// always have the "null" receiver
-	receivers = append(receivers, &alertmanager.Receiver{Name: receiverNull})
-
-	amconfig := &alertmanager.Config{
-		Global: &alertmanager.GlobalConfig{
-			ResolveTimeout: "5m",
-			PagerdutyURL:   pagerdutyURL,
-		},
-		Route: &alertmanager.Route{
-			Receiver: defaultReceiver,
-			GroupByStr: []string{
-				"job",
-			},
-			GroupWait:      "30s",
-			GroupInterval:  "5m",
-			RepeatInterval: "12h",
-			Routes:         routes,
-		},
-		Receivers: receivers,
-		Templates: []string{},
-		// Work request: https://issues.redhat.com/browse/OSD-4623
-		// Reference: https://github.com/openshift/cluster-monitoring-operator/blob/6a02b14773169330d7a31ede73dce5adb1c66bb4/assets/alertmanager/secret.yaml
-		InhibitRules: []*alertmanager.InhibitRule{
-			{
-				// Critical alert shouldn't also alert for warning/info
-				Equal: []string{
-					"namespace",
-					"alertname",
-				},
-				SourceMatch: map[string]string{
-					"severity": "critical",
-				},
-				TargetMatchRE: map[string]string{
-					"severity": "warning|info",
-				},
-			},
-			{
-				// Warning alerts shouldn't also alert for info
-				Equal: []string{
-					"namespace",
-					"alertname",
-				},
-				SourceMatch: map[string]string{
-					"severity": "warning",
-				},
-				TargetMatchRE: map[string]string{
-					"severity": "info",
-				},
-			},
-			{
-				// If a cluster operator is degraded, don't also fire ClusterOperatorDown
-				// The degraded alert is critical, and usually has more details
-				Equal: []string{
-					"namespace",
-					"name",
-				},
-				SourceMatch: map[string]string{
-					"alertname": "ClusterOperatorDegraded",
-					"severity":  "critical",
-				},
-				TargetMatchRE: map[string]string{
-					"alertname": "ClusterOperatorDown",
-				},
-			},
-			{
-				// If a node is not ready, we already know it's Unreachable
-				Equal: []string{
-					"node",
-					"instance",
-				},
-				SourceMatch: map[string]string{
-					"alertname": "KubeNodeNotReady",
-				},
-				TargetMatchRE: map[string]string{
-					"alertname": "KubeNodeUnreachable",
-				},
-			},
-			{
-				// node being Unreachable may also trigger certain pods being unavailable
-				SourceMatch: map[string]string{
-					"alertname": "KubeNodeUnreachable",
-				},
-				TargetMatchRE: map[string]string{
-					"alertname": "SDNPodNotReady|TargetDown",
-				},
-			},
-			{
-				// If a node is NotReady, then we also know that there will be pods that aren't running
-				Equal: []string{
-					"instance",
-				},
-				SourceMatch: map[string]string{
-					"alertname": "KubeNodeNotReady",
-				},
-				TargetMatchRE: map[string]string{
-					"alertname": "KubeDaemonSetRolloutStuck|KubeDaemonSetMisScheduled|KubeDeploymentReplicasMismatch|KubeStatefulSetReplicasMismatch|KubePodNotReady",
-				},
-			},
-			{
-				// If a deployment doesn't have it's replicas mismatch, then we don't need to fire for the pod not being ready
-				Equal: []string{
-					"namespace",
-				},
-				SourceMatch: map[string]string{
-					"alertname": "KubeDeploymentReplicasMismatch",
-				},
-				TargetMatchRE: map[string]string{
-					"alertname": "KubePodNotReady|KubePodCrashLooping",
-				},
-			},
-			{
-				// NB this label obviously won't match and that's both ok and expected. When a label is missing (or empty) on both source and target, the rule will apply (see: docs ).
-				// see: https://www.prometheus.io/docs/alerting/latest/configuration/#inhibit_rule
-
-				// If there wasn't a label here, the tests exploded spectacularly, so I figured a label that would never match is the next best thing.
-				Equal: []string{
-					"dummylabel",
-				},
-				SourceMatch: map[string]string{
-					"alertname": "ElasticsearchOperatorCSVNotSuccessful",
-				},
-				TargetMatchRE: map[string]string{
-					"alertname": "ElasticsearchClusterNotHealthy",
-				},
-			},
-			// https://issues.redhat.com/browse/OSD-13721
-			{
-				Equal: []string{
-					"severity",
-				},
-				SourceMatch: map[string]string{
-					"alertname": "KubeAPIErrorBudgetBurn",
-				},
-				TargetMatchRE: map[string]string{
-					"alertname": "api-ErrorBudgetBurn",
-				},
-			},
-		},
+	if err := mgr.Add(manager.RunnableFunc(r.runRoutingCoverage)); err != nil {
+		return err
 	}
 
-	return amconfig
-}
-
-// Retrieves data from all relevant configMaps. Returns a list of namespaces, represented as regular expressions, to monitor
-func (r *SecretReconciler) parseConfigMaps(reqLogger logr.Logger, cmList *corev1.ConfigMapList, cmNamespace string) (namespaceList []string) {
+	controller := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Secret{}).
+		Watches(&corev1.ConfigMap{}, &handler.EnqueueRequestForObject{}).
+		// The cluster type is re-detected when either of these changes
+		Watches(&configv1.ClusterVersion{}, handler.EnqueueRequestsFromMapFunc(r.enqueueOnClusterProfileChange),
+			builder.WithPredicates(clusterProfilePredicate)).
+		Watches(&configv1.Infrastructure{}, handler.EnqueueRequestsFromMapFunc(r.enqueueOnClusterProfileChange),
+			builder.WithPredicates(clusterProfilePredicate)).
+		// Readiness is re-evaluated when ClusterOperators become unready, e.g. on resume
+		Watches(&configv1.ClusterOperator{}, handler.EnqueueRequestsFromMapFunc(enqueueAlertmanagerSecret),
+			builder.WithPredicates(clusterOperatorPredicate)).
+		// Only spec changes bump the generation, so our own status updates don't retrigger a reconcile
+		Watches(&v1alpha1.ConfigureAlertmanager{}, handler.EnqueueRequestsFromMapFunc(enqueueAlertmanagerSecret),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Only Namespace metadata is watched and cached, to keep memory use down on large clusters
+		WatchesMetadata(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(enqueueAlertmanagerSecret),
+			builder.WithPredicates(predicate.Or(hostedControlPlanePredicate, managedAlertingPredicate)))
+	// AlertmanagerConfigs are only cached, and so only watched, if some were selected at startup
+	if len(config.AlertmanagerConfigNamespaces()) > 0 {
+		controller = controller.Watches(&monitoringv1beta1.AlertmanagerConfig{}, handler.EnqueueRequestsFromMapFunc(enqueueAlertmanagerSecret))
+	}
+	return controller.Complete(r)
+}
+
+// logRenderWarnings logs the inputs the rendered config ignored or may not use as intended.
+func logRenderWarnings(reqLogger logr.Logger, warnings []render.Warning) {
+	for _, warning := range warnings {
+		reqLogger.Info("WARNING: "+warning.Message, "Input", warning.Input)
+	}
+}
+
+// Retrieves data from all relevant configMaps. Returns a sorted list of namespaces, represented as regular
+// expressions, to monitor, including the labelled namespaces, and how each configMap was used. A configMap
+// that is missing or can't be parsed is replaced by its default namespaces; the others are still used.
+// The owning teams set in the configMaps are returned by regular expression, the first one set winning.
+func (r *SecretReconciler) parseConfigMaps(reqLogger logr.Logger, cmList *corev1.ConfigMapList, cmNamespace string, readMC bool, labelledNamespaces []string) (namespaceList []string, teams map[string]string, sources []v1alpha1.NamespaceSource) {
 	// Retrieve namespaces from their respective configMaps, if the configMaps exist
-	managedNamespaces := r.parseNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, cmNamespace, cmKeyManagedNamespaces, cmList)
-	ocpNamespaces := r.parseNamespaceConfigMap(reqLogger, cmNameOCPNamespaces, cmNamespace, cmKeyOCPNamespaces, cmList)
+	managedNamespaces, managedTeams := r.parseNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, cmNamespace, cmKeyManagedNamespaces, cmList)
+	ocpNamespaces, ocpTeams := r.parseNamespaceConfigMap(reqLogger, cmNameOCPNamespaces, cmNamespace, cmKeyOCPNamespaces, cmList)
 
-	// Check if this is a management cluster and load MC-specific namespaces if so
+	// Load MC-specific namespaces on a management cluster
 	var mcNamespaces []string
-	readMC, err := r.isManagementCluster()
-	if err != nil {
-		// Default to true (management cluster) for safety
-		readMC = true
-		reqLogger.Error(err, "CRITICAL: Unable to determine if cluster is management cluster - defaulting to MANAGEMENT CLUSTER behavior",
-			"default_behavior", "loading_mc_namespaces",
-			"action_required", "investigate this error immediately - file issue at https://github.com/openshift/configure-alertmanager-operator/issues if not already reported")
-		// Log stack trace for debugging
-		reqLogger.Error(err, "Stack trace for cluster type determination failure", "stack", string(debug.Stack()))
-		// Generate a Kubernetes event to alert SRE
-		r.recordClusterTypeDetectionEvent(err)
-	}
-
+	var mcTeams map[string]string
 	if readMC {
 		reqLogger.Info("INFO: Management cluster detected, loading MC-specific namespaces for alerting")
-		mcNamespaces = r.parseMCNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, cmNamespace, cmList)
+		mcNamespaces, mcTeams = r.parseMCNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, cmNamespace, cmList)
 	} else {
 		reqLogger.Info("INFO: Not a management cluster, skipping MC-specific namespaces")
 	}
 
-	// Default to alerting on all ^openshift-.* namespaces if either list is empty, potentially indicating a problem parsing configMaps
-	if len(managedNamespaces) == 0 ||
-		len(ocpNamespaces) == 0 {
-		reqLogger.Info("DEBUG: Could not retrieve namespaces from one or more configMaps. Using default namespaces", "Default namespaces", defaultNamespaces)
-		return defaultNamespaces
+	for _, source := range []struct {
+		name       string
+		namespaces []string
+		teams      map[string]string
+		defaults   []string
+		skipped    bool
+	}{
+		{name: namespaceSourceManaged, namespaces: managedNamespaces, teams: managedTeams, defaults: defaultManagedNamespaces},
+		{name: namespaceSourceOCP, namespaces: ocpNamespaces, teams: ocpTeams, defaults: defaultOCPNamespaces},
+		{name: namespaceSourceManagementCluster, namespaces: mcNamespaces, teams: mcTeams, skipped: !readMC},
+	} {
+		namespaces, state := source.namespaces, v1alpha1.NamespaceSourceLoaded
+		switch {
+		case source.skipped:
+			state = v1alpha1.NamespaceSourceSkipped
+		case len(namespaces) == 0 && len(source.defaults) > 0:
+			// Default to alerting on the source's default namespaces, as there may be a problem parsing the configMap
+			reqLogger.Info("DEBUG: Could not retrieve namespaces from configMap. Using its default namespaces", "Source", source.name, "Default namespaces", source.defaults)
+			namespaces, state = source.defaults, v1alpha1.NamespaceSourceDefaulted
+		case len(namespaces) == 0:
+			state = v1alpha1.NamespaceSourceEmpty
+		}
+		namespaceList = append(namespaceList, namespaces...)
+		for re, team := range source.teams {
+			if _, ok := teams[re]; ok {
+				continue
+			}
+			if teams == nil {
+				teams = map[string]string{}
+			}
+			teams[re] = team
+		}
+		sources = append(sources, v1alpha1.NamespaceSource{Name: source.name, State: state, Namespaces: int32(len(namespaces))})
+		metrics.UpdateNamespaceSourceMetric(source.name, string(state), len(namespaces))
 	}
 
-	namespaceList = append(namespaceList, managedNamespaces...)
-	namespaceList = append(namespaceList, ocpNamespaces...)
-	namespaceList = append(namespaceList, mcNamespaces...)
-
-	return namespaceList
+	namespaceList = append(namespaceList, labelledNamespaces...)
+	slices.Sort(namespaceList)
+	return slices.Compact(namespaceList), teams, sources
 }
 
-// Returns the namespaces from a *-namespaces configMap as a list of regular expressions
-func (r *SecretReconciler) parseNamespaceConfigMap(reqLogger logr.Logger, cmName string, cmNamespace string, cmKey string, cmList *corev1.ConfigMapList) (nsList []string) {
+// Returns the namespaces from a *-namespaces configMap as a list of regular expressions, and the
+// owning teams of those that have one, by regular expression
+func (r *SecretReconciler) parseNamespaceConfigMap(reqLogger logr.Logger, cmName string, cmNamespace string, cmKey string, cmList *corev1.ConfigMapList) (nsList []string, teams map[string]string) {
+	rejected := 0
+	defer func() {
+		metrics.UpdateNamespaceEntriesRejectedMetric(cmNamespace+"/"+cmName, namespaceListNamespaces, rejected)
+	}()
+
 	cmExists := cmInList(reqLogger, cmName, cmList)
 	if !cmExists {
 		reqLogger.Info("INFO: ConfigMap does not exist", "ConfigMap", cmNameManagedNamespaces)
-		return []string{}
+		return []string{}, nil
 	}
 
 	// Unmarshal configMap, fail on error or if no namespaces are present in decoded config
@@ -1010,25 +586,28 @@ func (r *SecretReconciler) parseNamespaceConfigMap(reqLogger logr.Logger, cmName
 	err := yaml.Unmarshal([]byte(rawNamespaces), &namespaceConfig)
 	if err != nil {
 		reqLogger.Info("DEBUG: Unable to unmarshal from configMap", "ConfigMap", fmt.Sprintf("%s/%s", cmNamespace, cmName), "Error", err)
-		return []string{}
+		return []string{}, nil
 	} else if len(namespaceConfig.Resources.Namespaces) == 0 {
 		reqLogger.Info("DEBUG: No namespaces found in configMap", "ConfigMap", fmt.Sprintf("%s/%s", cmNamespace, cmName))
-		return []string{}
+		return []string{}, nil
 	}
 
-	for _, ns := range namespaceConfig.Resources.Namespaces {
-		nsList = append(nsList, "^"+ns.Name+"$")
-	}
-	return nsList
+	nsList, teams, rejected = r.namespaceMatchREs(reqLogger, cmName, cmNamespace, namespaceListNamespaces, namespaceConfig.Resources.Namespaces)
+	return nsList, teams
 }
 
 // parseMCNamespaceConfigMap returns management-cluster-specific namespaces from managed-namespaces configMap
 // This function extracts the ManagementCluster.AdditionalNamespaces list which should only be used on management clusters
-func (r *SecretReconciler) parseMCNamespaceConfigMap(reqLogger logr.Logger, cmName string, cmNamespace string, cmList *corev1.ConfigMapList) (nsList []string) {
+func (r *SecretReconciler) parseMCNamespaceConfigMap(reqLogger logr.Logger, cmName string, cmNamespace string, cmList *corev1.ConfigMapList) (nsList []string, teams map[string]string) {
+	rejected := 0
+	defer func() {
+		metrics.UpdateNamespaceEntriesRejectedMetric(cmNamespace+"/"+cmName, namespaceListAdditionalNamespaces, rejected)
+	}()
+
 	cmExists := cmInList(reqLogger, cmName, cmList)
 	if !cmExists {
 		reqLogger.Info("INFO: ConfigMap does not exist", "ConfigMap", cmName)
-		return []string{}
+		return []string{}, nil
 	}
 
 	// Unmarshal configMap to get ManagementCluster configuration
@@ -1037,26 +616,74 @@ func (r *SecretReconciler) parseMCNamespaceConfigMap(reqLogger logr.Logger, cmNa
 	err := yaml.Unmarshal([]byte(rawNamespaces), &namespaceConfig)
 	if err != nil {
 		reqLogger.Info("DEBUG: Unable to unmarshal from configMap", "ConfigMap", fmt.Sprintf("%s/%s", cmNamespace, cmName), "Error", err)
-		return []string{}
+		return []string{}, nil
 	}
 
 	// Extract MC-specific namespaces from ManagementCluster.AdditionalNamespaces
 	if namespaceConfig.Resources.ManagementCluster == nil {
 		reqLogger.Info("DEBUG: No ManagementCluster configuration found in configMap", "ConfigMap", fmt.Sprintf("%s/%s", cmNamespace, cmName))
-		return []string{}
+		return []string{}, nil
 	}
 
 	if len(namespaceConfig.Resources.ManagementCluster.AdditionalNamespaces) == 0 {
 		reqLogger.Info("DEBUG: No MC-specific namespaces found in ManagementCluster.AdditionalNamespaces", "ConfigMap", fmt.Sprintf("%s/%s", cmNamespace, cmName))
-		return []string{}
+		return []string{}, nil
 	}
 
-	for _, ns := range namespaceConfig.Resources.ManagementCluster.AdditionalNamespaces {
-		nsList = append(nsList, "^"+ns.Name+"$")
-	}
+	nsList, teams, rejected = r.namespaceMatchREs(reqLogger, cmName, cmNamespace, namespaceListAdditionalNamespaces, namespaceConfig.Resources.ManagementCluster.AdditionalNamespaces)
 
 	reqLogger.Info("INFO: Loaded MC-specific namespaces for alerting", "Count", len(nsList), "Namespaces", nsList)
-	return nsList
+	return nsList, teams
+}
+
+// namespaceMatchRE returns a namespace entry as an anchored regular expression for a route's
+// match_re. Literal names must be valid namespace names and are escaped; regular expressions
+// must compile.
+func namespaceMatchRE(ns alertmanager.Namespace) (string, error) {
+	if ns.Name == "" {
+		return "", fmt.Errorf("namespace entry has no name")
+	}
+	if !ns.Regex {
+		if errs := validation.IsDNS1123Label(ns.Name); len(errs) > 0 {
+			return "", fmt.Errorf("%q is not a valid namespace name (set regex: true for a pattern): %s", ns.Name, strings.Join(errs, "; "))
+		}
+		return "^" + regexp.QuoteMeta(ns.Name) + "$", nil
+	}
+	expr := "^(?:" + ns.Name + ")$"
+	if _, err := regexp.Compile(expr); err != nil {
+		return "", fmt.Errorf("%q is not a valid regular expression: %w", ns.Name, err)
+	}
+	return expr, nil
+}
+
+// namespaceMatchREs converts the entries of a namespace list in a *-namespaces configMap to
+// regular expressions. Invalid entries are skipped and reported with a Warning Event on the
+// configMap, so one bad entry doesn't change the meaning of, or drop, the others. The owning
+// teams of the valid entries are returned by regular expression.
+func (r *SecretReconciler) namespaceMatchREs(reqLogger logr.Logger, cmName string, cmNamespace string, list string, entries []alertmanager.Namespace) (nsList []string, teams map[string]string, rejected int) {
+	var errs []string
+	for _, ns := range entries {
+		re, err := namespaceMatchRE(ns)
+		if err != nil {
+			reqLogger.Info("WARNING: Skipping invalid namespace entry", "ConfigMap", fmt.Sprintf("%s/%s", cmNamespace, cmName), "List", list, "Error", err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		nsList = append(nsList, re)
+		if ns.Team != "" {
+			if teams == nil {
+				teams = map[string]string{}
+			}
+			teams[re] = ns.Team
+		}
+	}
+
+	if len(errs) > 0 {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: cmNamespace}}
+		r.recordEvent(r.eventSubject(context.TODO(), cm), corev1.EventTypeWarning, eventReasonNamespaceEntryRejected, eventActionParseNamespaces,
+			fmt.Sprintf("Skipped %d invalid entries in %s: %s", len(errs), list, strings.Join(errs, "; ")))
+	}
+	return nsList, teams, len(errs)
 }
 
 // readOCMAgentServiceURLFromConfig returns the OCM Agent service URL from the OCM Agent configmap
@@ -1077,68 +704,6 @@ func (r *SecretReconciler) readOCMAgentServiceURLFromConfig(reqLogger logr.Logge
 	return serviceURL
 }
 
-func (r *SecretReconciler) parseSecrets(reqLogger logr.Logger, secretList *corev1.SecretList, namespace string, clusterReady bool) (pagerdutyRoutingKey string, cadPagerdutyRoutingKey string, watchdogURL string, goalertURLlow string, goalertURLhigh string, goalertURLheartbeat string) {
-	// Check for the presence of specific secrets.
-	goalertSecretExists := secretInList(reqLogger, secretNameGoalert, secretList)
-	pagerDutySecretExists := secretInList(reqLogger, secretNamePD, secretList)
-	cadPagerDutySecretExists := secretInList(reqLogger, secretNameCADPD, secretList)
-	snitchSecretExists := secretInList(reqLogger, secretNameDMS, secretList)
-
-	// If a secret exists, add the necessary configs to Alertmanager.
-	// But don't activate PagerDuty/Goalert unless the cluster is "ready".
-	// This is to avoid alert noise while the cluster is still being installed and configured.
-	if pagerDutySecretExists {
-		reqLogger.Info("INFO: Pager Duty secret exists")
-		if clusterReady {
-			reqLogger.Info("INFO: Cluster is ready; configuring Pager Duty")
-			pagerdutyRoutingKey = readSecretKey(r, secretNamePD, namespace, secretKeyPD)
-		} else {
-			reqLogger.Info("INFO: Cluster is not ready; skipping Pager Duty configuration")
-		}
-	} else {
-		reqLogger.Info("INFO: Pager Duty secret does not exist")
-	}
-
-	if cadPagerDutySecretExists {
-		reqLogger.Info("INFO: CAD Pager Duty secret exists")
-		if clusterReady {
-			reqLogger.Info("INFO: Cluster is ready; configuring CAD Pager Duty")
-			cadPagerdutyRoutingKey = readSecretKey(r, secretNameCADPD, namespace, secretKeyCADPD)
-			if cadPagerdutyRoutingKey == "" {
-				reqLogger.Info("INFO: CAD Pager Duty secret exists but configuration is empty")
-			}
-		} else {
-			reqLogger.Info("INFO: Cluster is not ready; skipping CAD Pager Duty configuration")
-		}
-	} else {
-		reqLogger.Info("INFO: CAD Pager Duty secret does not exist")
-	}
-
-	if snitchSecretExists {
-		reqLogger.Info("INFO: Dead Man's Snitch secret exists")
-		watchdogURL = readSecretKey(r, secretNameDMS, namespace, secretKeyDMS)
-	} else {
-		reqLogger.Info("INFO: Dead Man's Snitch secret does not exist")
-	}
-
-	// Pulls the values needed from the goalert secret
-	if goalertSecretExists {
-		reqLogger.Info("INFO: Goalert secret exists")
-		if clusterReady {
-			reqLogger.Info("INFO: Cluster is ready; configuring Goalert")
-			goalertURLlow = readSecretKey(r, secretNameGoalert, namespace, secretKeyGoalertLow)
-			goalertURLhigh = readSecretKey(r, secretNameGoalert, namespace, secretKeyGoalertHigh)
-			goalertURLheartbeat = readSecretKey(r, secretNameGoalert, namespace, secretKeyGoalertHeartbeat)
-		} else {
-			reqLogger.Info("INFO: Cluster is not ready; skipping Goalert configuration")
-		}
-	} else {
-		reqLogger.Info("INFO: Goalert secret does not exist")
-	}
-
-	return pagerdutyRoutingKey, cadPagerdutyRoutingKey, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat
-}
-
 func (r *SecretReconciler) getClusterID() (string, error) {
 	var version configv1.ClusterVersion
 	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: "version"}, &version)
@@ -1203,75 +768,10 @@ func (r *SecretReconciler) getClusterRegion(ctx context.Context) (string, error)
 	return "", nil
 }
 
-// isManagementCluster checks if the current cluster is a HyperShift management cluster
-// by examining the infrastructureName in the Infrastructure object
-func (r *SecretReconciler) isManagementCluster() (bool, error) {
-	var infra configv1.Infrastructure
-	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: "cluster"}, &infra)
-	if err != nil {
-		return false, err
-	}
-
-	// Primary detection: Check if infrastructureName starts with "hs-mc"
-	// HyperShift management clusters have infrastructure names like "hs-mc-<cluster-id>-<hash>"
-	// Example: "hs-mc-ibv8l52c0-d4v8v"
-	if infra.Status.InfrastructureName != "" {
-		if len(infra.Status.InfrastructureName) >= 5 {
-			// Check if the name starts with "hs-mc" (first 5 characters)
-			if infra.Status.InfrastructureName[:5] == "hs-mc" {
-				return true, nil
-			}
-		}
-	}
-
-	// Fallback: Check via ClusterVersion annotations
-	var version configv1.ClusterVersion
-	err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "version"}, &version)
-	if err != nil {
-		// If we can't get the ClusterVersion, don't fail - just return false
-		return false, nil
-	}
-
-	// Check if cluster version has hypershift annotations
-	if clusterType, exists := version.Annotations[clusterTypeLabelKey]; exists {
-		return clusterType == clusterTypeManagement, nil
-	}
-
-	// Default to false if not a management cluster
-	return false, nil
-}
-
-// recordClusterTypeDetectionEvent creates a Kubernetes event to alert SRE when cluster type detection fails
+// recordClusterTypeDetectionEvent records a Warning Event to alert SRE when cluster type detection fails
 func (r *SecretReconciler) recordClusterTypeDetectionEvent(err error) {
-	event := &corev1.Event{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("cluster-type-detection-failure-%d", time.Now().Unix()),
-			Namespace: "openshift-monitoring",
-		},
-		InvolvedObject: corev1.ObjectReference{
-			Kind:      "ConfigMap",
-			Namespace: "openshift-monitoring",
-			Name:      "configure-alertmanager-operator",
-		},
-		Reason:  "ClusterTypeDetectionFailure",
-		Message: fmt.Sprintf("CRITICAL: Failed to determine cluster type - defaulting to management cluster behavior. Error: %v. Action required: This is a bug that needs to be addressed by CAMO. If an issue is not already opened, please file at https://github.com/openshift/configure-alertmanager-operator/issues", err),
-		Type:    corev1.EventTypeWarning,
-		EventTime: metav1.MicroTime{
-			Time: time.Now(),
-		},
-		FirstTimestamp: metav1.Time{
-			Time: time.Now(),
-		},
-		LastTimestamp: metav1.Time{
-			Time: time.Now(),
-		},
-		Count: 1,
-	}
-
-	// Best effort event creation - don't fail reconciliation if event creation fails
-	if createErr := r.Client.Create(context.TODO(), event); createErr != nil {
-		log.Error(createErr, "Failed to create cluster type detection failure event")
-	}
+	r.recordEvent(r.alertmanagerSecret(context.TODO()), corev1.EventTypeWarning, eventReasonClusterTypeDetectionFailure, eventActionDetectClusterType,
+		fmt.Sprintf("CRITICAL: Failed to determine cluster type - defaulting to management cluster behavior. Error: %v. Action required: This is a bug that needs to be addressed by CAMO. If an issue is not already opened, please file at https://github.com/openshift/configure-alertmanager-operator/issues", err))
 }
 
 // secretInList takes the name of Secret, and a list of Secrets, and returns a Bool
@@ -1357,70 +857,85 @@ func validateAlertManagerConfig(reqLogger logr.Logger, cfg *alertmanager.Config)
 	return nil
 }
 
-// recordConfigValidationEvent creates a Kubernetes event to alert SRE when Alertmanager config validation fails
-func (r *SecretReconciler) recordConfigValidationEvent(ctx context.Context, err error) {
-	event := &corev1.Event{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("alertmanager-config-validation-failure-%d", time.Now().Unix()),
-			Namespace: "openshift-monitoring",
-		},
-		InvolvedObject: corev1.ObjectReference{
-			Kind:      "Secret",
-			Namespace: "openshift-monitoring",
-			Name:      secretNameAlertmanager,
-		},
-		Reason:  "AlertmanagerConfigValidationFailure",
-		Message: fmt.Sprintf("CRITICAL: Failed to validate Alertmanager configuration - config will not be written to prevent Alertmanager from failing on restart. Error: %v. Action required: Check source configmaps and secrets for invalid data. Review operator logs for details.", err),
-		Type:    corev1.EventTypeWarning,
-		EventTime: metav1.MicroTime{
-			Time: time.Now(),
-		},
-		FirstTimestamp: metav1.Time{
-			Time: time.Now(),
-		},
-		LastTimestamp: metav1.Time{
-			Time: time.Now(),
-		},
-		Count: 1,
-	}
-
-	// Best effort event creation - don't fail reconciliation if event creation fails
-	if createErr := r.Client.Create(ctx, event); createErr != nil {
-		log.Error(createErr, "Failed to create Alertmanager config validation failure event")
+// recordConfigValidationEvent records a Warning Event to alert SRE when Alertmanager config validation fails
+func (r *SecretReconciler) recordConfigValidationEvent(ctx context.Context, target alertmanagerTarget, err error) {
+	r.recordEvent(r.eventSubject(ctx, target.secret()), corev1.EventTypeWarning, eventReasonConfigValidationFailure, eventActionValidateConfig,
+		fmt.Sprintf("CRITICAL: Failed to validate Alertmanager configuration - config will not be written to prevent Alertmanager from failing on restart. Error: %v. Action required: Check source configmaps and secrets for invalid data. Review operator logs for details.", err))
+}
+
+// currentAlertManagerConfig returns the target Secret and its parsed alertmanager.yaml.
+// The Secret is nil if it doesn't exist, and the config is nil if it can't be parsed.
+func (r *SecretReconciler) currentAlertManagerConfig(ctx context.Context, target alertmanagerTarget) (*corev1.Secret, *alertmanager.Config) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: target.namespace, Name: target.secretName}, secret); err != nil {
+		return nil, nil
+	}
+	return secret, parseAlertManagerConfig(secret.Data["alertmanager.yaml"])
+}
+
+// parseAlertManagerConfig parses alertmanager.yaml, returning nil if it is empty or invalid.
+func parseAlertManagerConfig(amconfigbyte []byte) *alertmanager.Config {
+	if len(amconfigbyte) == 0 {
+		return nil
+	}
+	amconfig := &alertmanager.Config{}
+	if err := yaml.Unmarshal(amconfigbyte, amconfig); err != nil {
+		return nil
 	}
+	return amconfig
 }
 
-// writeAlertManagerConfig writes the updated alertmanager config to the `alertmanager-main` secret in namespace `openshift-monitoring`.
+// writeAlertManagerConfig writes the updated alertmanager config to the target secret, usually `alertmanager-main` in namespace `openshift-monitoring`.
 // It validates the config before writing to prevent Alertmanager from failing on restart.
-func writeAlertManagerConfig(ctx context.Context, r *SecretReconciler, reqLogger logr.Logger, amconfig *alertmanager.Config) error {
+func writeAlertManagerConfig(ctx context.Context, r *SecretReconciler, reqLogger logr.Logger, target alertmanagerTarget, amconfig *alertmanager.Config) error {
 	// Validate the config before writing
 	if err := validateAlertManagerConfig(reqLogger, amconfig); err != nil {
 		reqLogger.Error(err, "ERROR: Alertmanager config validation failed - will not write to secret")
 		// Record Kubernetes event to alert SRE
-		r.recordConfigValidationEvent(ctx, err)
+		r.recordConfigValidationEvent(ctx, target, err)
 		// Update metric to indicate validation failure
-		metrics.UpdateAlertmanagerConfigValidationMetric(false)
-		return fmt.Errorf("alertmanager config validation failed, config not written: %w", err)
+		target.updateValidationMetric(false)
+		return fmt.Errorf("%w, config not written: %w", errConfigInvalid, err)
+	}
+
+	if !target.userWorkload && config.GetSettings().OutputMode == config.OutputModeAlertmanagerConfig {
+		if err := r.writeAlertmanagerConfigOutput(ctx, reqLogger, target, amconfig); err != nil {
+			reqLogger.Error(err, "ERROR: Could not write the AlertmanagerConfig output")
+			target.updateValidationMetric(false)
+			return err
+		}
+		target.updateValidationMetric(true)
+		return nil
 	}
 
 	// Config is valid, proceed with marshaling and writing
 	amconfigbyte, marshalerr := yaml.Marshal(amconfig)
 	if marshalerr != nil {
 		reqLogger.Error(marshalerr, "ERROR: failed to marshal Alertmanager config")
-		metrics.UpdateAlertmanagerConfigValidationMetric(false)
+		target.updateValidationMetric(false)
 		return fmt.Errorf("failed to marshal alertmanager config: %w", marshalerr)
 	}
 	// This is commented out because it prints secrets, but it might be useful for debugging when running locally.
 	//reqLogger.Info("DEBUG: Marshalled Alertmanager config:", string(amconfigbyte))
 
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretNameAlertmanager,
-			Namespace: "openshift-monitoring",
-		},
-		Data: map[string][]byte{
-			"alertmanager.yaml": amconfigbyte,
-		},
+	secret := target.secret()
+	secret.Data = map[string][]byte{
+		"alertmanager.yaml": amconfigbyte,
+	}
+
+	// The hash lets the next reconcile tell whether the secret was edited outside the operator.
+	secret.Annotations = map[string]string{annotationConfigHash: hashBytes(amconfigbyte)}
+
+	// Describe what this write changes. Both sides are parsed from YAML so defaults
+	// applied on unmarshal don't show up as changes.
+	var diff *configDiff
+	previous, previousConfig := r.currentAlertManagerConfig(ctx, target)
+	if previous == nil || hashBytes(previous.Data["alertmanager.yaml"]) != hashBytes(amconfigbyte) {
+		diff = diffConfigs(previousConfig, parseAlertManagerConfig(amconfigbyte))
+		diff.Time = time.Now().UTC().Format(time.RFC3339)
+		secret.Annotations[annotationLastChange] = diff.annotation()
+	} else if lastChange, ok := previous.Annotations[annotationLastChange]; ok {
+		secret.Annotations[annotationLastChange] = lastChange
 	}
 
 	// Write the alertmanager config into the alertmanager secret.
@@ -1434,13 +949,25 @@ func writeAlertManagerConfig(ctx context.Context, r *SecretReconciler, reqLogger
 	}
 
 	if err != nil {
-		reqLogger.Error(err, "ERROR: Could not write secret alertmanger-main", "namespace", secret.Namespace)
-		metrics.UpdateAlertmanagerConfigValidationMetric(false)
-		return fmt.Errorf("failed to write alertmanager-main secret: %w", err)
+		reqLogger.Error(err, "ERROR: Could not write secret", "name", secret.Name, "namespace", secret.Namespace)
+		target.updateValidationMetric(false)
+		return fmt.Errorf("failed to write %s secret: %w", secret.Name, err)
+	}
+
+	reqLogger.Info("INFO: Secret successfully updated", "name", secret.Name, "namespace", secret.Namespace)
+	if !target.userWorkload {
+		// Remove the output of the AlertmanagerConfig output mode only once the Secret is
+		// written, so notifications are duplicated rather than dropped while switching.
+		if err := r.deleteAlertmanagerConfigOutput(ctx, reqLogger, target); err != nil {
+			reqLogger.Error(err, "ERROR: Could not delete the AlertmanagerConfig output")
+			return err
+		}
+	}
+	if diff != nil {
+		reqLogger.Info("INFO: Alertmanager config changed", "summary", diff.summary(), "diff", diff)
+		r.recordConfigChangeEvents(ctx, target, previousConfig, amconfig, diff)
 	}
-
-	reqLogger.Info("INFO: Secret alertmanager-main successfully updated")
 	// Update metric to indicate validation and write success
-	metrics.UpdateAlertmanagerConfigValidationMetric(true)
+	target.updateValidationMetric(true)
 	return nil
 }
@@ -2,26 +2,39 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/configure-alertmanager-operator/api/v1alpha1"
 	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/coverage"
 	"github.com/openshift/configure-alertmanager-operator/pkg/readiness"
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+	"github.com/openshift/configure-alertmanager-operator/pkg/routing"
 	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	monitoringv1beta1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1beta1"
 	"go.uber.org/mock/gomock"
 	yaml "gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -42,6 +55,14 @@ var exampleManagedNamespaces = []string{
 	"openshift-backplane-managed-scripts",
 }
 
+// defaultNamespaces are the namespaces routed if neither namespace configMap can be read:
+// defaultManagedNamespaces and defaultOCPNamespaces, sorted and without duplicates.
+var defaultNamespaces = []string{
+	alertmanager.PDRegexKube,
+	alertmanager.PDRegexOS,
+	alertmanager.PDRegexLP,
+}
+
 var exampleOCPNamespaces = []string{
 	"openshift-apiserver-operator",
 	"openshift-authentication-operator",
@@ -131,7 +152,7 @@ func assertFalse(t *testing.T, status bool, message string) {
 
 // utility class to test PD route creation
 func verifyPagerdutyRoute(t *testing.T, route *alertmanager.Route, expectedNamespaces []string) {
-	assertEquals(t, defaultReceiver, route.Receiver, "Receiver Name")
+	assertEquals(t, receiverNull, route.Receiver, "Receiver Name")
 	assertEquals(t, true, route.Continue, "Continue")
 	assertEquals(t, []string{"alertname", "severity"}, route.GroupByStr, "GroupByStr")
 	assertGte(t, 1, len(route.Routes), "Number of Routes")
@@ -249,6 +270,70 @@ func verifyPagerdutyReceivers(t *testing.T, key string, proxy string, receivers
 	assertTrue(t, hasPagerduty, fmt.Sprintf("No '%s' receiver", receiverPagerduty))
 }
 
+// renderTestConfig renders a config with the integrations whose keys or URLs are set, in
+// render.IntegrationOrder. Namespaces other than namespaceList are taken from extra.
+func renderTestConfig(pdKey string, automation []render.AutomationRoute, gaLowURL, gaHighURL, gaHeartbeatURL, dmsURL, ocmAgentURL, clusterID, clusterRegion, clusterProxy string, namespaceList []string, extra *render.Namespaces) *alertmanager.Config {
+	var integrations []render.Integration
+	if dmsURL != "" {
+		integrations = append(integrations, render.DeadMansSnitch{URL: dmsURL})
+	}
+	if ocmAgentURL != "" {
+		integrations = append(integrations, render.OCMAgent{URL: ocmAgentURL})
+	}
+	if len(automation) > 0 {
+		integrations = append(integrations, render.Automation{Routes: automation})
+	}
+	if pdKey != "" {
+		integrations = append(integrations, render.PagerDuty{RoutingKey: pdKey})
+	}
+	if gaLowURL != "" && gaHighURL != "" {
+		integrations = append(integrations, render.GoAlert{LowURL: gaLowURL, HighURL: gaHighURL})
+	}
+	if gaHeartbeatURL != "" {
+		integrations = append(integrations, render.GoAlertHeartbeat{URL: gaHeartbeatURL})
+	}
+	namespaces := render.Namespaces{}
+	if extra != nil {
+		namespaces = *extra
+	}
+	namespaces.Managed = namespaceList
+	amconfig, _, err := render.Render(render.Inputs{
+		Cluster:      render.Cluster{ID: clusterID, Region: clusterRegion, Proxy: clusterProxy},
+		Profile:      render.Profile{Management: len(namespaces.HostedControlPlanes) > 0},
+		Integrations: integrations,
+		Namespaces:   namespaces,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return amconfig
+}
+
+// integrationSettings returns the keys and URLs of the configured integrations, empty for
+// those that aren't configured.
+func integrationSettings(integrations []render.Integration) (pdKey string, automation []render.AutomationRoute, dmsURL, gaLowURL, gaHighURL, gaHeartbeatURL string) {
+	for _, integration := range integrations {
+		switch s := integration.(type) {
+		case render.PagerDuty:
+			pdKey = s.RoutingKey
+		case render.Automation:
+			automation = s.Routes
+		case render.DeadMansSnitch:
+			dmsURL = s.URL
+		case render.GoAlert:
+			gaLowURL, gaHighURL = s.LowURL, s.HighURL
+		case render.GoAlertHeartbeat:
+			gaHeartbeatURL = s.URL
+		}
+	}
+	return pdKey, automation, dmsURL, gaLowURL, gaHighURL, gaHeartbeatURL
+}
+
+// cadAutomationRoutes returns the built-in CAD route, as read from a cad-pd-secret holding key.
+func cadAutomationRoutes(key string) []render.AutomationRoute {
+	return []render.AutomationRoute{automationRoutes(reqLogger, nil)[0].rendered(key, "")}
+}
+
 func verifyCADPagerdutyRoute(t *testing.T, route *alertmanager.Route) {
 	assertEquals(t, receiverCADPagerduty, route.Receiver, "Receiver Name")
 	assertEquals(t, routeCADLabelValue, route.Match[routeCADLabel], "Match route_to_cad label")
@@ -361,7 +446,7 @@ func verifyWatchdogReceiver(t *testing.T, url string, proxy string, receivers []
 func verifyOCMAgentRoute(t *testing.T, route *alertmanager.Route) {
 	assertEquals(t, receiverOCMAgent, route.Receiver, "Receiver Name")
 	assertFalse(t, route.Continue, "Continue")
-	assertEquals(t, "true", route.Match[managedNotificationLabel], "Alert Label")
+	assertEquals(t, "true", route.Match[render.ManagedNotificationLabel], "Alert Label")
 }
 
 // utility to test watchdog receivers
@@ -592,8 +677,18 @@ func Test_secretInList(t *testing.T) {
 	assertTrue(t, !secretInList(reqLogger, "fake-secret", &secretList), fmt.Sprintf("Did not expect Secret to be present in list: %s", "fake-secret"))
 }
 
-// Test_parseSecrets tests the parseSecrets function under normal circumstances
-func Test_parseSecrets(t *testing.T) {
+// Test_integrationRegistry tests that the integrations are registered in render.IntegrationOrder,
+// the order the rendering scenarios are rendered in
+func Test_integrationRegistry(t *testing.T) {
+	names := make([]string, 0, len(integrationRegistry))
+	for _, integration := range integrationRegistry {
+		names = append(names, integration.Name())
+	}
+	assertTrue(t, reflect.DeepEqual(render.IntegrationOrder, names), fmt.Sprintf("Expected integrations %v, got %v", render.IntegrationOrder, names))
+}
+
+// Test_parseIntegrations tests the parseIntegrations function under normal circumstances
+func Test_parseIntegrations(t *testing.T) {
 	// prepare environment
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -627,18 +722,19 @@ func Test_parseSecrets(t *testing.T) {
 	}
 
 	request := createReconcileRequest(reconciler, secretNamePD)
-	pagerdutyRoutingKey, cadPagerdutyRoutingKey, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := reconciler.parseSecrets(reqLogger, secretList, request.Namespace, true)
+	pagerdutyRoutingKey, automation, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := integrationSettings(reconciler.parseIntegrations(reqLogger, request.Namespace, secretList, &corev1.ConfigMapList{}, true))
 
 	assertEquals(t, pdKey, pagerdutyRoutingKey, "Expected PagerDuty routing keys to match")
-	assertEquals(t, cadKey, cadPagerdutyRoutingKey, "Expected CAD PagerDuty routing keys to match")
+	assertEquals(t, 1, len(automation), "Expected the CAD automation route")
+	assertEquals(t, cadKey, automation[0].PagerDutyRoutingKey, "Expected CAD PagerDuty routing keys to match")
 	assertEquals(t, dmsURL, watchdogURL, "Expected DMS URLs to match")
 	assertEquals(t, gaLowURL, goalertURLlow, "Expected GoAlert Low URLs to match")
 	assertEquals(t, gaHighURL, goalertURLhigh, "Expected GoAlert High URLs to match")
 	assertEquals(t, gaHeartURL, goalertURLheartbeat, "Expected GoAlert Heartbeat URLs to match")
 }
 
-// Test_parseSecrets tests the parseSecrets function when the DMS secret does not exist
-func Test_parseSecrets_MissingDMS(t *testing.T) {
+// Test_parseIntegrations_MissingDMS tests the parseIntegrations function when the DMS secret does not exist
+func Test_parseIntegrations_MissingDMS(t *testing.T) {
 	// prepare environment
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -659,18 +755,19 @@ func Test_parseSecrets_MissingDMS(t *testing.T) {
 	}
 
 	request := createReconcileRequest(reconciler, secretNamePD)
-	pagerdutyRoutingKey, cadPagerdutyRoutingKey, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := reconciler.parseSecrets(reqLogger, secretList, request.Namespace, true)
+	pagerdutyRoutingKey, automation, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := integrationSettings(reconciler.parseIntegrations(reqLogger, request.Namespace, secretList, &corev1.ConfigMapList{}, true))
 
 	assertEquals(t, pdKey, pagerdutyRoutingKey, "Expected PagerDuty routing keys to match")
-	assertEquals(t, cadKey, cadPagerdutyRoutingKey, "Expected CAD PagerDuty routing keys to match")
+	assertEquals(t, 1, len(automation), "Expected the CAD automation route")
+	assertEquals(t, cadKey, automation[0].PagerDutyRoutingKey, "Expected CAD PagerDuty routing keys to match")
 	assertEquals(t, "", watchdogURL, "Expected DMS URLs to match")
 	assertEquals(t, "", goalertURLlow, "Expected GoAlert Low URLs to match")
 	assertEquals(t, "", goalertURLhigh, "Expected GoAlert High URLs to match")
 	assertEquals(t, "", goalertURLheartbeat, "Expected GoAlert Heartbeat URLs to match")
 }
 
-// Tests the parseSecrets function when the PD secret does not exist
-func Test_parseSecrets_MissingPagerDuty(t *testing.T) {
+// Tests the parseIntegrations function when the PD secret does not exist
+func Test_parseIntegrations_MissingPagerDuty(t *testing.T) {
 	// prepare environment
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -689,18 +786,18 @@ func Test_parseSecrets_MissingPagerDuty(t *testing.T) {
 	}
 
 	request := createReconcileRequest(reconciler, secretNamePD)
-	pagerdutyRoutingKey, cadPagerdutyRoutingKey, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := reconciler.parseSecrets(reqLogger, secretList, request.Namespace, true)
+	pagerdutyRoutingKey, automation, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := integrationSettings(reconciler.parseIntegrations(reqLogger, request.Namespace, secretList, &corev1.ConfigMapList{}, true))
 
 	assertEquals(t, "", pagerdutyRoutingKey, "Expected PagerDuty routing keys to match")
-	assertEquals(t, "", cadPagerdutyRoutingKey, "Expected CAD PagerDuty routing keys to match")
+	assertEquals(t, 0, len(automation), "Expected no automation routes without their secrets")
 	assertEquals(t, dmsURL, watchdogURL, "Expected DMS URLs to match")
 	assertEquals(t, "", goalertURLlow, "Expected GoAlert Low URLs to match")
 	assertEquals(t, "", goalertURLhigh, "Expected GoAlert High URLs to match")
 	assertEquals(t, "", goalertURLheartbeat, "Expected GoAlert Heartbeat URLs to match")
 }
 
-// Tests the parseSecrets function when the GoAlert secrets do not exist
-func Test_parseSecrets_MissingGoAlert(t *testing.T) {
+// Tests the parseIntegrations function when the GoAlert secrets do not exist
+func Test_parseIntegrations_MissingGoAlert(t *testing.T) {
 	// prepare environment
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -728,16 +825,68 @@ func Test_parseSecrets_MissingGoAlert(t *testing.T) {
 	}
 
 	request := createReconcileRequest(reconciler, secretNameGoalert)
-	pagerdutyRoutingKey, cadPagerdutyRoutingKey, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := reconciler.parseSecrets(reqLogger, secretList, request.Namespace, true)
+	pagerdutyRoutingKey, automation, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := integrationSettings(reconciler.parseIntegrations(reqLogger, request.Namespace, secretList, &corev1.ConfigMapList{}, true))
 
 	assertEquals(t, "", pagerdutyRoutingKey, "Expected PagerDuty routing keys to match")
-	assertEquals(t, "", cadPagerdutyRoutingKey, "Expected CAD PagerDuty routing keys to match")
+	assertEquals(t, 0, len(automation), "Expected no automation routes without their secrets")
 	assertEquals(t, "", watchdogURL, "Expected DMS URLs to match")
 	assertEquals(t, gaLowURL, goalertURLlow, "Expected GoAlert Low URLs to match")
 	assertEquals(t, gaHighURL, goalertURLhigh, "Expected GoAlert High URLs to match")
 	assertEquals(t, gaHeartURL, goalertURLheartbeat, "Expected GoAlert Heartbeat URLs to match")
 }
 
+// Test_parseIntegrations_ClusterNotReady tests that only integrations not gated on cluster
+// readiness are configured before the cluster is ready
+func Test_parseIntegrations_ClusterNotReady(t *testing.T) {
+	// prepare environment
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockReadiness := readiness.NewMockInterface(ctrl)
+	reconciler := createReconciler(t, mockReadiness)
+
+	dmsURL := "https://hjklasdf09876"
+	ocmAgentURL := "https://ocm-agent.example.com/alertmanager-receiver"
+
+	createNamespace(reconciler, t)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "asdfjkl123")
+	createSecret(reconciler, secretNameCADPD, secretKeyCADPD, "cadfgh456")
+	createSecret(reconciler, secretNameDMS, secretKeyDMS, dmsURL)
+	createGoAlertSecret(reconciler,
+		secretNameGoalert,
+		secretKeyGoalertLow,
+		secretKeyGoalertHigh,
+		secretKeyGoalertHeartbeat,
+		"https://dummy-galow-url",
+		"https://dummy-gahigh-url",
+		"https://dummy-gaheartbeat-url")
+	createConfigMap(reconciler, cmNameOcmAgent, cmKeyOCMAgent, ocmAgentURL)
+
+	secretList := &corev1.SecretList{}
+	err := reconciler.Client.List(context.TODO(), secretList, &client.ListOptions{})
+	if err != nil {
+		t.Fatalf("Could not list Secrets: %v", err)
+	}
+	cmList := &corev1.ConfigMapList{}
+	err = reconciler.Client.List(context.TODO(), cmList, &client.ListOptions{})
+	if err != nil {
+		t.Fatalf("Could not list ConfigMaps: %v", err)
+	}
+
+	integrations := reconciler.parseIntegrations(reqLogger, config.OperatorNamespace, secretList, cmList, false)
+
+	assertEquals(t, 2, len(integrations), "Expected only DMS and OCM Agent to be configured")
+	pagerdutyRoutingKey, automation, watchdogURL, goalertURLlow, goalertURLhigh, goalertURLheartbeat := integrationSettings(integrations)
+	assertEquals(t, "", pagerdutyRoutingKey, "Expected no PagerDuty routing key before the cluster is ready")
+	assertEquals(t, 0, len(automation), "Expected no automation routes before the cluster is ready")
+	assertEquals(t, dmsURL, watchdogURL, "Expected DMS URLs to match")
+	assertEquals(t, "", goalertURLlow, "Expected no GoAlert Low URL before the cluster is ready")
+	assertEquals(t, "", goalertURLhigh, "Expected no GoAlert High URL before the cluster is ready")
+	assertEquals(t, "", goalertURLheartbeat, "Expected no GoAlert Heartbeat URL before the cluster is ready")
+	ocm, ok := integrations[1].(render.OCMAgent)
+	assertTrue(t, ok, "Expected the OCM Agent integration to be configured")
+	assertEquals(t, ocmAgentURL, ocm.URL, "Expected OCM Agent URLs to match")
+}
+
 // Test_parseConfigMaps tests the parseConfigMaps function under various circumstances
 func Test_parseConfigMaps(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -752,6 +901,15 @@ func Test_parseConfigMaps(t *testing.T) {
 	for i, ns := range validNamespaces {
 		validNamespaces[i] = "^" + ns + "$"
 	}
+	validManagedNamespaces := validNamespaces[:len(exampleManagedNamespaces)]
+	validOCPNamespaces := validNamespaces[len(exampleManagedNamespaces):]
+
+	// sorted returns the union of the lists, sorted, as parseConfigMaps() returns it
+	sorted := func(lists ...[]string) []string {
+		union := slices.Concat(lists...)
+		slices.Sort(union)
+		return slices.Compact(union)
+	}
 
 	type configMapTest struct {
 		invalid bool
@@ -762,12 +920,14 @@ func Test_parseConfigMaps(t *testing.T) {
 	tests := []struct {
 		name               string
 		expectedNamespaces []string
+		expectedStates     []v1alpha1.NamespaceSourceState
 		managedNamespace   configMapTest
 		ocpNamespaces      configMapTest
 	}{
 		{
 			name:               "Valid configMaps",
-			expectedNamespaces: validNamespaces,
+			expectedNamespaces: sorted(validNamespaces),
+			expectedStates:     []v1alpha1.NamespaceSourceState{v1alpha1.NamespaceSourceLoaded, v1alpha1.NamespaceSourceLoaded},
 			managedNamespace: configMapTest{
 				invalid: false,
 				missing: false,
@@ -779,7 +939,8 @@ func Test_parseConfigMaps(t *testing.T) {
 		},
 		{
 			name:               "Invalid managed-namespaces configMap",
-			expectedNamespaces: defaultNamespaces,
+			expectedNamespaces: sorted(defaultManagedNamespaces, validOCPNamespaces),
+			expectedStates:     []v1alpha1.NamespaceSourceState{v1alpha1.NamespaceSourceDefaulted, v1alpha1.NamespaceSourceLoaded},
 			managedNamespace: configMapTest{
 				invalid: true,
 				missing: false,
@@ -791,7 +952,8 @@ func Test_parseConfigMaps(t *testing.T) {
 		},
 		{
 			name:               "Missing managed-namespaces configMap",
-			expectedNamespaces: defaultNamespaces,
+			expectedNamespaces: sorted(defaultManagedNamespaces, validOCPNamespaces),
+			expectedStates:     []v1alpha1.NamespaceSourceState{v1alpha1.NamespaceSourceDefaulted, v1alpha1.NamespaceSourceLoaded},
 			managedNamespace: configMapTest{
 				invalid: false,
 				missing: true,
@@ -803,7 +965,8 @@ func Test_parseConfigMaps(t *testing.T) {
 		},
 		{
 			name:               "Invalid ocp-namespaces configMap",
-			expectedNamespaces: defaultNamespaces,
+			expectedNamespaces: sorted(validManagedNamespaces, defaultOCPNamespaces),
+			expectedStates:     []v1alpha1.NamespaceSourceState{v1alpha1.NamespaceSourceLoaded, v1alpha1.NamespaceSourceDefaulted},
 			managedNamespace: configMapTest{
 				invalid: false,
 				missing: false,
@@ -815,7 +978,8 @@ func Test_parseConfigMaps(t *testing.T) {
 		},
 		{
 			name:               "Missing ocp-namespaces configMap",
-			expectedNamespaces: defaultNamespaces,
+			expectedNamespaces: sorted(validManagedNamespaces, defaultOCPNamespaces),
+			expectedStates:     []v1alpha1.NamespaceSourceState{v1alpha1.NamespaceSourceLoaded, v1alpha1.NamespaceSourceDefaulted},
 			managedNamespace: configMapTest{
 				invalid: false,
 				missing: false,
@@ -825,6 +989,17 @@ func Test_parseConfigMaps(t *testing.T) {
 				missing: true,
 			},
 		},
+		{
+			name:               "Missing configMaps",
+			expectedNamespaces: defaultNamespaces,
+			expectedStates:     []v1alpha1.NamespaceSourceState{v1alpha1.NamespaceSourceDefaulted, v1alpha1.NamespaceSourceDefaulted},
+			managedNamespace: configMapTest{
+				missing: true,
+			},
+			ocpNamespaces: configMapTest{
+				missing: true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -868,9 +1043,14 @@ func Test_parseConfigMaps(t *testing.T) {
 		}
 
 		request := createReconcileRequest(reconciler, cmNameManagedNamespaces)
-		namespaceList := reconciler.parseConfigMaps(reqLogger, cmList, request.Namespace)
+		namespaceList, _, sources := reconciler.parseConfigMaps(reqLogger, cmList, request.Namespace, false, nil)
 
 		assertEquals(t, tt.expectedNamespaces, namespaceList, "Expected namespace lists to match")
+		states := []v1alpha1.NamespaceSourceState{}
+		for _, source := range sources {
+			states = append(states, source.State)
+		}
+		assertEquals(t, append(tt.expectedStates, v1alpha1.NamespaceSourceSkipped), states, tt.name)
 	}
 }
 
@@ -947,98 +1127,7 @@ func Test_readOCMAgentServiceURLFromConfig(t *testing.T) {
 	}
 }
 
-func Test_createPagerdutyRoute(t *testing.T) {
-	// test the structure of the Route is sane
-	route := createSubroutes(defaultNamespaces, Pagerduty)
-
-	verifyPagerdutyRoute(t, route, defaultNamespaces)
-}
-
-func Test_createPagerdutyRoute_etcdDatabaseQuotaLowSpace(t *testing.T) {
-	route := createSubroutes(defaultNamespaces, Pagerduty)
-
-	found := false
-	for _, r := range route.Routes {
-		if r.Receiver == receiverMakeItCritical &&
-			r.Match["alertname"] == "etcdDatabaseQuotaLowSpace" &&
-			r.Match["severity"] == "warning" {
-			found = true
-			break
-		}
-	}
-	assertTrue(t, found, "No route to escalate etcdDatabaseQuotaLowSpace warning to critical")
-}
-
-func Test_createGoalertSubroute(t *testing.T) {
-	// test the structure of the Route is sane
-	route := createSubroutes(defaultNamespaces, GoAlert)
-
-	verifyGoalertRoute(t, route, defaultNamespaces)
-}
-
-func Test_createPagerdutyReceivers_WithoutKey(t *testing.T) {
-	assertEquals(t, 0, len(createPagerdutyReceivers("", "", "", "")), "Number of Receivers")
-}
-
-func Test_createGoalertReceivers_WithoutURL(t *testing.T) {
-	assertEquals(t, 0, len(createGoalertReceiver("", "", "")), "Number of Receivers")
-}
-
-func Test_createPagerdutyReceivers_WithKey(t *testing.T) {
-	key := "abcdefg1234567890"
-
-	receivers := createPagerdutyReceivers(key, exampleClusterId, exampleRegion, exampleProxy)
-
-	verifyPagerdutyReceivers(t, key, exampleProxy, receivers)
-}
-
-func Test_createGoalertReceivers_WithURL(t *testing.T) {
-	url := "https://dummy-ga-url"
-
-	receiver := createGoalertReceiver(url, receiverGoAlertLow, exampleProxy)
-	verifyGoalertLowReceivers(t, url, exampleProxy, receiver)
-	receiver = createGoalertReceiver(url, receiverGoAlertHigh, exampleProxy)
-	verifyGoalertHighReceivers(t, url, exampleProxy, receiver)
-}
-
-func Test_createWatchdogRoute(t *testing.T) {
-	// test the structure of the Route is sane
-	route := createWatchdogRoute()
-
-	verifyWatchdogRoute(t, route)
-}
-
-func Test_createWatchdogReceivers_WithoutURL(t *testing.T) {
-	assertEquals(t, 0, len(createWatchdogReceivers("", "")), "Number of Receivers")
-}
-
-func Test_createWatchdogReceivers_WithKey(t *testing.T) {
-	url := "http://whatever/something"
-
-	receivers := createWatchdogReceivers(url, exampleProxy)
-
-	verifyWatchdogReceiver(t, url, exampleProxy, receivers)
-}
-
-func Test_createHeartbeatRoute(t *testing.T) {
-	// test the structure of the Route is sane
-	route := createHeartbeatRoute()
-	verifyHeartbeatRoute(t, route)
-}
-
-func Test_createHeartbeatReceivers_WithoutURL(t *testing.T) {
-	assertEquals(t, 0, len(createHeartbeatReceivers("", "")), "Number of Receivers")
-}
-
-func Test_createHeartbeatReceivers_WithKey(t *testing.T) {
-	url := "https://whatever/something"
-
-	receivers := createHeartbeatReceivers(url, exampleProxy)
-
-	verifyHeartbeatReceiver(t, url, exampleProxy, receivers)
-}
-
-func Test_createAlertManagerConfig_WithoutKey_WithoutURL(t *testing.T) {
+func Test_renderConfig_WithoutKey_WithoutURL(t *testing.T) {
 	pdKey := ""
 	wdURL := ""
 	oaURL := ""
@@ -1046,12 +1135,12 @@ func Test_createAlertManagerConfig_WithoutKey_WithoutURL(t *testing.T) {
 	gaLowURL := ""
 	gaHeartURL := ""
 
-	config := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces)
+	config := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces, nil)
 
 	// verify static things
 	assertEquals(t, "5m", config.Global.ResolveTimeout, "Global.ResolveTimeout")
-	assertEquals(t, pagerdutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
-	assertEquals(t, defaultReceiver, config.Route.Receiver, "Route.Receiver")
+	assertEquals(t, render.PagerDutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
+	assertEquals(t, receiverNull, config.Route.Receiver, "Route.Receiver")
 	assertEquals(t, "30s", config.Route.GroupWait, "Route.GroupWait")
 	assertEquals(t, "5m", config.Route.GroupInterval, "Route.GroupInterval")
 	assertEquals(t, "12h", config.Route.RepeatInterval, "Route.RepeatInterval")
@@ -1063,7 +1152,7 @@ func Test_createAlertManagerConfig_WithoutKey_WithoutURL(t *testing.T) {
 	verifyInhibitRules(t, config.InhibitRules)
 }
 
-func Test_createAlertManagerConfig_WithKey_WithoutURL(t *testing.T) {
+func Test_renderConfig_WithKey_WithoutURL(t *testing.T) {
 	pdKey := "poiuqwer78902345"
 	wdURL := ""
 	oaURL := ""
@@ -1071,12 +1160,12 @@ func Test_createAlertManagerConfig_WithKey_WithoutURL(t *testing.T) {
 	gaLowURL := ""
 	gaHeartURL := ""
 
-	config := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces)
+	config := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces, nil)
 
 	// verify static things
 	assertEquals(t, "5m", config.Global.ResolveTimeout, "Global.ResolveTimeout")
-	assertEquals(t, pagerdutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
-	assertEquals(t, defaultReceiver, config.Route.Receiver, "Route.Receiver")
+	assertEquals(t, render.PagerDutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
+	assertEquals(t, receiverNull, config.Route.Receiver, "Route.Receiver")
 	assertEquals(t, "30s", config.Route.GroupWait, "Route.GroupWait")
 	assertEquals(t, "5m", config.Route.GroupInterval, "Route.GroupInterval")
 	assertEquals(t, "12h", config.Route.RepeatInterval, "Route.RepeatInterval")
@@ -1091,11 +1180,11 @@ func Test_createAlertManagerConfig_WithKey_WithoutURL(t *testing.T) {
 	verifyInhibitRules(t, config.InhibitRules)
 }
 
-func Test_createAlertManagerConfig_WithCADPagerDuty(t *testing.T) {
+func Test_renderConfig_WithCADPagerDuty(t *testing.T) {
 	pdKey := "general-routing-key"
 	cadKey := "cad-routing-key"
 
-	config := createAlertManagerConfig(reqLogger, pdKey, cadKey, "", "", "", "", "", exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces)
+	config := renderTestConfig(pdKey, cadAutomationRoutes(cadKey), "", "", "", "", "", exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces, nil)
 
 	assertEquals(t, 2, len(config.Route.Routes), "Route.Routes")
 	assertEquals(t, 6, len(config.Receivers), "Receivers")
@@ -1107,7 +1196,89 @@ func Test_createAlertManagerConfig_WithCADPagerDuty(t *testing.T) {
 	verifyPagerdutyReceivers(t, pdKey, exampleProxy, config.Receivers)
 }
 
-func Test_createAlertManagerConfig_WithKey_WithWDURL_WithOAURL(t *testing.T) {
+func Test_automationRoutes(t *testing.T) {
+	continues := true
+	routes := automationRoutes(reqLogger, []config.AutomationRoute{
+		{Name: "ticketing", MatchLabels: map[string]string{"route_to_ticketing": "true"}, SecretName: "ticketing-secret"},
+		{Name: automationRouteCAD, Continue: &continues},
+		{Name: "incomplete", MatchLabels: map[string]string{"route_to_nowhere": "true"}},
+	})
+
+	assertEquals(t, 2, len(routes), "Expected the built-in route, then the configured route, without the incomplete route")
+	assertEquals(t, receiverCADPagerduty, routes[0].receiver, "Expected the built-in receiver to be kept")
+	assertEquals(t, map[string]string{routeCADLabel: routeCADLabelValue}, routes[0].matchLabels, "Expected the built-in labels to be kept")
+	assertEquals(t, secretNameCADPD, routes[0].secretName, "Expected the built-in secret to be kept")
+	assertTrue(t, routes[0].continues, "Expected the built-in route to continue")
+	assertEquals(t, receiverAutomationPrefix+"ticketing", routes[1].receiver, "Expected the receiver to be named after the route")
+	assertEquals(t, secretKeyAutomationPD, routes[1].secretKeyPD, "Expected the standard PagerDuty key")
+	assertTrue(t, !routes[1].continues, "Expected routes not to continue by default")
+
+	assertTrue(t, isAutomationSecretName(config.Settings{AutomationRoutes: []config.AutomationRoute{{Name: "ticketing", MatchLabels: map[string]string{"a": "b"}, SecretName: "ticketing-secret"}}}, "ticketing-secret"), "Expected the configured secret to be a source")
+	assertTrue(t, isAutomationSecretName(config.DefaultSettings(), secretNameCADPD), "Expected the CAD secret to be a source")
+}
+
+func Test_SecretReconciler_AutomationRoutes(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockReadiness := readiness.NewMockInterface(gomock.NewController(t))
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "pdkey")
+	createSecret(reconciler, secretNameCADPD, secretKeyCADPD, "cadkey")
+	createSecretWithData(reconciler, "remediation-secret", map[string]string{secretKeyAutomationWebhook: "https://remediation.example/alerts"})
+
+	continues := true
+	operatorConfig := &v1alpha1.ConfigureAlertmanager{
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ConfigureAlertmanagerName},
+		Spec: v1alpha1.ConfigureAlertmanagerSpec{AutomationRoutes: []v1alpha1.AutomationRoute{
+			{Name: "remediation", MatchLabels: map[string]string{"route_to_remediation": "true"}, SecretName: "remediation-secret", Continue: &continues},
+			{Name: "ticketing", MatchLabels: map[string]string{"route_to_ticketing": "true"}, SecretName: "ticketing-secret"},
+		}},
+	}
+	if err := reconciler.Client.Create(context.TODO(), operatorConfig); err != nil {
+		t.Fatalf("Failed to create ConfigureAlertmanager: %v", err)
+	}
+
+	// Changes to the secret of an automation route are reconciled, even before a reconcile
+	// has applied the settings that configure the route
+	req := createReconcileRequest(reconciler, "remediation-secret")
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	amconfig := readAlertManagerConfig(reconciler, createReconcileRequest(reconciler, secretNameAlertmanager))
+	verifyCADPagerdutyRoute(t, amconfig.Route.Routes[0])
+	assertEquals(t, &alertmanager.Route{Receiver: receiverAutomationPrefix + "remediation", Match: map[string]string{"route_to_remediation": "true"}, Continue: true}, amconfig.Route.Routes[1], "Expected the remediation route after the CAD route")
+	receivers := receiversByName(amconfig)
+	assertEquals(t, "https://remediation.example/alerts", receivers[receiverAutomationPrefix+"remediation"].WebhookConfigs[0].URL, "Expected the webhook URL from the secret")
+	if _, ok := receivers[receiverAutomationPrefix+"ticketing"]; ok {
+		t.Fatal("Expected no ticketing receiver without its secret")
+	}
+
+	explainer, err := routing.NewExplainer(amconfig)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the config: %v", err)
+	}
+	assertEquals(t, []string{receiverCADPagerduty}, explainer.Receivers(map[string]string{routeCADLabel: routeCADLabelValue, "route_to_remediation": "true"}),
+		"Expected CAD alerts to stop at CAD")
+	remediated := explainer.Receivers(map[string]string{"route_to_remediation": "true", "namespace": "openshift-monitoring", "prometheus": "openshift-monitoring/k8s", "severity": "critical"})
+	assertEquals(t, receiverAutomationPrefix+"remediation", remediated[0], "Expected the alert to be sent for remediation")
+	assertTrue(t, slices.Contains(remediated, receiverPagerduty), fmt.Sprintf("Expected the remediated alert to page as well, got %v", remediated))
+
+	configured := &v1alpha1.ConfigureAlertmanager{}
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Name: v1alpha1.ConfigureAlertmanagerName}, configured); err != nil {
+		t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+	}
+	assertTrue(t, slices.Contains(configured.Status.ObservedInputs.Sources, v1alpha1.ObservedSource{Kind: "Secret", Name: "remediation-secret", ResourceVersion: "1"}),
+		fmt.Sprintf("Expected the automation secret in the observed sources, got %v", configured.Status.ObservedInputs.Sources))
+}
+
+func Test_renderConfig_WithKey_WithWDURL_WithOAURL(t *testing.T) {
 	pdKey := "poiuqwer78902345"
 	wdURL := "http://theinterwebs"
 	oaURL := "https://dummy-oa-url"
@@ -1115,12 +1286,12 @@ func Test_createAlertManagerConfig_WithKey_WithWDURL_WithOAURL(t *testing.T) {
 	gaLowURL := "https://dummy-galow-url"
 	gaHeartURL := "https://dummy-gaheartbeat-url"
 
-	config := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces)
+	config := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, exampleManagedNamespaces, nil)
 
 	// verify static things
 	assertEquals(t, "5m", config.Global.ResolveTimeout, "Global.ResolveTimeout")
-	assertEquals(t, pagerdutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
-	assertEquals(t, defaultReceiver, config.Route.Receiver, "Route.Receiver")
+	assertEquals(t, render.PagerDutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
+	assertEquals(t, receiverNull, config.Route.Receiver, "Route.Receiver")
 	assertEquals(t, "30s", config.Route.GroupWait, "Route.GroupWait")
 	assertEquals(t, "5m", config.Route.GroupInterval, "Route.GroupInterval")
 	assertEquals(t, "12h", config.Route.RepeatInterval, "Route.RepeatInterval")
@@ -1148,7 +1319,7 @@ func Test_createAlertManagerConfig_WithKey_WithWDURL_WithOAURL(t *testing.T) {
 	verifyInhibitRules(t, config.InhibitRules)
 }
 
-func Test_createAlertManagerConfig_WithoutKey_WithoutOA_WithWDURL(t *testing.T) {
+func Test_renderConfig_WithoutKey_WithoutOA_WithWDURL(t *testing.T) {
 	pdKey := ""
 	wdURL := "http://theinterwebs"
 	oaURL := ""
@@ -1156,9 +1327,9 @@ func Test_createAlertManagerConfig_WithoutKey_WithoutOA_WithWDURL(t *testing.T)
 	gaLowURL := ""
 	gaHeartURL := ""
 
-	config := createAlertManagerConfig(reqLogger,
+	config := renderTestConfig(
 		pdKey,
-		"",
+		nil,
 		gaLowURL,
 		gaHighURL,
 		gaHeartURL,
@@ -1167,12 +1338,13 @@ func Test_createAlertManagerConfig_WithoutKey_WithoutOA_WithWDURL(t *testing.T)
 		exampleClusterId,
 		exampleRegion,
 		exampleProxy,
-		exampleManagedNamespaces)
+		exampleManagedNamespaces,
+		nil)
 
 	// verify static things
 	assertEquals(t, "5m", config.Global.ResolveTimeout, "Global.ResolveTimeout")
-	assertEquals(t, pagerdutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
-	assertEquals(t, defaultReceiver, config.Route.Receiver, "Route.Receiver")
+	assertEquals(t, render.PagerDutyURL, config.Global.PagerdutyURL, "Global.PagerdutyURL")
+	assertEquals(t, receiverNull, config.Route.Receiver, "Route.Receiver")
 	assertEquals(t, "30s", config.Route.GroupWait, "Route.GroupWait")
 	assertEquals(t, "5m", config.Route.GroupInterval, "Route.GroupInterval")
 	assertEquals(t, "12h", config.Route.RepeatInterval, "Route.RepeatInterval")
@@ -1251,6 +1423,8 @@ func createReconciler(t *testing.T, ready readiness.Interface) *SecretReconciler
 	utilruntime.Must(configv1.AddToScheme(fakeScheme))
 	utilruntime.Must(corev1.AddToScheme(fakeScheme))
 	utilruntime.Must(monitoringv1.AddToScheme(fakeScheme))
+	utilruntime.Must(monitoringv1beta1.AddToScheme(fakeScheme))
+	utilruntime.Must(v1alpha1.AddToScheme(fakeScheme))
 
 	// if err := configv1.AddToScheme(scheme); err != nil {
 	// 	t.Fatalf("Unable to add route scheme: (%v)", err)
@@ -1260,10 +1434,40 @@ func createReconciler(t *testing.T, ready readiness.Interface) *SecretReconciler
 	}
 
 	return &SecretReconciler{
-		Client:    fake.NewClientBuilder().WithScheme(fakeScheme).Build(),
+		Client:    fake.NewClientBuilder().WithScheme(fakeScheme).WithStatusSubresource(&v1alpha1.ConfigureAlertmanager{}).Build(),
 		Scheme:    fakeScheme,
 		Readiness: ready,
+		Recorder:  &testRecorder{},
+	}
+}
+
+// recordedEvent is an Event captured by testRecorder.
+type recordedEvent struct {
+	regarding k8sruntime.Object
+	eventType string
+	reason    string
+	action    string
+	note      string
+}
+
+// testRecorder captures Events, including the object they are about.
+type testRecorder struct {
+	events []recordedEvent
+}
+
+func (tr *testRecorder) Eventf(regarding k8sruntime.Object, _ k8sruntime.Object, eventType, reason, action, note string, args ...interface{}) {
+	tr.events = append(tr.events, recordedEvent{regarding, eventType, reason, action, fmt.Sprintf(note, args...)})
+}
+
+// recordedEvents returns the Events recorded by the reconciler with the given reason.
+func recordedEvents(reconciler *SecretReconciler, reason string) []recordedEvent {
+	matching := []recordedEvent{}
+	for _, event := range reconciler.Recorder.(*testRecorder).events {
+		if event.reason == reason {
+			matching = append(matching, event)
+		}
 	}
+	return matching
 }
 
 // createNamespace creates a fake `openshift-monitoring` namespace for testing.
@@ -1295,9 +1499,9 @@ func Test_createPagerdutySecret_Create(t *testing.T) {
 	gaLowURL := ""
 	gaHeartURL := ""
 
-	configExpected := createAlertManagerConfig(reqLogger,
+	configExpected := renderTestConfig(
 		pdKey,
-		"",
+		nil,
 		gaLowURL,
 		gaHighURL,
 		gaHeartURL,
@@ -1306,7 +1510,8 @@ func Test_createPagerdutySecret_Create(t *testing.T) {
 		exampleClusterId,
 		exampleRegion,
 		exampleProxy,
-		defaultNamespaces)
+		defaultNamespaces,
+		nil)
 
 	verifyInhibitRules(t, configExpected.InhibitRules)
 
@@ -1349,7 +1554,7 @@ func Test_createPagerdutySecret_Update(t *testing.T) {
 	var ret reconcile.Result
 	var err error
 
-	configExpected := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces)
+	configExpected := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces, nil)
 
 	verifyInhibitRules(t, configExpected.InhibitRules)
 
@@ -1399,7 +1604,7 @@ func Test_createGoalertSecret_Create(t *testing.T) {
 	gaLowURL := "https://dummy-galow-url"
 	gaHeartURL := "https://dummy-gaheartbeat-url"
 
-	configExpected := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces)
+	configExpected := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces, nil)
 
 	verifyInhibitRules(t, configExpected.InhibitRules)
 
@@ -1448,7 +1653,7 @@ func Test_createGoalertSecret_Update(t *testing.T) {
 	var ret reconcile.Result
 	var err error
 
-	configExpected := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces)
+	configExpected := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces, nil)
 
 	verifyInhibitRules(t, configExpected.InhibitRules)
 
@@ -1676,7 +1881,7 @@ func Test_SecretReconciler(t *testing.T) {
 
 		// Create the secrets for this specific test.
 		if tt.amExists {
-			if err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, "", "", "", defaultNamespaces)); err != nil {
+			if err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, "", "", "", defaultNamespaces, nil)); err != nil {
 				t.Fatalf("Failed to write alertmanager config in test setup: %v", err)
 			}
 		}
@@ -1709,7 +1914,7 @@ func Test_SecretReconciler(t *testing.T) {
 			createConfigMap(reconciler, cmNameOcmAgent, cmKeyOCMAgent, oaURL)
 		}
 
-		configExpected := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces)
+		configExpected := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, wdURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces, nil)
 
 		verifyInhibitRules(t, configExpected.InhibitRules)
 
@@ -1785,7 +1990,7 @@ func Test_SecretReconciler_Readiness(t *testing.T) {
 		createClusterProxy(reconciler)
 		createClusterInfrastructure(reconciler)
 
-		if err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, createAlertManagerConfig(reqLogger, "", "", "", "", "", "", "", "", "", "", defaultNamespaces)); err != nil {
+		if err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), renderTestConfig("", nil, "", "", "", "", "", "", "", "", defaultNamespaces, nil)); err != nil {
 			t.Fatalf("Failed to write alertmanager config in test setup: %v", err)
 		}
 
@@ -1828,7 +2033,7 @@ func Test_SecretReconciler_Readiness(t *testing.T) {
 			oaURL = ""
 		}
 
-		configExpected := createAlertManagerConfig(reqLogger, pdKey, "", gaLowURL, gaHighURL, gaHeartURL, dmsURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces)
+		configExpected := renderTestConfig(pdKey, nil, gaLowURL, gaHighURL, gaHeartURL, dmsURL, oaURL, exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces, nil)
 
 		verifyInhibitRules(t, configExpected.InhibitRules)
 
@@ -1997,62 +2202,44 @@ func Test_recordConfigValidationEvent(t *testing.T) {
 	validationErr := fmt.Errorf("alertmanager config validation failed: invalid label name \"route-to-cad\"")
 
 	// Record the event
-	reconciler.recordConfigValidationEvent(context.Background(), validationErr)
-
-	// Verify event was created
-	eventList := &corev1.EventList{}
-	err := reconciler.Client.List(context.TODO(), eventList, &client.ListOptions{
-		Namespace: "openshift-monitoring",
-	})
-	if err != nil {
-		t.Fatalf("Failed to list events: %v", err)
-	}
-
-	if len(eventList.Items) == 0 {
-		t.Fatal("Expected at least one event to be created, got none")
-	}
+	reconciler.recordConfigValidationEvent(context.Background(), platformTarget(), validationErr)
 
 	// Find our validation event
-	var validationEvent *corev1.Event
-	for i := range eventList.Items {
-		if eventList.Items[i].Reason == "AlertmanagerConfigValidationFailure" {
-			validationEvent = &eventList.Items[i]
-			break
-		}
-	}
-
-	if validationEvent == nil {
-		t.Fatal("Expected to find AlertmanagerConfigValidationFailure event, but didn't")
+	validationEvents := recordedEvents(reconciler, "AlertmanagerConfigValidationFailure")
+	if len(validationEvents) != 1 {
+		t.Fatalf("Expected one AlertmanagerConfigValidationFailure event, got %v", validationEvents)
 	}
+	validationEvent := validationEvents[0]
 
 	// Verify event fields
-	if validationEvent.Type != corev1.EventTypeWarning {
-		t.Errorf("Expected event type %s, got %s", corev1.EventTypeWarning, validationEvent.Type)
+	if validationEvent.eventType != corev1.EventTypeWarning {
+		t.Errorf("Expected event type %s, got %s", corev1.EventTypeWarning, validationEvent.eventType)
 	}
 
-	if validationEvent.InvolvedObject.Kind != "Secret" {
-		t.Errorf("Expected InvolvedObject.Kind 'Secret', got '%s'", validationEvent.InvolvedObject.Kind)
+	regarding, ok := validationEvent.regarding.(*corev1.Secret)
+	if !ok {
+		t.Fatalf("Expected event to be about a Secret, got %T", validationEvent.regarding)
 	}
 
-	if validationEvent.InvolvedObject.Name != secretNameAlertmanager {
-		t.Errorf("Expected InvolvedObject.Name '%s', got '%s'", secretNameAlertmanager, validationEvent.InvolvedObject.Name)
+	if regarding.Name != secretNameAlertmanager {
+		t.Errorf("Expected regarding Name '%s', got '%s'", secretNameAlertmanager, regarding.Name)
 	}
 
-	if validationEvent.InvolvedObject.Namespace != "openshift-monitoring" {
-		t.Errorf("Expected InvolvedObject.Namespace 'openshift-monitoring', got '%s'", validationEvent.InvolvedObject.Namespace)
+	if regarding.Namespace != "openshift-monitoring" {
+		t.Errorf("Expected regarding Namespace 'openshift-monitoring', got '%s'", regarding.Namespace)
 	}
 
 	// Verify error message is included in event message
-	if !strings.Contains(validationEvent.Message, validationErr.Error()) {
-		t.Errorf("Expected event message to contain validation error '%s', got: %s", validationErr.Error(), validationEvent.Message)
+	if !strings.Contains(validationEvent.note, validationErr.Error()) {
+		t.Errorf("Expected event message to contain validation error '%s', got: %s", validationErr.Error(), validationEvent.note)
 	}
 
 	// Verify event message contains actionable guidance
-	if !strings.Contains(validationEvent.Message, "CRITICAL") {
+	if !strings.Contains(validationEvent.note, "CRITICAL") {
 		t.Error("Expected event message to contain 'CRITICAL'")
 	}
 
-	if !strings.Contains(validationEvent.Message, "Check source configmaps and secrets") {
+	if !strings.Contains(validationEvent.note, "Check source configmaps and secrets") {
 		t.Error("Expected event message to contain guidance about checking source configmaps and secrets")
 	}
 }
@@ -2078,7 +2265,7 @@ func Test_writeAlertManagerConfig_ValidationFailure(t *testing.T) {
 		},
 	}
 
-	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, invalidConfig)
+	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), invalidConfig)
 	if err == nil {
 		t.Fatal("Expected writeAlertManagerConfig to return error for invalid config, but got nil")
 	}
@@ -2126,7 +2313,7 @@ func Test_writeAlertManagerConfig_Success(t *testing.T) {
 		Templates: []string{},
 	}
 
-	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, validConfig)
+	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), validConfig)
 	if err != nil {
 		t.Fatalf("Expected writeAlertManagerConfig to succeed for valid config, got error: %v", err)
 	}
@@ -2423,13 +2610,13 @@ func Test_isManagementCluster_NamespaceScoping(t *testing.T) {
 				t.Fatalf("Failed to create ClusterVersion: %v", err)
 			}
 
-			result, err := reconciler.isManagementCluster()
+			profile, err := reconciler.clusterProfile.profile(context.TODO(), reconciler.Client)
 			if err != nil {
 				t.Fatalf("Expected no error, got: %v", err)
 			}
 
-			if result != tt.expected {
-				t.Fatalf("Expected isManagementCluster to return %v, got: %v", tt.expected, result)
+			if profile.isManagementCluster() != tt.expected {
+				t.Fatalf("Expected isManagementCluster to return %v, got: %v", tt.expected, profile.isManagementCluster())
 			}
 		})
 	}
@@ -2527,13 +2714,102 @@ func Test_parseMCNamespaceConfigMap(t *testing.T) {
 			}
 
 			request := createReconcileRequest(reconciler, cmNameManagedNamespaces)
-			namespaceList := reconciler.parseMCNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, request.Namespace, cmList)
+			namespaceList, _ := reconciler.parseMCNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, request.Namespace, cmList)
 
 			assertEquals(t, tt.expectedNamespaces, namespaceList, fmt.Sprintf("Test case: %s", tt.name))
 		})
 	}
 }
 
+func Test_namespaceMatchRE(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace alertmanager.Namespace
+		expected  string
+		wantErr   bool
+	}{
+		{
+			name:      "literal name",
+			namespace: alertmanager.Namespace{Name: "openshift-monitoring"},
+			expected:  "^openshift-monitoring$",
+		},
+		{
+			name:      "literal name with regex metacharacters",
+			namespace: alertmanager.Namespace{Name: "openshift-.*"},
+			wantErr:   true,
+		},
+		{
+			name:      "empty name",
+			namespace: alertmanager.Namespace{},
+			wantErr:   true,
+		},
+		{
+			name:      "regex",
+			namespace: alertmanager.Namespace{Name: "openshift-.*|redhat-.*", Regex: true},
+			expected:  "^(?:openshift-.*|redhat-.*)$",
+		},
+		{
+			name:      "invalid regex",
+			namespace: alertmanager.Namespace{Name: "openshift-(", Regex: true},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := namespaceMatchRE(tt.namespace)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %q", re)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			assertEquals(t, tt.expected, re, tt.name)
+		})
+	}
+}
+
+// Test_parseNamespaceConfigMap_InvalidEntries verifies that invalid entries are skipped and
+// reported without dropping the valid ones.
+func Test_parseNamespaceConfigMap_InvalidEntries(t *testing.T) {
+	reconciler := createReconciler(t, nil)
+	createNamespace(reconciler, t)
+	createConfigMap(reconciler, cmNameManagedNamespaces, cmKeyManagedNamespaces, `Resources:
+  Namespace:
+  - name: 'dedicated-admin'
+  - name: 'openshift-(bad'
+  - name: 'openshift-(bad'
+    regex: true
+  - name: 'openshift-(backplane|logging)'
+    regex: true
+  ManagementCluster:
+    AdditionalNamespaces:
+    - name: 'hypershift'
+    - name: 'Clusters'`)
+
+	cmList := &corev1.ConfigMapList{}
+	if err := reconciler.Client.List(context.TODO(), cmList, &client.ListOptions{}); err != nil {
+		t.Fatalf("Could not list ConfigMaps: %v", err)
+	}
+
+	namespaceList, _ := reconciler.parseNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, config.OperatorNamespace, cmKeyManagedNamespaces, cmList)
+	assertEquals(t, []string{"^dedicated-admin$", "^(?:openshift-(backplane|logging))$"}, namespaceList, "valid namespace entries")
+
+	mcNamespaceList, _ := reconciler.parseMCNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, config.OperatorNamespace, cmList)
+	assertEquals(t, []string{"^hypershift$"}, mcNamespaceList, "valid MC namespace entries")
+
+	rejected := recordedEvents(reconciler, eventReasonNamespaceEntryRejected)
+	if len(rejected) != 2 {
+		t.Fatalf("Expected a rejection event per namespace list, got %v", rejected)
+	}
+	if !strings.Contains(rejected[0].note, "Skipped 2 invalid entries") {
+		t.Errorf("Expected the event to count both invalid entries, got %q", rejected[0].note)
+	}
+}
+
 // ============================================================================
 // Additional Coverage Tests for SREP-3376
 // These tests address uncovered code paths in validation and write operations
@@ -2589,7 +2865,7 @@ func Test_writeAlertManagerConfig_SecretDoesNotExist_CreateSucceeds(t *testing.T
 	}
 
 	// Write the config - since secret doesn't exist, it should be created
-	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, validConfig)
+	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), validConfig)
 	if err != nil {
 		t.Fatalf("Expected write to succeed via Create fallback, got error: %v", err)
 	}
@@ -2648,7 +2924,7 @@ func Test_writeAlertManagerConfig_UpdateExistingSecret(t *testing.T) {
 	}
 
 	// Write the config - should update existing secret
-	err = writeAlertManagerConfig(context.Background(), reconciler, reqLogger, validConfig)
+	err = writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), validConfig)
 	if err != nil {
 		t.Fatalf("Expected write to succeed via Update, got error: %v", err)
 	}
@@ -2691,7 +2967,7 @@ func Test_writeAlertManagerConfig_ValidationFailure_MetricUpdated(t *testing.T)
 	}
 
 	// Attempt to write the invalid config
-	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, invalidConfig)
+	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), invalidConfig)
 	if err == nil {
 		t.Fatal("Expected write to fail due to validation error, but it succeeded")
 	}
@@ -2735,7 +3011,7 @@ func Test_writeAlertManagerConfig_Success_MetricUpdated(t *testing.T) {
 	}
 
 	// Write the config
-	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, validConfig)
+	err := writeAlertManagerConfig(context.Background(), reconciler, reqLogger, platformTarget(), validConfig)
 	if err != nil {
 		t.Fatalf("Expected write to succeed, got error: %v", err)
 	}
@@ -2769,39 +3045,18 @@ func Test_recordConfigValidationEvent_Success(t *testing.T) {
 	// Create a validation error
 	validationErr := fmt.Errorf("alertmanager config validation failed: test error")
 
-	// Record the event (should succeed with fake client)
-	reconciler.recordConfigValidationEvent(context.Background(), validationErr)
-
-	// Verify event was created
-	eventList := &corev1.EventList{}
-	err := reconciler.Client.List(context.TODO(), eventList, &client.ListOptions{
-		Namespace: "openshift-monitoring",
-	})
-	if err != nil {
-		t.Fatalf("Failed to list events: %v", err)
-	}
-
-	// Check that at least one event exists
-	if len(eventList.Items) == 0 {
-		t.Fatal("Expected at least one event to be created, but found none")
-	}
+	// Record the event
+	reconciler.recordConfigValidationEvent(context.Background(), platformTarget(), validationErr)
 
 	// Find the validation failure event
-	found := false
-	for _, event := range eventList.Items {
-		if event.Reason == "AlertmanagerConfigValidationFailure" {
-			found = true
-			// Verify event contains error message
-			if !strings.Contains(event.Message, "test error") {
-				t.Fatalf("Expected event message to contain 'test error', got: %s", event.Message)
-			}
-			break
-		}
-	}
-
-	if !found {
+	validationEvents := recordedEvents(reconciler, "AlertmanagerConfigValidationFailure")
+	if len(validationEvents) == 0 {
 		t.Fatal("Expected to find AlertmanagerConfigValidationFailure event, but didn't")
 	}
+	// Verify event contains error message
+	if !strings.Contains(validationEvents[0].note, "test error") {
+		t.Fatalf("Expected event message to contain 'test error', got: %s", validationEvents[0].note)
+	}
 }
 
 // Test_validateAlertManagerConfig_ComplexValidConfig verifies complex but valid configs pass
@@ -2880,3 +3135,1520 @@ func Test_validateAlertManagerConfig_InvalidRegexInRoute(t *testing.T) {
 		t.Fatalf("Expected error about invalid regex, got: %v", err)
 	}
 }
+
+func Test_settingsFromOperatorConfig(t *testing.T) {
+	defaults := config.Settings{
+		MaxClusterAgeMinutes:     90,
+		ReadinessRequeueInterval: 30 * time.Second,
+		SettlingWindowMinutes:    30,
+		ResumeSettling:           true,
+	}
+
+	assertEquals(t, defaults, settingsFromOperatorConfig(defaults, nil), "Expected defaults without a ConfigureAlertmanager")
+
+	maxAge := int32(120)
+	requeue := int32(60)
+	resumeSettling := false
+	compactRoutes := true
+	operatorConfig := &v1alpha1.ConfigureAlertmanager{
+		Spec: v1alpha1.ConfigureAlertmanagerSpec{
+			Profile: v1alpha1.ProfileFedRAMP,
+			Readiness: v1alpha1.ReadinessSpec{
+				MaxClusterAgeMinutes:   &maxAge,
+				RequeueIntervalSeconds: &requeue,
+			},
+			Features: v1alpha1.FeatureToggles{ResumeSettling: &resumeSettling, CompactRoutes: &compactRoutes, RoutingCoverage: &compactRoutes},
+			AutomationRoutes: []v1alpha1.AutomationRoute{
+				{Name: "ticketing", MatchLabels: map[string]string{"route_to_ticketing": "true"}, SecretName: "ticketing-secret", Continue: &compactRoutes},
+			},
+		},
+	}
+	expected := config.Settings{
+		Fedramp:                  true,
+		MaxClusterAgeMinutes:     120,
+		ReadinessRequeueInterval: time.Minute,
+		SettlingWindowMinutes:    30,
+		ResumeSettling:           false,
+		CompactRoutes:            true,
+		RoutingCoverage:          true,
+		AutomationRoutes: []config.AutomationRoute{
+			{Name: "ticketing", MatchLabels: map[string]string{"route_to_ticketing": "true"}, SecretName: "ticketing-secret", Continue: &compactRoutes},
+		},
+	}
+	assertEquals(t, expected, settingsFromOperatorConfig(defaults, operatorConfig), "Unexpected settings")
+}
+
+func Test_SecretReconciler_OperatorConfigStatus(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).Times(2)
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).Times(2)
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNameDMS, secretKeyDMS, "https://hjklasdf09876")
+
+	maxAge := int32(0)
+	operatorConfig := &v1alpha1.ConfigureAlertmanager{
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ConfigureAlertmanagerName},
+		Spec: v1alpha1.ConfigureAlertmanagerSpec{
+			Readiness: v1alpha1.ReadinessSpec{MaxClusterAgeMinutes: &maxAge},
+		},
+	}
+	if err := reconciler.Client.Create(context.TODO(), operatorConfig); err != nil {
+		t.Fatalf("Failed to create ConfigureAlertmanager: %v", err)
+	}
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKeyFromObject(operatorConfig), operatorConfig); err != nil {
+		t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+	}
+	status := operatorConfig.Status
+	if !meta.IsStatusConditionTrue(status.Conditions, v1alpha1.ConditionConfigApplied) {
+		t.Fatalf("Expected ConfigApplied condition to be true, got %v", status.Conditions)
+	}
+	secret := &corev1.Secret{}
+	if err := reconciler.Client.Get(context.TODO(), req.NamespacedName, secret); err != nil {
+		t.Fatalf("Failed to get %s: %v", secretNameAlertmanager, err)
+	}
+	sum := sha256.Sum256(secret.Data["alertmanager.yaml"])
+	if status.LastAppliedHash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Expected LastAppliedHash to match the written config, got %s", status.LastAppliedHash)
+	}
+	assertEquals(t, int32(0), status.ObservedInputs.MaxClusterAgeMinutes, "Unexpected observed max cluster age")
+	assertEquals(t, config.OperatorNamespace, status.ObservedInputs.ManagedNamespace, "Unexpected observed managed namespace")
+	if len(status.ObservedInputs.Sources) != 1 || status.ObservedInputs.Sources[0].Name != secretNameDMS {
+		t.Fatalf("Expected %s as the only observed source, got %v", secretNameDMS, status.ObservedInputs.Sources)
+	}
+	assertEquals(t, []v1alpha1.NamespaceSource{
+		{Name: namespaceSourceManaged, State: v1alpha1.NamespaceSourceDefaulted, Namespaces: int32(len(defaultManagedNamespaces))},
+		{Name: namespaceSourceOCP, State: v1alpha1.NamespaceSourceDefaulted, Namespaces: int32(len(defaultOCPNamespaces))},
+		{Name: namespaceSourceManagementCluster, State: v1alpha1.NamespaceSourceSkipped},
+	}, status.ObservedInputs.NamespaceSources, "Unexpected observed namespace sources")
+	for _, conditionType := range []string{v1alpha1.ConditionReady, v1alpha1.ConditionConfigValid} {
+		if !meta.IsStatusConditionTrue(status.Conditions, conditionType) {
+			t.Fatalf("Expected %s condition to be true, got %v", conditionType, status.Conditions)
+		}
+	}
+	if !meta.IsStatusConditionFalse(status.Conditions, v1alpha1.ConditionDegraded) {
+		t.Fatalf("Expected Degraded condition to be false, got %v", status.Conditions)
+	}
+	assertEquals(t, status.LastAppliedHash, status.RenderedHash, "Expected rendered and applied hashes to match")
+	assertEquals(t, []string{integrationDeadMansSnitch}, status.ActiveIntegrations, "Unexpected active integrations")
+	if status.LastWriteTime == nil {
+		t.Fatal("Expected LastWriteTime to be set")
+	}
+	assertEquals(t, "", status.LastError, "Unexpected last error")
+
+	// A reconcile with the same outcome leaves the status alone
+	resourceVersion := operatorConfig.ResourceVersion
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKeyFromObject(operatorConfig), operatorConfig); err != nil {
+		t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+	}
+	assertEquals(t, resourceVersion, operatorConfig.ResourceVersion, "Expected an unchanged status not to be written")
+}
+
+// Test_SecretReconciler_OperatorConfigStatus_ReadinessError tests that the ConfigureAlertmanager
+// singleton is created if missing and reports a reconcile that failed before writing the config.
+func Test_SecretReconciler_OperatorConfigStatus_ReadinessError(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(false, fmt.Errorf("prometheus unreachable"))
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{})
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterInfrastructure(reconciler)
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err == nil {
+		t.Fatal("Expected readiness error to be returned")
+	}
+
+	operatorConfig := &v1alpha1.ConfigureAlertmanager{}
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Name: v1alpha1.ConfigureAlertmanagerName}, operatorConfig); err != nil {
+		t.Fatalf("Expected ConfigureAlertmanager to be created: %v", err)
+	}
+	status := operatorConfig.Status
+	if !meta.IsStatusConditionTrue(status.Conditions, v1alpha1.ConditionDegraded) {
+		t.Fatalf("Expected Degraded condition to be true, got %v", status.Conditions)
+	}
+	if !meta.IsStatusConditionFalse(status.Conditions, v1alpha1.ConditionReady) {
+		t.Fatalf("Expected Ready condition to be false, got %v", status.Conditions)
+	}
+	if meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionConfigApplied) != nil {
+		t.Fatalf("Expected no ConfigApplied condition before a write was attempted, got %v", status.Conditions)
+	}
+	if !strings.Contains(status.LastError, "prometheus unreachable") {
+		t.Fatalf("Expected last error to mention the readiness failure, got %q", status.LastError)
+	}
+}
+
+func Test_setOperatorConfigStatus_ValidationFailed(t *testing.T) {
+	status := &v1alpha1.ConfigureAlertmanagerStatus{ManagedConfigStatus: v1alpha1.ManagedConfigStatus{LastAppliedHash: "previous"}}
+	validationErr := fmt.Errorf("%w: bad regex", errConfigInvalid)
+	setOperatorConfigStatus(status, 2, &reconcileOutcome{
+		clusterReady:   true,
+		renderedHash:   "rendered",
+		validationErr:  validationErr,
+		writeAttempted: true,
+		err:            validationErr,
+	}, metav1.Now())
+
+	assertEquals(t, int64(2), status.ObservedGeneration, "Unexpected observed generation")
+	assertEquals(t, "previous", status.LastAppliedHash, "Expected the last applied hash to be kept")
+	assertEquals(t, "rendered", status.RenderedHash, "Unexpected rendered hash")
+	for _, conditionType := range []string{v1alpha1.ConditionConfigValid, v1alpha1.ConditionConfigApplied, v1alpha1.ConditionReady} {
+		if !meta.IsStatusConditionFalse(status.Conditions, conditionType) {
+			t.Fatalf("Expected %s condition to be false, got %v", conditionType, status.Conditions)
+		}
+	}
+	if !meta.IsStatusConditionTrue(status.Conditions, v1alpha1.ConditionDegraded) {
+		t.Fatalf("Expected Degraded condition to be true, got %v", status.Conditions)
+	}
+}
+
+// Test_SecretReconciler_ConfigChangeEvents tests that integration transitions and config
+// updates are recorded as Events, and that an unchanged config records nothing.
+func Test_SecretReconciler_ConfigChangeEvents(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNameDMS, secretKeyDMS, "https://hjklasdf09876")
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	recorder := reconciler.Recorder.(*testRecorder)
+
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	enabled := recordedEvents(reconciler, eventReasonIntegrationEnabled)
+	if len(enabled) != 1 || !strings.HasPrefix(enabled[0].note, integrationDeadMansSnitch) {
+		t.Fatalf("Expected DeadMansSnitch to be enabled, got %v", enabled)
+	}
+	if source, ok := enabled[0].regarding.(*corev1.Secret); !ok || source.Name != secretNameDMS {
+		t.Fatalf("Expected the event to be about %s, got %v", secretNameDMS, enabled[0].regarding)
+	}
+	if updated := recordedEvents(reconciler, eventReasonConfigUpdated); len(updated) != 1 || !strings.Contains(updated[0].note, "created") {
+		t.Fatalf("Expected a single config created event, got %v", updated)
+	}
+
+	// Reconciling again without changes records nothing
+	recorder.events = nil
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(recorder.events) != 0 {
+		t.Fatalf("Expected no events for an unchanged config, got %v", recorder.events)
+	}
+
+	// Adding PagerDuty enables it and summarises the new receivers
+	createSecret(reconciler, secretNamePD, secretKeyPD, "asdfjkl123")
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	enabled = recordedEvents(reconciler, eventReasonIntegrationEnabled)
+	if len(enabled) != 1 || !strings.HasPrefix(enabled[0].note, integrationPagerDuty) {
+		t.Fatalf("Expected PagerDuty to be enabled, got %v", enabled)
+	}
+	updated := recordedEvents(reconciler, eventReasonConfigUpdated)
+	if len(updated) != 1 || !strings.Contains(updated[0].note, "receivers added: "+receiverPagerduty) {
+		t.Fatalf("Expected the config update to list the pagerduty receiver, got %v", updated)
+	}
+}
+
+func Test_diffConfigs(t *testing.T) {
+	previous := renderTestConfig("old-routing-key", nil, "", "", "", "https://dms.example/old", "", exampleClusterId, exampleRegion, "", defaultNamespaces, nil)
+	current := renderTestConfig("new-routing-key", nil, "https://goalert.example/low", "https://goalert.example/high", "", "https://dms.example/old", "", exampleClusterId, exampleRegion, "", defaultNamespaces, nil)
+
+	diff := diffConfigs(previous, current)
+
+	assertEquals(t, []string{receiverGoAlertLow, receiverGoAlertHigh}, diff.ReceiversAdded, "Unexpected receivers added")
+	if len(diff.ReceiversRemoved) != 0 {
+		t.Fatalf("Expected no receivers removed, got %v", diff.ReceiversRemoved)
+	}
+	if len(diff.RoutesAdded) == 0 {
+		t.Fatal("Expected GoAlert routes to be added")
+	}
+
+	var pagerdutyChange *receiverChange
+	for i := range diff.ReceiversChanged {
+		if diff.ReceiversChanged[i].Name == receiverPagerduty {
+			pagerdutyChange = &diff.ReceiversChanged[i]
+		}
+	}
+	if pagerdutyChange == nil {
+		t.Fatalf("Expected the %s receiver to have changed, got %v", receiverPagerduty, diff.ReceiversChanged)
+	}
+	assertEquals(t, []string{"pagerduty_configs[0].routing_key: <redacted> -> <redacted>"}, pagerdutyChange.Changes, "Unexpected pagerduty changes")
+
+	annotation := diff.annotation()
+	for _, secretValue := range []string{"old-routing-key", "new-routing-key", "goalert.example"} {
+		if strings.Contains(annotation, secretValue) {
+			t.Fatalf("Expected %q to be redacted from the diff, got %s", secretValue, annotation)
+		}
+	}
+	if !strings.Contains(diff.summary(), "receivers added: goalert, goalert-high") {
+		t.Fatalf("Unexpected summary: %s", diff.summary())
+	}
+
+	if created := diffConfigs(nil, current); !created.Created || !strings.HasPrefix(created.summary(), "Alertmanager config created") {
+		t.Fatalf("Expected a created diff, got %+v", created)
+	}
+}
+
+func Test_writeAlertManagerConfig_LastChangeAnnotation(t *testing.T) {
+	reconciler := createReconciler(t, nil)
+	createNamespace(reconciler, t)
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	lastChange := func() string {
+		secret := &corev1.Secret{}
+		if err := reconciler.Client.Get(context.TODO(), req.NamespacedName, secret); err != nil {
+			t.Fatalf("Failed to get %s: %v", secretNameAlertmanager, err)
+		}
+		return secret.Annotations[annotationLastChange]
+	}
+
+	withDMS := renderTestConfig("", nil, "", "", "", "https://dms.example", "", exampleClusterId, exampleRegion, "", defaultNamespaces, nil)
+	if err := writeAlertManagerConfig(context.TODO(), reconciler, reqLogger, platformTarget(), withDMS); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	created := lastChange()
+	if !strings.Contains(created, `"created":true`) {
+		t.Fatalf("Expected the annotation to record the config was created, got %s", created)
+	}
+
+	// An unchanged write keeps the previous annotation
+	if err := writeAlertManagerConfig(context.TODO(), reconciler, reqLogger, platformTarget(), withDMS); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, created, lastChange(), "Expected the annotation to be unchanged")
+
+	withoutDMS := renderTestConfig("", nil, "", "", "", "", "", exampleClusterId, exampleRegion, "", defaultNamespaces, nil)
+	if err := writeAlertManagerConfig(context.TODO(), reconciler, reqLogger, platformTarget(), withoutDMS); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated := lastChange(); !strings.Contains(updated, `"receiversRemoved":["watchdog"]`) {
+		t.Fatalf("Expected the annotation to record the watchdog receiver was removed, got %s", updated)
+	}
+}
+
+// Test_SecretReconciler_ManualEdits tests that edits to alertmanager-main made outside the
+// operator are detected and handled according to the drift policy and pause annotation.
+func Test_SecretReconciler_ManualEdits(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNameDMS, secretKeyDMS, "https://hjklasdf09876")
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	recorder := reconciler.Recorder.(*testRecorder)
+
+	reconcileAndGet := func() *corev1.Secret {
+		if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		secret := &corev1.Secret{}
+		if err := reconciler.Client.Get(context.TODO(), req.NamespacedName, secret); err != nil {
+			t.Fatalf("Failed to get %s: %v", secretNameAlertmanager, err)
+		}
+		return secret
+	}
+	// editSecret replaces alertmanager.yaml with a config without the watchdog receiver
+	editSecret := func(annotations map[string]string) {
+		secret := &corev1.Secret{}
+		if err := reconciler.Client.Get(context.TODO(), req.NamespacedName, secret); err != nil {
+			t.Fatalf("Failed to get %s: %v", secretNameAlertmanager, err)
+		}
+		edited, err := yaml.Marshal(renderTestConfig("", nil, "", "", "", "", "", exampleClusterId, exampleRegion, "", defaultNamespaces, nil))
+		if err != nil {
+			t.Fatalf("Failed to marshal config: %v", err)
+		}
+		secret.Data["alertmanager.yaml"] = edited
+		for k, v := range annotations {
+			secret.Annotations[k] = v
+		}
+		if err := reconciler.Client.Update(context.TODO(), secret); err != nil {
+			t.Fatalf("Failed to edit %s: %v", secretNameAlertmanager, err)
+		}
+	}
+	conditionReason := func(conditionType string) string {
+		operatorConfig := &v1alpha1.ConfigureAlertmanager{}
+		if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Name: v1alpha1.ConfigureAlertmanagerName}, operatorConfig); err != nil {
+			t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+		}
+		condition := meta.FindStatusCondition(operatorConfig.Status.Conditions, conditionType)
+		if condition == nil {
+			t.Fatalf("Expected a %s condition, got %v", conditionType, operatorConfig.Status.Conditions)
+		}
+		return condition.Reason
+	}
+
+	setDriftPolicy := func(policy v1alpha1.DriftPolicy) {
+		operatorConfig := &v1alpha1.ConfigureAlertmanager{}
+		if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Name: v1alpha1.ConfigureAlertmanagerName}, operatorConfig); err != nil {
+			t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+		}
+		operatorConfig.Spec.DriftPolicy = policy
+		if err := reconciler.Client.Update(context.TODO(), operatorConfig); err != nil {
+			t.Fatalf("Failed to update ConfigureAlertmanager: %v", err)
+		}
+	}
+
+	written := reconcileAndGet()
+	writtenConfig := string(written.Data["alertmanager.yaml"])
+	sum := sha256.Sum256(written.Data["alertmanager.yaml"])
+	assertEquals(t, hex.EncodeToString(sum[:]), written.Annotations[annotationConfigHash], "Expected the hash of the written config to be annotated")
+	if drift := recordedEvents(reconciler, eventReasonConfigDriftDetected); len(drift) != 0 {
+		t.Fatalf("Expected no drift for the operator's own write, got %v", drift)
+	}
+
+	// The default policy overwrites the edit and reports it
+	recorder.events = nil
+	editSecret(nil)
+	assertEquals(t, writtenConfig, string(reconcileAndGet().Data["alertmanager.yaml"]), "Expected the edit to be overwritten")
+	drift := recordedEvents(reconciler, eventReasonConfigDriftDetected)
+	if len(drift) != 1 || !strings.Contains(drift[0].note, "receivers removed: "+receiverWatchdog) || !strings.Contains(drift[0].note, "overwriting") {
+		t.Fatalf("Expected a drift event describing the overwritten edit, got %v", drift)
+	}
+
+	// PreserveAndAlert keeps the edit and reports it on status
+	setDriftPolicy(v1alpha1.DriftPolicyPreserveAndAlert)
+	recorder.events = nil
+	editSecret(nil)
+	if preserved := string(reconcileAndGet().Data["alertmanager.yaml"]); preserved == writtenConfig {
+		t.Fatal("Expected the edit to be preserved")
+	}
+	if drift := recordedEvents(reconciler, eventReasonConfigDriftDetected); len(drift) != 1 || !strings.Contains(drift[0].note, "preserving") {
+		t.Fatalf("Expected a drift event describing the preserved edit, got %v", drift)
+	}
+	assertEquals(t, conditionReasonDriftPreserved, conditionReason(v1alpha1.ConditionConfigApplied), "Unexpected ConfigApplied reason")
+	assertEquals(t, conditionReasonDriftPreserved, conditionReason(v1alpha1.ConditionReady), "Unexpected Ready reason")
+
+	// The pause annotation leaves the secret alone regardless of policy
+	setDriftPolicy(v1alpha1.DriftPolicyOverwrite)
+	editSecret(map[string]string{annotationPaused: "investigating OHSS-1234"})
+	if paused := reconcileAndGet(); string(paused.Data["alertmanager.yaml"]) == writtenConfig {
+		t.Fatal("Expected the secret not to be written while paused")
+	}
+	if events := recordedEvents(reconciler, eventReasonManagementPaused); len(events) != 1 {
+		t.Fatalf("Expected a management paused event, got %v", events)
+	}
+	assertEquals(t, conditionReasonManagementPaused, conditionReason(v1alpha1.ConditionConfigApplied), "Unexpected ConfigApplied reason")
+
+	// Removing the annotation resumes management
+	paused := &corev1.Secret{}
+	if err := reconciler.Client.Get(context.TODO(), req.NamespacedName, paused); err != nil {
+		t.Fatalf("Failed to get %s: %v", secretNameAlertmanager, err)
+	}
+	delete(paused.Annotations, annotationPaused)
+	if err := reconciler.Client.Update(context.TODO(), paused); err != nil {
+		t.Fatalf("Failed to update %s: %v", secretNameAlertmanager, err)
+	}
+	assertEquals(t, writtenConfig, string(reconcileAndGet().Data["alertmanager.yaml"]), "Expected management to resume")
+	assertEquals(t, "Applied", conditionReason(v1alpha1.ConditionConfigApplied), "Unexpected ConfigApplied reason")
+}
+
+func Test_mergeOverrides(t *testing.T) {
+	generated := renderTestConfig("routing-key", nil, "", "", "", "https://dms.example", "", exampleClusterId, exampleRegion, "", defaultNamespaces, nil)
+	generatedRoutes := len(generated.Route.Routes)
+	generatedReceivers := len(generated.Receivers)
+
+	teamRoute := &alertmanager.Route{Receiver: "team-webhook", Match: map[string]string{"team": "storage"}}
+	lastRoute := &alertmanager.Route{Receiver: "team-webhook", Match: map[string]string{"alertname": "CustomerAlert"}}
+	fragments := []overridesFragment{
+		{description: "ConfigMap " + cmNameOverrides, overrides: &alertmanager.ConfigOverrides{
+			Routes:    alertmanager.RouteOverrides{Prepend: []*alertmanager.Route{teamRoute}, Append: []*alertmanager.Route{lastRoute}},
+			Templates: []string{"/etc/alertmanager/config/team.tmpl"},
+		}},
+		{description: "Secret " + secretNameOverrides, overrides: &alertmanager.ConfigOverrides{
+			Receivers: []*alertmanager.Receiver{{Name: "team-webhook", WebhookConfigs: []*alertmanager.WebhookConfig{{URL: "https://team.example"}}}},
+			Templates: []string{"/etc/alertmanager/config/team.tmpl"},
+		}},
+	}
+
+	merged, err := mergeOverrides(generated, fragments)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes := merged.Route.Routes
+	if len(routes) != generatedRoutes+2 || routes[0] != teamRoute || routes[len(routes)-1] != lastRoute {
+		t.Fatalf("Expected the prepended and appended routes around the generated routes, got %v", describeRoutes(merged.Route))
+	}
+	assertEquals(t, "team-webhook", merged.Receivers[len(merged.Receivers)-1].Name, "Expected the override receiver to be added")
+	assertEquals(t, []string{"/etc/alertmanager/config/team.tmpl"}, merged.Templates, "Expected templates to be deduplicated")
+	assertEquals(t, generatedRoutes, len(generated.Route.Routes), "Expected the generated routes to be unchanged")
+	assertEquals(t, generatedReceivers, len(generated.Receivers), "Expected the generated receivers to be unchanged")
+	if err := validateAlertManagerConfig(reqLogger, merged); err != nil {
+		t.Fatalf("Expected the merged config to be valid: %v", err)
+	}
+
+	// Receivers may not shadow the operator's receivers, or each other
+	collisions := []struct {
+		name      string
+		fragments []overridesFragment
+		expected  string
+	}{
+		{
+			name: "operator receiver",
+			fragments: []overridesFragment{{description: "Secret " + secretNameOverrides, overrides: &alertmanager.ConfigOverrides{
+				Receivers: []*alertmanager.Receiver{{Name: receiverPagerduty}},
+			}}},
+			expected: `receiver "pagerduty" is already defined by the operator`,
+		},
+		{
+			name: "other override",
+			fragments: []overridesFragment{
+				{description: "ConfigMap " + cmNameOverrides, overrides: &alertmanager.ConfigOverrides{Receivers: []*alertmanager.Receiver{{Name: "team"}}}},
+				{description: "Secret " + secretNameOverrides, overrides: &alertmanager.ConfigOverrides{Receivers: []*alertmanager.Receiver{{Name: "team"}}}},
+			},
+			expected: `receiver "team" is already defined by ConfigMap alertmanager-overrides`,
+		},
+	}
+	for _, tt := range collisions {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mergeOverrides(generated, tt.fragments); err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Fatalf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// Test_SecretReconciler_Overrides tests that overrides are merged into alertmanager-main, and
+// that rejected overrides fall back to the generated config.
+func Test_SecretReconciler_Overrides(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNameDMS, secretKeyDMS, "https://hjklasdf09876")
+	createConfigMap(reconciler, cmNameOverrides, keyOverrides, `
+routes:
+  prepend:
+  - receiver: team-webhook
+    match:
+      team: storage
+`)
+	createSecret(reconciler, secretNameOverrides, keyOverrides, `
+receivers:
+- name: team-webhook
+  webhook_configs:
+  - url: https://team.example/hook
+`)
+	req := createReconcileRequest(reconciler, cmNameOverrides)
+
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	amconfig := readAlertManagerConfig(reconciler, req)
+	assertEquals(t, "team-webhook", amconfig.Route.Routes[0].Receiver, "Expected the prepended route first")
+	if _, ok := receiversByName(amconfig)["team-webhook"]; !ok {
+		t.Fatalf("Expected the override receiver in %v", receiverNames(amconfig))
+	}
+
+	// Redefining an operator receiver rejects the overrides as a whole
+	secret := &corev1.Secret{}
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Namespace: config.OperatorNamespace, Name: secretNameOverrides}, secret); err != nil {
+		t.Fatalf("Failed to get %s: %v", secretNameOverrides, err)
+	}
+	secret.Data[keyOverrides] = []byte(`
+receivers:
+- name: watchdog
+  webhook_configs:
+  - url: https://team.example/hook
+`)
+	if err := reconciler.Client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to update %s: %v", secretNameOverrides, err)
+	}
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Expected rejected overrides not to fail the reconcile: %v", err)
+	}
+	amconfig = readAlertManagerConfig(reconciler, req)
+	if _, ok := receiversByName(amconfig)["team-webhook"]; ok {
+		t.Fatal("Expected the generated config to be written without the overrides")
+	}
+	if rejected := recordedEvents(reconciler, eventReasonOverridesRejected); len(rejected) != 2 {
+		t.Fatalf("Expected a rejection event on the overrides ConfigMap and Secret, got %v", rejected)
+	}
+
+	// Unsupported fields are rejected rather than dropped
+	secret.Data[keyOverrides] = []byte(`
+receivers:
+- name: team-slack
+  slack_configs:
+  - channel: '#storage'
+`)
+	if err := reconciler.Client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to update %s: %v", secretNameOverrides, err)
+	}
+	secretList := &corev1.SecretList{}
+	cmList := &corev1.ConfigMapList{}
+	if err := reconciler.Client.List(context.TODO(), secretList); err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	if err := reconciler.Client.List(context.TODO(), cmList); err != nil {
+		t.Fatalf("Failed to list configMaps: %v", err)
+	}
+	generated := renderTestConfig("", nil, "", "", "", "", "", exampleClusterId, exampleRegion, "", defaultNamespaces, nil)
+	if result, err := reconciler.applyOverrides(reqLogger, generated, secretList, cmList); err == nil || !strings.Contains(err.Error(), "slack_configs") || result != generated {
+		t.Fatalf("Expected unsupported receiver types to be rejected, got %v", err)
+	}
+}
+
+func Test_translateRoute(t *testing.T) {
+	amc := &monitoringv1beta1.AlertmanagerConfig{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "storage"}}
+	child, err := json.Marshal(monitoringv1beta1.Route{
+		Receiver: "storage-pager",
+		Matchers: []monitoringv1beta1.Matcher{{Name: "severity", Value: "critical"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal child route: %v", err)
+	}
+	in := monitoringv1beta1.Route{
+		Receiver: "storage-webhook",
+		GroupBy:  []string{"alertname"},
+		Matchers: []monitoringv1beta1.Matcher{
+			{Name: "namespace", Value: "openshift-.*", MatchType: monitoringv1beta1.MatchRegexp},
+			{Name: "team", Value: "storage"},
+		},
+		Routes: []apiextensionsv1.JSON{{Raw: child}},
+	}
+
+	route, err := translateRoute(amc, in, []string{"storage-webhook", "storage-pager"}, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, "team-a/storage/storage-webhook", route.Receiver, "Expected the receiver to be prefixed")
+	assertEquals(t, []string{`namespace="team-a"`, `team="storage"`}, route.Matchers, "Expected the route to be scoped to its namespace")
+	if !route.Continue {
+		t.Fatal("Expected the top-level route to continue")
+	}
+	if len(route.Routes) != 1 || route.Routes[0].Receiver != "team-a/storage/storage-pager" || route.Routes[0].Continue {
+		t.Fatalf("Unexpected child routes: %v", describeRoutes(route))
+	}
+	assertEquals(t, []string{`severity="critical"`}, route.Routes[0].Matchers, "Expected child matchers to be kept")
+
+	if _, err := translateRoute(amc, monitoringv1beta1.Route{Receiver: "undefined"}, []string{"storage-webhook"}, true); err == nil {
+		t.Fatal("Expected an error for an undefined receiver")
+	}
+}
+
+// Test_SecretReconciler_AlertmanagerConfigs tests that selected AlertmanagerConfigs are added
+// to alertmanager-main, and that unsupported ones are skipped.
+func Test_SecretReconciler_AlertmanagerConfigs(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "asdfjkl123")
+
+	operatorConfig := &v1alpha1.ConfigureAlertmanager{
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ConfigureAlertmanagerName},
+		Spec: v1alpha1.ConfigureAlertmanagerSpec{
+			AlertmanagerConfigs: v1alpha1.AlertmanagerConfigSelection{Namespaces: []string{"team-a"}},
+		},
+	}
+	webhookSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "storage-webhook"},
+		Data:       map[string][]byte{"url": []byte("https://storage.example/hook")},
+	}
+	webhookRoute := &monitoringv1beta1.Route{Receiver: "storage-webhook", Matchers: []monitoringv1beta1.Matcher{{Name: "team", Value: "storage"}}}
+	selected := &monitoringv1beta1.AlertmanagerConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "storage"},
+		Spec: monitoringv1beta1.AlertmanagerConfigSpec{
+			Route: webhookRoute,
+			Receivers: []monitoringv1beta1.Receiver{{
+				Name:           "storage-webhook",
+				WebhookConfigs: []monitoringv1beta1.WebhookConfig{{URLSecret: &monitoringv1beta1.SecretKeySelector{Name: "storage-webhook", Key: "url"}}},
+			}},
+		},
+	}
+	unsupported := &monitoringv1beta1.AlertmanagerConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "slack"},
+		Spec: monitoringv1beta1.AlertmanagerConfigSpec{
+			Route:     &monitoringv1beta1.Route{Receiver: "slack"},
+			Receivers: []monitoringv1beta1.Receiver{{Name: "slack", SlackConfigs: []monitoringv1beta1.SlackConfig{{}}}},
+		},
+	}
+	teamBURL := "https://team-b.example/hook"
+	notSelected := &monitoringv1beta1.AlertmanagerConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "storage"},
+		Spec: monitoringv1beta1.AlertmanagerConfigSpec{
+			Route:     &monitoringv1beta1.Route{Receiver: "storage-webhook"},
+			Receivers: []monitoringv1beta1.Receiver{{Name: "storage-webhook", WebhookConfigs: []monitoringv1beta1.WebhookConfig{{URL: &teamBURL}}}},
+		},
+	}
+	for _, obj := range []client.Object{operatorConfig, webhookSecret, selected, unsupported, notSelected} {
+		if err := reconciler.Client.Create(context.TODO(), obj); err != nil {
+			t.Fatalf("Failed to create %s: %v", obj.GetName(), err)
+		}
+	}
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	result, err := reconciler.Reconcile(context.TODO(), *req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, alertmanagerConfigSecretResync, result.RequeueAfter, "Expected a requeue to pick up rotated webhook Secrets")
+
+	amconfig := readAlertManagerConfig(reconciler, req)
+	assertEquals(t, "team-a/storage/storage-webhook", amconfig.Route.Routes[0].Receiver, "Expected the AlertmanagerConfig route first")
+	assertEquals(t, []string{`namespace="team-a"`, `team="storage"`}, amconfig.Route.Routes[0].Matchers, "Unexpected AlertmanagerConfig matchers")
+	receivers := receiversByName(amconfig)
+	webhook, ok := receivers["team-a/storage/storage-webhook"]
+	if !ok || webhook.WebhookConfigs[0].URL != "https://storage.example/hook" {
+		t.Fatalf("Expected the webhook receiver with the URL from its Secret, got %v", receiverNames(amconfig))
+	}
+	for _, name := range []string{"team-a/slack/slack", "team-b/storage/storage-webhook"} {
+		if _, ok := receivers[name]; ok {
+			t.Fatalf("Expected receiver %s not to be aggregated", name)
+		}
+	}
+	if _, ok := receivers[receiverPagerduty]; !ok {
+		t.Fatal("Expected the operator's receivers to be kept")
+	}
+	if rejected := recordedEvents(reconciler, eventReasonAlertmanagerConfigRejected); len(rejected) != 1 || !strings.Contains(rejected[0].note, "only pagerdutyConfigs and webhookConfigs") {
+		t.Fatalf("Expected the slack AlertmanagerConfig to be rejected, got %v", rejected)
+	}
+
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKeyFromObject(operatorConfig), operatorConfig); err != nil {
+		t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+	}
+	observed := operatorConfig.Status.ObservedInputs.AlertmanagerConfigs
+	if len(observed) != 1 || observed[0].Namespace != "team-a" || observed[0].Name != "storage" {
+		t.Fatalf("Expected team-a/storage to be reported as aggregated, got %v", observed)
+	}
+}
+
+func Test_alertmanagerConfigNamespaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection v1alpha1.AlertmanagerConfigSelection
+		expected  []string
+	}{
+		{name: "nothing selected"},
+		{
+			name:      "namespaces",
+			selection: v1alpha1.AlertmanagerConfigSelection{Namespaces: []string{"team-b", "team-a", "team-b"}},
+			expected:  []string{"team-a", "team-b"},
+		},
+		{
+			name: "a selector matches in every namespace",
+			selection: v1alpha1.AlertmanagerConfigSelection{
+				Namespaces: []string{"team-a"},
+				Selector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "storage"}},
+			},
+			expected: []string{metav1.NamespaceAll},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertEquals(t, tt.expected, alertmanagerConfigNamespaces(tt.selection), "Unexpected cached namespaces")
+		})
+	}
+}
+
+func Test_requeueForSecretRotation(t *testing.T) {
+	url := "https://storage.example/hook"
+	inline := &monitoringv1beta1.AlertmanagerConfig{Spec: monitoringv1beta1.AlertmanagerConfigSpec{
+		Receivers: []monitoringv1beta1.Receiver{{Name: "webhook", WebhookConfigs: []monitoringv1beta1.WebhookConfig{{URL: &url}}}},
+	}}
+	fromSecret := &monitoringv1beta1.AlertmanagerConfig{Spec: monitoringv1beta1.AlertmanagerConfigSpec{
+		Receivers: []monitoringv1beta1.Receiver{{Name: "pager", PagerDutyConfigs: []monitoringv1beta1.PagerDutyConfig{{
+			RoutingKey: &monitoringv1beta1.SecretKeySelector{Name: "pager", Key: "key"},
+		}}}},
+	}}
+
+	tests := []struct {
+		name       string
+		result     reconcile.Result
+		aggregated []*monitoringv1beta1.AlertmanagerConfig
+		expected   time.Duration
+	}{
+		{name: "inline values aren't polled", aggregated: []*monitoringv1beta1.AlertmanagerConfig{inline}, expected: 0},
+		{name: "Secrets are polled", aggregated: []*monitoringv1beta1.AlertmanagerConfig{inline, fromSecret}, expected: alertmanagerConfigSecretResync},
+		{
+			name:       "an earlier readiness requeue is kept",
+			result:     reconcile.Result{RequeueAfter: 30 * time.Second},
+			aggregated: []*monitoringv1beta1.AlertmanagerConfig{fromSecret},
+			expected:   30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertEquals(t, tt.expected, requeueForSecretRotation(tt.result, tt.aggregated).RequeueAfter, "Unexpected requeue")
+		})
+	}
+}
+
+func Test_renderUserWorkloadConfig(t *testing.T) {
+	amconfig, _, err := render.RenderUserWorkload(render.Inputs{
+		Cluster:      render.Cluster{ID: exampleClusterId, Region: exampleRegion},
+		Integrations: []render.Integration{render.PagerDuty{RoutingKey: "pdkey"}, render.GoAlert{LowURL: "https://goalert/low", HighURL: "https://goalert/high"}},
+		Namespaces:   render.Namespaces{Managed: []string{"^my-app$"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := validateAlertManagerConfig(reqLogger, amconfig); err != nil {
+		t.Fatalf("Expected the user-workload config to be valid: %v", err)
+	}
+	assertEquals(t, 2, len(amconfig.Route.Routes), "Expected a PagerDuty and a GoAlert route")
+
+	pdRoute := amconfig.Route.Routes[0]
+	assertEquals(t, 1, len(pdRoute.Routes), "Expected one PagerDuty route per namespace")
+	assertEquals(t, receiverPagerduty, pdRoute.Routes[0].Receiver, "Expected user workloads to page")
+	assertEquals(t, map[string]string{"namespace": "^my-app$"}, pdRoute.Routes[0].MatchRE, "Expected the route to match the namespace")
+	if _, ok := pdRoute.Routes[0].Match["prometheus"]; ok {
+		t.Fatal("Expected user-workload routes not to require the platform Prometheus")
+	}
+	assertEquals(t, 3, len(amconfig.Route.Routes[1].Routes), "Expected GoAlert routes for critical, error and warning")
+
+	empty, _, err := render.RenderUserWorkload(render.Inputs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, []string{receiverNull}, receiverNames(empty), "Expected only the null receiver without integration secrets")
+}
+
+func Test_SecretReconciler_UserWorkload(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+
+	objects := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: config.UserWorkloadNamespace}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretNamePD, Namespace: config.UserWorkloadNamespace},
+			Data:       map[string][]byte{secretKeyPD: []byte("customerkey")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: cmNameManagedNamespaces, Namespace: config.UserWorkloadNamespace},
+			Data: map[string]string{cmKeyManagedNamespaces: `
+Resources:
+  Namespace:
+  - name: my-app
+`},
+		},
+	}
+	for _, obj := range objects {
+		if err := reconciler.Client.Create(context.TODO(), obj); err != nil {
+			t.Fatalf("Failed to create %s: %v", obj.GetName(), err)
+		}
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: config.UserWorkloadNamespace, Name: secretNamePD}}
+	userWorkloadSecret := client.ObjectKey{Namespace: config.UserWorkloadNamespace, Name: secretNameUserWorkloadAlertmanager}
+
+	// Nothing is written unless the user-workload Alertmanager is enabled
+	if _, err := reconciler.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := reconciler.Client.Get(context.TODO(), userWorkloadSecret, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Fatalf("Expected %s not to be written while disabled, got %v", secretNameUserWorkloadAlertmanager, err)
+	}
+
+	config.SetUserWorkloadEnabled(true)
+	defer config.SetUserWorkloadEnabled(false)
+	if _, err := reconciler.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	secret := &corev1.Secret{}
+	if err := reconciler.Client.Get(context.TODO(), userWorkloadSecret, secret); err != nil {
+		t.Fatalf("Expected %s to be written: %v", secretNameUserWorkloadAlertmanager, err)
+	}
+	amconfig := parseAlertManagerConfig(secret.Data["alertmanager.yaml"])
+	pd, ok := receiversByName(amconfig)[receiverPagerduty]
+	if !ok || pd.PagerdutyConfigs[0].RoutingKey != "customerkey" {
+		t.Fatalf("Expected the user-workload PagerDuty key to be used, got %v", receiverNames(amconfig))
+	}
+	assertEquals(t, map[string]string{"namespace": "^my-app$"}, amconfig.Route.Routes[0].Routes[0].MatchRE, "Expected the user-workload namespace to be routed")
+
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Namespace: config.OperatorNamespace, Name: secretNameAlertmanager}, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Fatalf("Expected alertmanager-main not to be written by a user-workload reconcile, got %v", err)
+	}
+}
+
+// Test_SecretReconciler_UserWorkloadStatus tests that user-workload reconciles report their
+// outcome in status.userWorkload, and that manual edits are handled by the drift policy.
+func Test_SecretReconciler_UserWorkloadStatus(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+	config.SetUserWorkloadEnabled(true)
+	defer config.SetUserWorkloadEnabled(false)
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	reconciler := createReconciler(t, mockReadiness)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	objects := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: config.UserWorkloadNamespace}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretNamePD, Namespace: config.UserWorkloadNamespace},
+			Data:       map[string][]byte{secretKeyPD: []byte("customerkey")},
+		},
+		&v1alpha1.ConfigureAlertmanager{ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ConfigureAlertmanagerName}},
+	}
+	for _, obj := range objects {
+		if err := reconciler.Client.Create(context.TODO(), obj); err != nil {
+			t.Fatalf("Failed to create %s: %v", obj.GetName(), err)
+		}
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: config.UserWorkloadNamespace, Name: secretNamePD}}
+	userWorkloadSecret := client.ObjectKey{Namespace: config.UserWorkloadNamespace, Name: secretNameUserWorkloadAlertmanager}
+	userWorkloadStatus := func() *v1alpha1.ManagedConfigStatus {
+		operatorConfig := &v1alpha1.ConfigureAlertmanager{}
+		if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Name: v1alpha1.ConfigureAlertmanagerName}, operatorConfig); err != nil {
+			t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+		}
+		if len(operatorConfig.Status.Conditions) != 0 {
+			t.Fatalf("Expected the alertmanager-main conditions to be left alone, got %v", operatorConfig.Status.Conditions)
+		}
+		if operatorConfig.Status.UserWorkload == nil {
+			t.Fatal("Expected the user-workload status to be reported")
+		}
+		return operatorConfig.Status.UserWorkload
+	}
+
+	if _, err := reconciler.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	status := userWorkloadStatus()
+	if !meta.IsStatusConditionTrue(status.Conditions, v1alpha1.ConditionReady) {
+		t.Fatalf("Expected the user-workload config to be ready, got %v", status.Conditions)
+	}
+	assertEquals(t, status.RenderedHash, status.LastAppliedHash, "Expected the rendered config to be applied")
+	assertEquals(t, []string{"PagerDuty"}, status.ActiveIntegrations, "Expected PagerDuty to be active")
+
+	// A manual edit is preserved and reported with the PreserveAndAlert policy
+	settings := config.DefaultSettings()
+	settings.DriftPolicy = config.DriftPolicyPreserveAndAlert
+	config.SetSettings(settings)
+	secret := &corev1.Secret{}
+	if err := reconciler.Client.Get(context.TODO(), userWorkloadSecret, secret); err != nil {
+		t.Fatalf("Failed to get %s: %v", secretNameUserWorkloadAlertmanager, err)
+	}
+	secret.Data["alertmanager.yaml"] = []byte("route:\n  receiver: edited\nreceivers:\n- name: edited\n")
+	if err := reconciler.Client.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("Failed to edit %s: %v", secretNameUserWorkloadAlertmanager, err)
+	}
+	if _, err := reconciler.Reconcile(context.TODO(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	applied := meta.FindStatusCondition(userWorkloadStatus().Conditions, v1alpha1.ConditionConfigApplied)
+	if applied == nil || applied.Status != metav1.ConditionFalse || applied.Reason != conditionReasonDriftPreserved {
+		t.Fatalf("Expected ConfigApplied to report the preserved edit, got %v", applied)
+	}
+	if err := reconciler.Client.Get(context.TODO(), userWorkloadSecret, secret); err != nil {
+		t.Fatalf("Failed to get %s: %v", secretNameUserWorkloadAlertmanager, err)
+	}
+	assertEquals(t, "route:\n  receiver: edited\nreceivers:\n- name: edited\n", string(secret.Data["alertmanager.yaml"]), "Expected the edit to be preserved")
+	if drifted := recordedEvents(reconciler, eventReasonConfigDriftDetected); len(drifted) != 1 {
+		t.Fatalf("Expected one %s Event, got %v", eventReasonConfigDriftDetected, drifted)
+	}
+}
+
+func Test_convertToAlertmanagerConfig(t *testing.T) {
+	amconfig := renderTestConfig("pdkey", nil, "https://goalert/low", "https://goalert/high", "", "https://dms/snitch", "", exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces, nil)
+	amc, secret, err := convertToAlertmanagerConfig(amconfig, config.OperatorNamespace)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assertEquals(t, config.OperatorNamespace, amc.Namespace, "Expected the AlertmanagerConfig in the managed namespace")
+	assertEquals(t, receiverNull, amc.Spec.Route.Receiver, "Expected the root receiver to be kept")
+	assertEquals(t, "30s", string(*amc.Spec.Route.GroupWait), "Expected the root timers to be kept")
+	assertEquals(t, len(amconfig.InhibitRules), len(amc.Spec.InhibitRules), "Expected every inhibit rule to be converted")
+
+	names := []string{}
+	receivers := map[string]monitoringv1beta1.Receiver{}
+	for _, receiver := range amc.Spec.Receivers {
+		names = append(names, receiver.Name)
+		receivers[receiver.Name] = receiver
+	}
+	assertEquals(t, receiverNames(amconfig), names, "Expected every receiver to be converted")
+
+	pd := receivers[receiverPagerduty].PagerDutyConfigs[0]
+	assertEquals(t, secret.Name, pd.RoutingKey.Name, "Expected the routing key to be read from the generated Secret")
+	assertEquals(t, "pdkey", string(secret.Data[pd.RoutingKey.Key]), "Expected the routing key in the generated Secret")
+	assertEquals(t, render.PagerDutyURL, string(*pd.URL), "Expected the global PagerDuty URL on the receiver")
+	assertEquals(t, exampleProxy, *pd.HTTPConfig.ProxyURL, "Expected the cluster proxy to be kept")
+	webhook := receivers[receiverWatchdog].WebhookConfigs[0]
+	assertEquals(t, "https://dms/snitch", string(secret.Data[webhook.URLSecret.Key]), "Expected the webhook URL in the generated Secret")
+	if webhook.URL != nil {
+		t.Fatal("Expected webhook URLs not to be inlined")
+	}
+
+	children, err := amc.Spec.Route.ChildRoutes()
+	if err != nil {
+		t.Fatalf("Expected child routes to decode: %v", err)
+	}
+	assertEquals(t, len(amconfig.Route.Routes), len(children), "Expected every child route to be converted")
+	assertEquals(t, []monitoringv1beta1.Matcher{
+		{Name: "alertname", Value: "Watchdog", MatchType: monitoringv1beta1.MatchEqual},
+	}, children[0].Matchers, "Expected match to become equality matchers")
+
+	regex, err := convertRoute(&alertmanager.Route{Receiver: receiverNull, MatchRE: map[string]string{"namespace": "^openshift-.*"}, Matchers: []string{`severity!="info"`}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, []monitoringv1beta1.Matcher{
+		{Name: "namespace", Value: "^openshift-.*", MatchType: monitoringv1beta1.MatchRegexp},
+		{Name: "severity", Value: "info", MatchType: monitoringv1beta1.MatchNotEqual},
+	}, regex.Matchers, "Expected match_re and matchers to be converted")
+
+	amconfig.Templates = []string{"/etc/alertmanager/custom.tmpl"}
+	if _, _, err := convertToAlertmanagerConfig(amconfig, config.OperatorNamespace); err == nil {
+		t.Fatal("Expected templates to be rejected")
+	}
+}
+
+func Test_SecretReconciler_AlertmanagerConfigOutput(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNameDMS, secretKeyDMS, "https://hjklasdf09876")
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	amcKey := client.ObjectKey{Namespace: config.OperatorNamespace, Name: outputAlertmanagerConfigName}
+
+	setOutputMode := func(mode v1alpha1.OutputMode) {
+		operatorConfig := &v1alpha1.ConfigureAlertmanager{}
+		if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Name: v1alpha1.ConfigureAlertmanagerName}, operatorConfig); err != nil {
+			t.Fatalf("Failed to get ConfigureAlertmanager: %v", err)
+		}
+		operatorConfig.Spec.OutputMode = mode
+		if err := reconciler.Client.Update(context.TODO(), operatorConfig); err != nil {
+			t.Fatalf("Failed to update ConfigureAlertmanager: %v", err)
+		}
+	}
+
+	// Start in the default mode, with the whole config in alertmanager-main
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := receiversByName(readAlertManagerConfig(reconciler, req))[receiverWatchdog]; !ok {
+		t.Fatal("Expected the watchdog receiver in alertmanager-main")
+	}
+
+	// The switch fails while the Alertmanager doesn't select AlertmanagerConfigs
+	alertmanagerMain := &monitoringv1.Alertmanager{ObjectMeta: metav1.ObjectMeta{Namespace: config.OperatorNamespace, Name: alertmanagerNameMain}}
+	if err := reconciler.Client.Create(context.TODO(), alertmanagerMain); err != nil {
+		t.Fatalf("Failed to create Alertmanager: %v", err)
+	}
+	setOutputMode(v1alpha1.OutputModeAlertmanagerConfig)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err == nil || !strings.Contains(err.Error(), "does not select any AlertmanagerConfigs") {
+		t.Fatalf("Expected an error while the Alertmanager doesn't select AlertmanagerConfigs, got %v", err)
+	}
+	if _, ok := receiversByName(readAlertManagerConfig(reconciler, req))[receiverWatchdog]; !ok {
+		t.Fatal("Expected alertmanager-main to be left alone when the switch fails")
+	}
+
+	alertmanagerMain.Spec.AlertmanagerConfigSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"managed.openshift.io/alertmanager": "main"}}
+	alertmanagerMain.Spec.AlertmanagerConfigMatcherStrategy.Type = monitoringv1.NoneConfigMatcherStrategyType
+	if err := reconciler.Client.Update(context.TODO(), alertmanagerMain); err != nil {
+		t.Fatalf("Failed to update Alertmanager: %v", err)
+	}
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	amc := &monitoringv1beta1.AlertmanagerConfig{}
+	if err := reconciler.Client.Get(context.TODO(), amcKey, amc); err != nil {
+		t.Fatalf("Expected the AlertmanagerConfig to be written: %v", err)
+	}
+	assertEquals(t, "main", amc.Labels["managed.openshift.io/alertmanager"], "Expected the AlertmanagerConfig to be selected by the Alertmanager")
+	if err := reconciler.Client.Get(context.TODO(), amcKey, &corev1.Secret{}); err != nil {
+		t.Fatalf("Expected the credentials Secret to be written: %v", err)
+	}
+	released := readAlertManagerConfig(reconciler, req)
+	assertEquals(t, []string{receiverNull}, receiverNames(released), "Expected alertmanager-main to be released to a base config")
+
+	// Switching back writes alertmanager-main and removes the AlertmanagerConfig
+	setOutputMode(v1alpha1.OutputModeSecret)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := receiversByName(readAlertManagerConfig(reconciler, req))[receiverWatchdog]; !ok {
+		t.Fatal("Expected the watchdog receiver back in alertmanager-main")
+	}
+	if err := reconciler.Client.Get(context.TODO(), amcKey, &monitoringv1beta1.AlertmanagerConfig{}); !errors.IsNotFound(err) {
+		t.Fatalf("Expected the AlertmanagerConfig to be deleted, got %v", err)
+	}
+	if err := reconciler.Client.Get(context.TODO(), amcKey, &corev1.Secret{}); !errors.IsNotFound(err) {
+		t.Fatalf("Expected the credentials Secret to be deleted, got %v", err)
+	}
+}
+
+func Test_renderConfig_HostedControlPlanes(t *testing.T) {
+	hostedControlPlanes := []render.HostedControlPlane{
+		{Namespace: "ocm-production-abc123-mycluster", ClusterID: "abc123"},
+		{Namespace: "ocm-production-abc123-mycluster-etcd", ClusterID: "abc123"},
+		{Namespace: "ocm-production-def456-other", ClusterID: "def456"},
+	}
+	amconfig := renderTestConfig("pdkey", nil, "https://goalert/low", "https://goalert/high", "", "", "", exampleClusterId, exampleRegion, exampleProxy, defaultNamespaces, &render.Namespaces{HostedControlPlanes: hostedControlPlanes})
+
+	receivers := receiversByName(amconfig)
+	for _, clusterID := range []string{"abc123", "def456"} {
+		receiver, ok := receivers[render.ReceiverPagerDutyHostedClusterPrefix+clusterID]
+		if !ok {
+			t.Fatalf("Expected a PagerDuty receiver for hosted cluster %s, got %v", clusterID, receiverNames(amconfig))
+		}
+		details := receiver.PagerdutyConfigs[0].Details
+		assertEquals(t, clusterID, details["hosted_cluster_id"], "Expected the hosted cluster ID in the incident details")
+		assertEquals(t, exampleClusterId, details["cluster_id"], "Expected the management cluster ID in the incident details")
+	}
+	hostedReceivers := 0
+	for _, name := range receiverNames(amconfig) {
+		if strings.HasPrefix(name, render.ReceiverPagerDutyHostedClusterPrefix) {
+			hostedReceivers++
+		}
+	}
+	assertEquals(t, 2, hostedReceivers, "Expected one receiver per hosted cluster")
+}
+
+func Test_SecretReconciler_HostedControlPlanes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "pdkey")
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{InfrastructureName: "hs-mc-ibv8l52c0-d4v8v"},
+	}
+	if err := reconciler.Client.Create(context.TODO(), infra); err != nil {
+		t.Fatalf("Failed to create Infrastructure: %v", err)
+	}
+	for _, namespace := range []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "ocm-production-abc123-mycluster", Labels: map[string]string{hostedControlPlaneLabelKey: "true", hostedClusterIDLabelKey: "abc123"}}},
+		// no cluster ID, so its alerts can't be attributed
+		{ObjectMeta: metav1.ObjectMeta{Name: "ocm-production-unlabelled", Labels: map[string]string{hostedControlPlaneLabelKey: "true"}}},
+		// not a HostedControlPlane namespace
+		{ObjectMeta: metav1.ObjectMeta{Name: "customer-app", Labels: map[string]string{hostedClusterIDLabelKey: "def456"}}},
+	} {
+		if err := reconciler.Client.Create(context.TODO(), namespace); err != nil {
+			t.Fatalf("Failed to create Namespace: %v", err)
+		}
+	}
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	names := receiverNames(readAlertManagerConfig(reconciler, req))
+	hosted := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, render.ReceiverPagerDutyHostedClusterPrefix) {
+			hosted = append(hosted, name)
+		}
+	}
+	assertEquals(t, []string{render.ReceiverPagerDutyHostedClusterPrefix + "abc123"}, hosted, "Expected a receiver only for the labelled HostedControlPlane namespace")
+}
+
+func Test_clusterProfileDetector(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	reconciler := createReconciler(t, nil)
+	detector := &clusterProfileDetector{}
+
+	// Without an Infrastructure, detection fails and isn't cached
+	profile, err := detector.profile(context.TODO(), reconciler.Client)
+	if err == nil {
+		t.Fatal("Expected an error without an Infrastructure")
+	}
+	assertEquals(t, clusterProfile{clusterType: config.ClusterTypeManagementCluster, source: clusterTypeSourceDetectionFailed}, profile, "Expected failed detection to default to a management cluster")
+
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{InfrastructureName: "regular-cluster-abc123"},
+	}
+	if err := reconciler.Client.Create(context.TODO(), infra); err != nil {
+		t.Fatalf("Failed to create Infrastructure: %v", err)
+	}
+	version := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{
+		Name:        clusterVersionName,
+		Annotations: map[string]string{clusterTypeLabelKey: clusterTypeManagement},
+	}}
+	if err := reconciler.Client.Create(context.TODO(), version); err != nil {
+		t.Fatalf("Failed to create ClusterVersion: %v", err)
+	}
+	profile, err = detector.profile(context.TODO(), reconciler.Client)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, clusterProfile{clusterType: config.ClusterTypeManagementCluster, source: clusterTypeSourceClusterVersion}, profile, "Expected the ClusterVersion annotation to be used")
+
+	// The detected type is cached until invalidated
+	version.Annotations = nil
+	if err := reconciler.Client.Update(context.TODO(), version); err != nil {
+		t.Fatalf("Failed to update ClusterVersion: %v", err)
+	}
+	profile, _ = detector.profile(context.TODO(), reconciler.Client)
+	assertEquals(t, clusterTypeSourceClusterVersion, profile.source, "Expected the cached cluster type")
+	detector.invalidate()
+	profile, _ = detector.profile(context.TODO(), reconciler.Client)
+	assertEquals(t, clusterProfile{clusterType: config.ClusterTypeStandard, source: clusterTypeSourceDefault}, profile, "Expected the cluster type to be detected again")
+
+	// An override takes precedence over the cache
+	settings := config.DefaultSettings()
+	settings.ClusterType = config.ClusterTypeManagementCluster
+	config.SetSettings(settings)
+	profile, err = detector.profile(context.TODO(), reconciler.Client)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, clusterProfile{clusterType: config.ClusterTypeManagementCluster, source: clusterTypeSourceOverride}, profile, "Expected the override to be used")
+}
+
+func Test_clusterProfileDetector_ClusterVersionError(t *testing.T) {
+	reconciler := createReconciler(t, nil)
+	createClusterInfrastructure(reconciler)
+	createClusterVersion(reconciler)
+	unavailable := true
+	c := interceptor.NewClient(reconciler.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*configv1.ClusterVersion); ok && unavailable {
+				return errors.NewServiceUnavailable("etcd leader changed")
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	detector := &clusterProfileDetector{}
+
+	// A ClusterVersion that can't be read doesn't make the cluster a management cluster
+	profile, err := detector.profile(context.TODO(), c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, clusterProfile{clusterType: config.ClusterTypeStandard, source: clusterTypeSourceDefault}, profile, "Expected a standard cluster")
+	if detector.cached != nil {
+		t.Fatal("Expected the cluster type not to be cached while the ClusterVersion can't be read")
+	}
+
+	// The ClusterVersion is checked again by the next detection
+	unavailable = false
+	version := &configv1.ClusterVersion{}
+	if err := reconciler.Client.Get(context.TODO(), client.ObjectKey{Name: clusterVersionName}, version); err != nil {
+		t.Fatalf("Failed to get ClusterVersion: %v", err)
+	}
+	version.Annotations = map[string]string{clusterTypeLabelKey: clusterTypeManagement}
+	if err := reconciler.Client.Update(context.TODO(), version); err != nil {
+		t.Fatalf("Failed to update ClusterVersion: %v", err)
+	}
+	profile, err = detector.profile(context.TODO(), c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertEquals(t, clusterProfile{clusterType: config.ClusterTypeManagementCluster, source: clusterTypeSourceClusterVersion}, profile, "Expected the ClusterVersion to be read again")
+}
+
+func Test_SecretReconciler_NamespaceDiscovery(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "pdkey")
+	for _, namespace := range []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "new-operator", Labels: map[string]string{managedAlertingLabelKey: "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "storage-operator", Labels: map[string]string{managedAlertingLabelKey: "true", managedAlertingTeamLabelKey: "storage"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "noisy-operator", Labels: map[string]string{managedAlertingLabelKey: "true", managedAlertingSeverityLabelKey: "bogus"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "customer-app", Labels: map[string]string{managedAlertingLabelKey: "false"}}},
+	} {
+		if err := reconciler.Client.Create(context.TODO(), namespace); err != nil {
+			t.Fatalf("Failed to create Namespace: %v", err)
+		}
+	}
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	amconfig := readAlertManagerConfig(reconciler, req)
+
+	// Without the namespace ConfigMaps the defaults are used, along with the labelled namespaces
+	routed := map[string]string{}
+	for _, route := range amconfig.Route.Routes {
+		if route.Receiver != receiverNull {
+			continue
+		}
+		for _, subroute := range route.Routes {
+			if namespace, ok := subroute.MatchRE["namespace"]; ok && subroute.Match["exported_namespace"] == "" {
+				routed[namespace] = subroute.Receiver
+			}
+			if namespace, ok := subroute.Match["namespace"]; ok && subroute.Match["prometheus"] != "" {
+				routed[namespace] = subroute.Receiver
+			}
+		}
+	}
+	assertEquals(t, receiverPagerduty, routed[defaultNamespaces[0]], "Expected the default namespaces to be routed")
+	assertEquals(t, receiverPagerduty, routed["^new-operator$"], "Expected the labelled namespace to be routed")
+	assertEquals(t, receiverPagerduty, routed["^noisy-operator$"], "Expected an unknown severity to be ignored")
+	assertEquals(t, render.ReceiverPagerDutyTeamPrefix+"storage", routed["storage-operator"], "Expected the team's namespace to be routed to its receiver")
+	if _, ok := routed["^customer-app$"]; ok {
+		t.Fatal("Expected namespaces without the label set to true not to be routed")
+	}
+	if _, ok := receiversByName(amconfig)[render.ReceiverPagerDutyTeamPrefix+"storage"]; !ok {
+		t.Fatalf("Expected a receiver for the storage team, got %v", receiverNames(amconfig))
+	}
+}
+
+func Test_SecretReconciler_NamespaceTeams(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockReadiness := readiness.NewMockInterface(mockCtrl)
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "pdkey")
+	createSecretWithData(reconciler, secretNamePDTeams, map[string]string{"storage": "storagekey", "empty": " "})
+	createConfigMap(reconciler, cmNameManagedNamespaces, cmKeyManagedNamespaces, `Resources:
+  Namespace:
+  - name: storage-csi
+    team: storage
+  - name: payments-.*
+    regex: true
+    team: payments
+  - name: openshift-logging
+    team: storage
+  - name: redhat-unowned
+`)
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	amconfig := readAlertManagerConfig(reconciler, req)
+
+	receivers := receiversByName(amconfig)
+	for _, name := range []string{
+		render.TeamReceiver("storage", receiverPagerduty),
+		render.TeamReceiver("storage", receiverMakeItWarning),
+		render.TeamReceiver("storage", receiverMakeItError),
+		render.TeamReceiver("storage", receiverMakeItCritical),
+		render.TeamReceiver("payments", receiverPagerduty),
+	} {
+		if _, ok := receivers[name]; !ok {
+			t.Fatalf("Expected a receiver %s, got %v", name, receiverNames(amconfig))
+		}
+	}
+	assertEquals(t, "storagekey", receivers[render.TeamReceiver("storage", receiverPagerduty)].PagerdutyConfigs[0].RoutingKey, "Expected the team's routing key")
+	assertEquals(t, "storage", receivers[render.TeamReceiver("storage", receiverMakeItWarning)].PagerdutyConfigs[0].Details["team"], "Expected the team in the incident details")
+	assertEquals(t, "pdkey", receivers[render.TeamReceiver("payments", receiverPagerduty)].PagerdutyConfigs[0].RoutingKey, "Expected the default routing key for a team without one")
+
+	explainer, err := routing.NewExplainer(amconfig)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the config: %v", err)
+	}
+	for _, tt := range []struct {
+		labels   map[string]string
+		receiver string
+	}{
+		{labels: map[string]string{"alertname": "PodCrashLooping", "namespace": "storage-csi"}, receiver: render.TeamReceiver("storage", receiverPagerduty)},
+		{labels: map[string]string{"alertname": "PodCrashLooping", "namespace": "payments-api"}, receiver: render.TeamReceiver("payments", receiverPagerduty)},
+		{labels: map[string]string{"alertname": "PodCrashLooping", "namespace": "redhat-unowned"}, receiver: receiverPagerduty},
+		{labels: map[string]string{"alertname": "LoggingSRE", "namespace": "openshift-logging"}, receiver: render.TeamReceiver("storage", receiverPagerduty)},
+		{labels: map[string]string{"alertname": "KubePersistentVolumeFillingUp", "namespace": "openshift-logging"}, receiver: receiverNull},
+	} {
+		tt.labels["prometheus"] = "openshift-monitoring/k8s"
+		tt.labels["severity"] = "critical"
+		if got := explainer.Receivers(tt.labels); !slices.Contains(got, tt.receiver) {
+			t.Errorf("Expected %v to be routed to %s, got %v", tt.labels, tt.receiver, got)
+		}
+	}
+}
+
+// Test_SecretReconciler_CompactRoutes verifies that enabling route compaction writes one
+// PagerDuty route per namespace label instead of one per managed namespace.
+func Test_SecretReconciler_CompactRoutes(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockReadiness := readiness.NewMockInterface(gomock.NewController(t))
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "pdkey")
+	createConfigMap(reconciler, cmNameManagedNamespaces, cmKeyManagedNamespaces, "Resources:\n  Namespace:\n  - name: dedicated-admin\n  - name: openshift-aqua")
+	createConfigMap(reconciler, cmNameOCPNamespaces, cmKeyOCPNamespaces, "Resources:\n  Namespace:\n  - name: openshift-monitoring")
+
+	compactRoutes := true
+	operatorConfig := &v1alpha1.ConfigureAlertmanager{
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ConfigureAlertmanagerName},
+		Spec:       v1alpha1.ConfigureAlertmanagerSpec{Features: v1alpha1.FeatureToggles{CompactRoutes: &compactRoutes}},
+	}
+	if err := reconciler.Client.Create(context.TODO(), operatorConfig); err != nil {
+		t.Fatalf("Failed to create ConfigureAlertmanager: %v", err)
+	}
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	amconfig := readAlertManagerConfig(reconciler, req)
+	var namespaceRoutes []*alertmanager.Route
+	for _, route := range amconfig.Route.Routes {
+		for _, subroute := range route.Routes {
+			if subroute.Receiver == receiverPagerduty && subroute.MatchRE["namespace"] != "" {
+				namespaceRoutes = append(namespaceRoutes, subroute)
+			}
+		}
+	}
+	if len(namespaceRoutes) != 1 {
+		t.Fatalf("Expected a single PagerDuty route for all managed namespaces, got %v", namespaceRoutes)
+	}
+	assertEquals(t, "^dedicated-admin$|^openshift-aqua$|^openshift-monitoring$", namespaceRoutes[0].MatchRE["namespace"], "Unexpected namespace alternation")
+}
+
+// Test_SecretReconciler_RoutingCoverage verifies that the routing coverage report routes the
+// alerts of PrometheusRules in any namespace through alertmanager-main, labelled by the
+// Prometheus evaluating them.
+func Test_SecretReconciler_RoutingCoverage(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+
+	mockReadiness := readiness.NewMockInterface(gomock.NewController(t))
+	mockReadiness.EXPECT().IsReady().Return(true, nil).AnyTimes()
+	mockReadiness.EXPECT().Result().Return(reconcile.Result{}).AnyTimes()
+	reconciler := createReconciler(t, mockReadiness)
+	createNamespace(reconciler, t)
+	createClusterVersion(reconciler)
+	createClusterProxy(reconciler)
+	createClusterInfrastructure(reconciler)
+	createSecret(reconciler, secretNamePD, secretKeyPD, "pdkey")
+
+	req := createReconcileRequest(reconciler, secretNameAlertmanager)
+	if _, err := reconciler.Reconcile(context.TODO(), *req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	platformNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "openshift-etcd", Labels: map[string]string{coverage.PlatformNamespaceLabel: "true"}}}
+	if err := reconciler.Client.Create(context.TODO(), platformNamespace); err != nil {
+		t.Fatalf("Failed to create Namespace: %v", err)
+	}
+	for _, rule := range []*monitoringv1.PrometheusRule{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: "openshift-etcd"},
+			Spec: monitoringv1.PrometheusRuleSpec{Groups: []monitoringv1.RuleGroup{{Name: "etcd", Rules: []monitoringv1.Rule{
+				{Alert: "etcdNoLeader", Labels: map[string]string{"severity": "critical"}},
+			}}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "customer", Namespace: "customer"},
+			Spec: monitoringv1.PrometheusRuleSpec{Groups: []monitoringv1.RuleGroup{{Name: "customer", Rules: []monitoringv1.Rule{
+				{Alert: "CustomerAlert", Labels: map[string]string{"severity": "critical"}},
+			}}}},
+		},
+	} {
+		if err := reconciler.Client.Create(context.TODO(), rule); err != nil {
+			t.Fatalf("Failed to create PrometheusRule: %v", err)
+		}
+	}
+
+	report, err := reconciler.reportRoutingCoverage(context.TODO(), reqLogger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	outcomes := map[string]coverage.Outcome{}
+	prometheus := map[string]string{}
+	for _, row := range report.Rows {
+		outcomes[row.Alert.Name] = row.Outcome
+		prometheus[row.Alert.Name] = row.Alert.Labels["prometheus"]
+	}
+	assertEquals(t, map[string]string{"etcdNoLeader": coverage.DefaultPrometheus, "CustomerAlert": coverage.UserWorkloadPrometheus}, prometheus, "Unexpected Prometheus evaluating the alerts")
+	assertEquals(t, map[string]coverage.Outcome{"etcdNoLeader": coverage.OutcomeNotified, "CustomerAlert": coverage.OutcomeUnpaged}, outcomes, "Unexpected outcomes")
+	if len(report.UnusedSuppressions) == 0 {
+		t.Errorf("Expected the platform silences to match none of the alerts")
+	}
+}
+
+func Test_routingCoverageDue(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		enabled  bool
+		lastRun  time.Time
+		expected bool
+	}{
+		{name: "disabled", enabled: false, expected: false},
+		{name: "enabled without a report since, on start or after the toggle", enabled: true, expected: true},
+		{name: "reported within the interval", enabled: true, lastRun: now.Add(-routingCoverageInterval + time.Minute), expected: false},
+		{name: "reported an interval ago", enabled: true, lastRun: now.Add(-routingCoverageInterval), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertEquals(t, tt.expected, routingCoverageDue(tt.enabled, tt.lastRun, now), "Unexpected routing coverage schedule")
+		})
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Secret mapping each team that owns namespaces to the routing key of its PagerDuty service
+	secretNamePDTeams = "pd-team-secrets" // #nosec G101
+)
+
+// readTeamRoutingKeys returns the routing key of each team's PagerDuty service from the
+// pd-team-secrets Secret, keyed by team. Empty keys are left out.
+func (r *SecretReconciler) readTeamRoutingKeys(reqLogger logr.Logger, secretList *corev1.SecretList, namespace string) map[string]string {
+	if !secretInList(reqLogger, secretNamePDTeams, secretList) {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: secretNamePDTeams}, secret); err != nil {
+		reqLogger.Error(err, "Unable to read team PagerDuty routing keys", "Secret", secretNamePDTeams)
+		return nil
+	}
+	keys := map[string]string{}
+	for team, key := range secret.Data {
+		if routingKey := strings.TrimSpace(string(key)); routingKey != "" {
+			keys[team] = routingKey
+		}
+	}
+	return keys
+}
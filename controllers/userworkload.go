@@ -0,0 +1,138 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+	"github.com/openshift/configure-alertmanager-operator/pkg/routing"
+)
+
+// reconcileUserWorkload renders the user-workload Alertmanager config from the pd-secret,
+// goalert-secret and managed-namespaces ConfigMap in the user-workload monitoring namespace,
+// and writes it to alertmanager-user-workload with the same validation and drift policy as
+// alertmanager-main. The outcome is reported in the userWorkload status of the
+// ConfigureAlertmanager singleton.
+func (r *SecretReconciler) reconcileUserWorkload(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	target := userWorkloadTarget()
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	switch request.Name {
+	case secretNameGoalert:
+	case secretNamePD:
+	case secretNameUserWorkloadAlertmanager:
+	case cmNameManagedNamespaces:
+	default:
+		reqLogger.Info("Skip reconcile: No changes detected to user-workload alertmanager secrets.")
+		return reconcile.Result{}, nil
+	}
+	reqLogger.Info("DEBUG: Started user-workload reconcile loop")
+
+	// User workloads don't wait for cluster readiness: there are none to alert on while
+	// the cluster is installing.
+	operatorConfig, err := getOperatorConfig(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "Unable to read ConfigureAlertmanager")
+	}
+	outcome := &reconcileOutcome{operatorConfig: operatorConfig, clusterReady: true}
+	defer r.updateUserWorkloadStatus(ctx, reqLogger, outcome)
+
+	opts := []client.ListOption{
+		client.InNamespace(target.namespace),
+	}
+	secretList := &corev1.SecretList{}
+	if err := r.Client.List(ctx, secretList, opts...); err != nil {
+		reqLogger.Error(err, "Unable to list secrets")
+		outcome.err = fmt.Errorf("unable to list secrets: %w", err)
+		return reconcile.Result{}, outcome.err
+	}
+	cmList := &corev1.ConfigMapList{}
+	if err := r.Client.List(ctx, cmList, opts...); err != nil {
+		reqLogger.Error(err, "Unable to list configMaps")
+		outcome.err = fmt.Errorf("unable to list configMaps: %w", err)
+		return reconcile.Result{}, outcome.err
+	}
+
+	// Only PagerDuty and GoAlert high/low are used.
+	integrations := []render.Integration{}
+	for _, integration := range []Integration{pagerdutyIntegration{}, goalertIntegration{}} {
+		if configured := integration.Parse(r, reqLogger, target.namespace, secretList, cmList); configured != nil {
+			integrations = append(integrations, configured)
+		}
+	}
+	namespaceList, _ := r.parseNamespaceConfigMap(reqLogger, cmNameManagedNamespaces, target.namespace, cmKeyManagedNamespaces, cmList)
+	reqLogger.Info("DEBUG: Adding user-workload routes for the following namespaces", "Namespaces", namespaceList)
+
+	clusterProxy, err := r.getClusterProxy()
+	if err != nil {
+		reqLogger.Error(err, "Unable to get cluster proxy")
+	}
+	clusterID, err := r.getClusterID()
+	if err != nil {
+		reqLogger.Error(err, "Error reading cluster id.")
+	}
+	clusterRegion, err := r.getClusterRegion(ctx)
+	if err != nil {
+		reqLogger.Error(err, "Error reading cluster region.")
+	}
+
+	amconfig, warnings, err := render.RenderUserWorkload(render.Inputs{
+		Cluster:      render.Cluster{ID: clusterID, Region: clusterRegion, Proxy: clusterProxy},
+		Profile:      render.Profile{FedRAMP: config.IsFedramp()},
+		Integrations: integrations,
+		Namespaces:   render.Namespaces{Managed: namespaceList},
+	})
+	logRenderWarnings(reqLogger, warnings)
+	if err != nil {
+		reqLogger.Error(err, "Failed to render user-workload alertmanager config")
+		outcome.err = fmt.Errorf("unable to render user-workload alertmanager config: %w", err)
+		return reconcile.Result{}, err
+	}
+	if config.GetSettings().CompactRoutes {
+		amconfig.Route = routing.Compact(amconfig.Route)
+	}
+	outcome.integrations = integrationsInConfig(amconfig)
+	outcome.renderedHash = configHash(amconfig)
+
+	if reason, message := r.checkManualEdits(ctx, reqLogger, target, amconfig); reason != "" {
+		outcome.writeSkipReason = reason
+		outcome.writeSkipMessage = message
+		return reconcile.Result{}, nil
+	}
+	outcome.writeAttempted = true
+	if err := writeAlertManagerConfig(ctx, r, reqLogger, target, amconfig); err != nil {
+		reqLogger.Error(err, "Failed to write user-workload alertmanager config")
+		if stderrors.Is(err, errConfigInvalid) {
+			outcome.validationErr = err
+		}
+		outcome.err = err
+		return reconcile.Result{}, err
+	}
+	outcome.written = true
+
+	reqLogger.Info("Finished user-workload reconcile.")
+	return reconcile.Result{}, nil
+}
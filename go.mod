@@ -24,6 +24,7 @@ require (
 require (
 	github.com/openshift/api v0.0.0-20260615110019-261e3a0546f3
 	github.com/prometheus/alertmanager v0.33.1
+	k8s.io/apiextensions-apiserver v0.36.2
 )
 
 require (
@@ -148,7 +149,6 @@ require (
 	google.golang.org/protobuf v1.36.12-0.20260120151049-f2248ac996af // indirect
 	gopkg.in/evanphx/json-patch.v4 v4.13.0 // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
-	k8s.io/apiextensions-apiserver v0.36.2 // indirect
 	k8s.io/klog/v2 v2.140.0 // indirect
 	k8s.io/kube-openapi v0.0.0-20260706235625-cdb1db5517a0 // indirect
 	k8s.io/streaming v0.36.2 // indirect
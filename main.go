@@ -29,12 +29,15 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/configure-alertmanager-operator/api/v1alpha1"
 	operatorconfig "github.com/openshift/configure-alertmanager-operator/config"
 	operatormetrics "github.com/openshift/configure-alertmanager-operator/pkg/metrics"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	monitoringv1beta1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1beta1"
 
 	"github.com/openshift/configure-alertmanager-operator/controllers"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -67,6 +70,8 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(configv1.Install(scheme))
 	utilruntime.Must(monitoringv1.AddToScheme(scheme))
+	utilruntime.Must(monitoringv1beta1.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -85,7 +90,74 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// The managed namespace scopes the cache, so it has to be known before the manager
+	// is created. Read it from the ConfigureAlertmanager resource with an uncached client.
+	restConfig := ctrl.GetConfigOrDie()
+	directClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+	managedNamespace, err := controllers.LoadManagedNamespace(context.TODO(), directClient)
+	if err != nil {
+		setupLog.Error(err, "unable to read ConfigureAlertmanager, using default managed namespace")
+	}
+	operatorconfig.SetManagedNamespace(managedNamespace)
+	setupLog.Info("managing alertmanager config", "namespace", managedNamespace)
+	userWorkloadEnabled, err := controllers.LoadUserWorkloadEnabled(context.TODO(), directClient)
+	if err != nil {
+		setupLog.Error(err, "unable to read ConfigureAlertmanager, not managing the user-workload alertmanager config")
+	}
+	operatorconfig.SetUserWorkloadEnabled(userWorkloadEnabled)
+	if userWorkloadEnabled {
+		setupLog.Info("managing user-workload alertmanager config", "namespace", operatorconfig.UserWorkloadNamespace)
+	}
+
+	alertmanagerConfigNamespaces, err := controllers.LoadAlertmanagerConfigNamespaces(context.TODO(), directClient)
+	if err != nil {
+		setupLog.Error(err, "unable to read ConfigureAlertmanager, not aggregating AlertmanagerConfigs")
+	}
+	operatorconfig.SetAlertmanagerConfigNamespaces(alertmanagerConfigNamespaces)
+
+	// Explicitly cache cluster-scoped resources the operator accesses
+	cachedObjects := map[client.Object]cache.ByObject{
+		// ClusterVersion: watched + accessed via Get() for cluster ID and management cluster detection
+		&configv1.ClusterVersion{}: {},
+		// ClusterOperator: watched + listed to decide cluster readiness
+		&configv1.ClusterOperator{}: {},
+		// Proxy: accessed via Get() for HTTPS proxy settings (not watched)
+		&configv1.Proxy{}: {},
+		// Infrastructure: watched + accessed via Get() for management cluster detection
+		&configv1.Infrastructure{}: {},
+		// Namespace: metadata only, watched + listed to discover labelled namespaces and
+		// HostedControlPlane namespaces on management clusters
+		&metav1.PartialObjectMetadata{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}}: {},
+		// ConfigureAlertmanager: watched + accessed via Get() for operator settings
+		&v1alpha1.ConfigureAlertmanager{}: {},
+	}
+	if len(alertmanagerConfigNamespaces) > 0 {
+		// AlertmanagerConfig: watched + listed only in the namespaces they are selected from.
+		// Secrets they reference are read uncached.
+		amcNamespaces := map[string]cache.Config{}
+		for _, namespace := range alertmanagerConfigNamespaces {
+			amcNamespaces[namespace] = cache.Config{}
+		}
+		cachedObjects[&monitoringv1beta1.AlertmanagerConfig{}] = cache.ByObject{Namespaces: amcNamespaces}
+		setupLog.Info("aggregating AlertmanagerConfigs", "namespaces", alertmanagerConfigNamespaces)
+	}
+
+	// The operator namespace is always cached: readiness reads the service CA from it.
+	cachedNamespaces := map[string]cache.Config{
+		operatorconfig.OperatorNamespace: {}, // "openshift-monitoring"
+	}
+	if managedNamespace != operatorconfig.OperatorNamespace {
+		cachedNamespaces[managedNamespace] = cache.Config{}
+	}
+	if userWorkloadEnabled {
+		cachedNamespaces[operatorconfig.UserWorkloadNamespace] = cache.Config{}
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme: scheme,
 		//MetricsBindAddress:     fmt.Sprintf("%s:%d", metricsHost, metricsPort),
 		Metrics: metricsserver.Options{BindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort)},
@@ -99,19 +171,10 @@ func main() {
 		// by avoiding caching of all cluster-wide secrets/configmaps.
 		// See NAMESPACE_SCOPING_SAFETY_ANALYSIS.md for detailed analysis.
 		Cache: cache.Options{
-			// Only cache namespaced resources (Secrets, ConfigMaps) from openshift-monitoring
-			DefaultNamespaces: map[string]cache.Config{
-				operatorconfig.OperatorNamespace: {}, // "openshift-monitoring"
-			},
-			// Explicitly cache cluster-scoped resources the operator accesses
-			ByObject: map[client.Object]cache.ByObject{
-				// ClusterVersion: watched + accessed via Get() for cluster ID
-				&configv1.ClusterVersion{}: {},
-				// Proxy: accessed via Get() for HTTPS proxy settings (not watched)
-				&configv1.Proxy{}: {},
-				// Infrastructure: accessed via Get() for management cluster detection (not watched)
-				&configv1.Infrastructure{}: {},
-			},
+			// Only cache namespaced resources (Secrets, ConfigMaps) from openshift-monitoring,
+			// the managed namespace, if different, and openshift-user-workload-monitoring, if enabled
+			DefaultNamespaces: cachedNamespaces,
+			ByObject:          cachedObjects,
 		},
 	})
 	if err != nil {
@@ -121,8 +184,8 @@ func main() {
 
 	// Print configuration info
 	printVersion()
-	if err := operatorconfig.SetIsFedramp(); err != nil {
-		setupLog.Error(err, "failed to get fedramp value")
+	if err := operatorconfig.LoadDefaults(); err != nil {
+		setupLog.Error(err, "failed to load operator settings")
 		os.Exit(1)
 	}
 	if operatorconfig.IsFedramp() {
@@ -151,8 +214,10 @@ func main() {
 	}
 
 	if err = (&controllers.SecretReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorder(operatorconfig.OperatorName),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Secret")
 		os.Exit(1)
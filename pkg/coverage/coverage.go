@@ -0,0 +1,189 @@
+// Package coverage reports where the alerts of PrometheusRules are routed by an Alertmanager
+// config, and which suppression routes no longer match any alert.
+package coverage
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+	"github.com/openshift/configure-alertmanager-operator/pkg/routing"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Outcome summarises where an alert is routed.
+type Outcome string
+
+const (
+	// OutcomeNotified alerts are sent to a receiver with their own severity.
+	OutcomeNotified Outcome = "Notified"
+	// OutcomeEscalated alerts are sent with a higher severity than their own.
+	OutcomeEscalated Outcome = "Escalated"
+	// OutcomeDowngraded alerts are sent with a lower severity than their own.
+	OutcomeDowngraded Outcome = "Downgraded"
+	// OutcomeSilenced alerts are routed to the null receiver by a route matching them.
+	OutcomeSilenced Outcome = "Silenced"
+	// OutcomeUnpaged alerts match no route and fall to the default null receiver, as intended
+	// for alerts that don't page: those with a severity that doesn't page, or from namespaces
+	// the config doesn't page for, such as those of customer workloads.
+	OutcomeUnpaged Outcome = "Unpaged"
+	// OutcomeUnrouted alerts could page, but match no route and fall to the default null
+	// receiver: they have a paging severity and are from a namespace the config pages for.
+	OutcomeUnrouted Outcome = "Unrouted"
+)
+
+// Outcomes are all outcomes, in the order they are reported.
+var Outcomes = []Outcome{OutcomeNotified, OutcomeEscalated, OutcomeDowngraded, OutcomeSilenced, OutcomeUnpaged, OutcomeUnrouted}
+
+// Row is where an alert is routed.
+type Row struct {
+	Alert     Alert
+	Outcome   Outcome
+	Receivers []string
+}
+
+// Report is where each alert is routed by a config.
+type Report struct {
+	Rows []Row
+	// UnusedSuppressions are the routes to the null receiver that match none of the alerts,
+	// identified by the matchers on the path to them from the root.
+	UnusedSuppressions []string
+}
+
+// NewReport routes the alerts through cfg.
+func NewReport(cfg *alertmanager.Config, alerts []Alert) (*Report, error) {
+	explainer, err := routing.NewExplainer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	used := map[string]bool{}
+	for _, alert := range alerts {
+		matches := explainer.Explain(alert.Labels)
+		severity := alert.Labels["severity"]
+		row := Row{Alert: alert, Outcome: outcome(severity, canPage(explainer, severity, alert.Labels["namespace"]), matches)}
+		for _, match := range matches {
+			row.Receivers = append(row.Receivers, match.Receiver)
+			if match.Receiver == render.ReceiverNull && !match.Default {
+				used[match.Route] = true
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	for _, leaf := range explainer.Leaves() {
+		if leaf.Receiver == render.ReceiverNull && !used[leaf.Route] && !slices.Contains(report.UnusedSuppressions, leaf.Route) {
+			report.UnusedSuppressions = append(report.UnusedSuppressions, leaf.Route)
+		}
+	}
+	return report, nil
+}
+
+// canPage is true for alerts with a paging severity, from a namespace the config has routes
+// to a receiver other than null for.
+func canPage(explainer *routing.Explainer, severity, namespace string) bool {
+	if !slices.Contains(render.PagingSeverities, severity) {
+		return false
+	}
+	for _, receiver := range explainer.NamespaceReceivers(namespace) {
+		if receiver != render.ReceiverNull {
+			return true
+		}
+	}
+	return false
+}
+
+// outcome decides the outcome of an alert with the given severity routed to matches. Alerts
+// falling to the default null receiver are unrouted if they can page, and unpaged otherwise.
+func outcome(severity string, pages bool, matches []routing.Match) Outcome {
+	fallThrough := OutcomeUnpaged
+	if pages {
+		fallThrough = OutcomeUnrouted
+	}
+	result := fallThrough
+	for _, match := range matches {
+		if match.Receiver == render.ReceiverNull {
+			if !match.Default && result == fallThrough {
+				result = OutcomeSilenced
+			}
+			continue
+		}
+		switch sent := sentSeverity(match.Receiver, severity); {
+		case severityRank(sent) < severityRank(severity):
+			return OutcomeEscalated
+		case severityRank(sent) > severityRank(severity):
+			return OutcomeDowngraded
+		}
+		result = OutcomeNotified
+	}
+	return result
+}
+
+// sentSeverity returns the severity an alert is sent to receiver with, which is its own
+// unless the receiver overrides it.
+func sentSeverity(receiver, severity string) string {
+	for _, makeIt := range []string{render.ReceiverMakeItCritical, render.ReceiverMakeItError, render.ReceiverMakeItWarning} {
+		if receiver == makeIt || strings.HasSuffix(receiver, "-"+makeIt) {
+			return strings.TrimPrefix(makeIt, "make-it-")
+		}
+	}
+	return severity
+}
+
+// severityRank orders severities from the highest. Severities that don't page rank lowest.
+func severityRank(severity string) int {
+	if rank := slices.Index(render.PagingSeverities, severity); rank >= 0 {
+		return rank
+	}
+	return len(render.PagingSeverities)
+}
+
+// Counts returns the number of alerts with each outcome.
+func (r *Report) Counts() map[Outcome]int {
+	counts := map[Outcome]int{}
+	for _, row := range r.Rows {
+		counts[row.Outcome]++
+	}
+	return counts
+}
+
+// WriteTable writes the report as a table of alerts, followed by the number of alerts with
+// each outcome, the labels the alerts were assumed to have, the unrouted alerts and the
+// unused suppressions.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ALERT\tNAMESPACE\tSEVERITY\tOUTCOME\tRECEIVERS")
+	for _, row := range r.Rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", row.Alert.Name, row.Alert.Namespace, row.Alert.Labels["severity"], row.Outcome, strings.Join(row.Receivers, ","))
+	}
+
+	counts := r.Counts()
+	summary := make([]string, 0, len(Outcomes))
+	for _, outcome := range Outcomes {
+		summary = append(summary, fmt.Sprintf("%s: %d", outcome, counts[outcome]))
+	}
+	fmt.Fprintf(tw, "\n%s\n", strings.Join(summary, ", "))
+	fmt.Fprintln(tw, "\nAlerts only have the static labels of their rule. Labels from the series of the rule's expression,")
+	fmt.Fprintln(tw, "such as the namespace of platform alerts, aren't known: platform alerts are assumed to be in the")
+	fmt.Fprintln(tw, "namespace of their PrometheusRule unless the rule sets one.")
+
+	if counts[OutcomeUnrouted] > 0 {
+		fmt.Fprintln(tw, "\nAlerts that could page falling to the default null receiver without a route silencing them:")
+		for _, row := range r.Rows {
+			if row.Outcome == OutcomeUnrouted {
+				fmt.Fprintf(tw, "  %s/%s\n", row.Alert.Namespace, row.Alert.Name)
+			}
+		}
+	}
+	if len(r.UnusedSuppressions) > 0 {
+		fmt.Fprintln(tw, "\nRoutes to the null receiver matching no alert:")
+		for _, route := range r.UnusedSuppressions {
+			fmt.Fprintf(tw, "  %s\n", route)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,256 @@
+package coverage
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+func coverageTestConfig() *alertmanager.Config {
+	return &alertmanager.Config{
+		Route: &alertmanager.Route{
+			Receiver: "null",
+			Routes: []*alertmanager.Route{
+				{Receiver: "null", Match: map[string]string{"alertname": "KubeQuotaExceeded"}},
+				{Receiver: "null", Match: map[string]string{"alertname": "RemovedAlert"}},
+				{Receiver: "make-it-critical", Match: map[string]string{"alertname": "EscalatedAlert"}},
+				{Receiver: "pagerduty-team-a-make-it-warning", Match: map[string]string{"alertname": "DowngradedAlert"}},
+				{
+					Receiver: "null",
+					Match:    map[string]string{"prometheus": "openshift-monitoring/k8s"},
+					Routes: []*alertmanager.Route{
+						{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^openshift-.*"}},
+					},
+				},
+			},
+		},
+		Receivers: []*alertmanager.Receiver{
+			{Name: "null"}, {Name: "pagerduty"}, {Name: "make-it-critical"}, {Name: "pagerduty-team-a-make-it-warning"},
+		},
+	}
+}
+
+func prometheusRule(namespace string, rules ...monitoringv1.Rule) monitoringv1.PrometheusRule {
+	return monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "rules", Namespace: namespace},
+		Spec:       monitoringv1.PrometheusRuleSpec{Groups: []monitoringv1.RuleGroup{{Name: "group", Rules: rules}}},
+	}
+}
+
+// platformNamespace is true for the namespaces of the platform Prometheus in these tests.
+func platformNamespace(namespace string) bool {
+	return strings.HasPrefix(namespace, "openshift-")
+}
+
+func alertingRule(name, severity string, labels ...string) monitoringv1.Rule {
+	rule := monitoringv1.Rule{Alert: name, Labels: map[string]string{"severity": severity}}
+	for i := 0; i+1 < len(labels); i += 2 {
+		rule.Labels[labels[i]] = labels[i+1]
+	}
+	return rule
+}
+
+func TestAlertsFromPrometheusRules(t *testing.T) {
+	rules := []monitoringv1.PrometheusRule{
+		prometheusRule("openshift-monitoring",
+			monitoringv1.Rule{Record: "job:up:sum"},
+			alertingRule("Watchdog", "none"),
+			alertingRule("Exported", "warning", "namespace", "openshift-etcd", "prometheus", "openshift-user-workload-monitoring/user-workload"),
+		),
+		prometheusRule("customer", alertingRule("CustomerAlert", "critical", "namespace", "other")),
+	}
+	expected := []Alert{
+		{Name: "Watchdog", Namespace: "openshift-monitoring", Labels: map[string]string{
+			"alertname": "Watchdog", "severity": "none", "namespace": "openshift-monitoring", "prometheus": "openshift-monitoring/k8s",
+		}},
+		{Name: "Exported", Namespace: "openshift-monitoring", Labels: map[string]string{
+			"alertname": "Exported", "severity": "warning", "namespace": "openshift-etcd", "prometheus": "openshift-user-workload-monitoring/user-workload",
+		}},
+		{Name: "CustomerAlert", Namespace: "customer", Labels: map[string]string{
+			"alertname": "CustomerAlert", "severity": "critical", "namespace": "customer", "prometheus": "openshift-user-workload-monitoring/user-workload",
+		}},
+	}
+
+	if got := AlertsFromPrometheusRules(rules, platformNamespace); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      monitoringv1.PrometheusRule
+		outcome   Outcome
+		receivers []string
+	}{
+		{
+			name:      "alerts routed to a notifying receiver are notified",
+			rule:      prometheusRule("openshift-etcd", alertingRule("EtcdDown", "critical")),
+			outcome:   OutcomeNotified,
+			receivers: []string{"pagerduty"},
+		},
+		{
+			name:      "alerts sent with a higher severity are escalated",
+			rule:      prometheusRule("openshift-etcd", alertingRule("EscalatedAlert", "warning")),
+			outcome:   OutcomeEscalated,
+			receivers: []string{"make-it-critical"},
+		},
+		{
+			name:      "alerts sent with a lower severity by a team receiver are downgraded",
+			rule:      prometheusRule("openshift-etcd", alertingRule("DowngradedAlert", "critical")),
+			outcome:   OutcomeDowngraded,
+			receivers: []string{"pagerduty-team-a-make-it-warning"},
+		},
+		{
+			name:      "alerts routed to null by a route matching them are silenced",
+			rule:      prometheusRule("openshift-etcd", alertingRule("KubeQuotaExceeded", "warning")),
+			outcome:   OutcomeSilenced,
+			receivers: []string{"null"},
+		},
+		{
+			name:      "paging alerts from a paged namespace matching no route are unrouted",
+			rule:      prometheusRule("openshift-etcd", alertingRule("UnroutedAlert", "critical", "prometheus", "other")),
+			outcome:   OutcomeUnrouted,
+			receivers: []string{"null"},
+		},
+		{
+			name:      "alerts from a paged namespace with a severity that doesn't page are unpaged",
+			rule:      prometheusRule("openshift-etcd", alertingRule("InfoAlert", "info", "prometheus", "other")),
+			outcome:   OutcomeUnpaged,
+			receivers: []string{"null"},
+		},
+		{
+			name:      "alerts from a namespace that isn't paged are unpaged",
+			rule:      prometheusRule("customer", alertingRule("CustomerAlert", "critical")),
+			outcome:   OutcomeUnpaged,
+			receivers: []string{"null"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewReport(coverageTestConfig(), AlertsFromPrometheusRules([]monitoringv1.PrometheusRule{tt.rule}, platformNamespace))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Rows) != 1 {
+				t.Fatalf("Expected one row, got %v", report.Rows)
+			}
+			if row := report.Rows[0]; row.Outcome != tt.outcome || !reflect.DeepEqual(row.Receivers, tt.receivers) {
+				t.Errorf("Expected %s via %v, got %s via %v", tt.outcome, tt.receivers, row.Outcome, row.Receivers)
+			}
+		})
+	}
+}
+
+func TestNewReport_UnusedSuppressions(t *testing.T) {
+	alerts := AlertsFromPrometheusRules([]monitoringv1.PrometheusRule{
+		prometheusRule("openshift-etcd", alertingRule("KubeQuotaExceeded", "warning")),
+	}, platformNamespace)
+	report, err := NewReport(coverageTestConfig(), alerts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{`{}/{alertname="RemovedAlert"}`}; !reflect.DeepEqual(report.UnusedSuppressions, expected) {
+		t.Errorf("Expected unused suppressions %v, got %v", expected, report.UnusedSuppressions)
+	}
+}
+
+func TestReport_WriteTable(t *testing.T) {
+	alerts := AlertsFromPrometheusRules([]monitoringv1.PrometheusRule{
+		prometheusRule("openshift-etcd", alertingRule("EtcdDown", "critical")),
+		prometheusRule("openshift-etcd", alertingRule("UnroutedAlert", "critical", "prometheus", "other")),
+		prometheusRule("customer", alertingRule("CustomerAlert", "critical")),
+	}, platformNamespace)
+	report, err := NewReport(coverageTestConfig(), alerts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteTable(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"EtcdDown       openshift-etcd  critical  Notified  pagerduty",
+		"Notified: 1, Escalated: 0, Downgraded: 0, Silenced: 0, Unpaged: 1, Unrouted: 1",
+		"platform alerts are assumed to be in the",
+		"  openshift-etcd/UnroutedAlert",
+		`  {}/{alertname="KubeQuotaExceeded"}`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected the table to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "  customer/CustomerAlert") {
+		t.Errorf("Expected unpaged alerts not to be listed as unrouted, got:\n%s", out.String())
+	}
+}
+
+func TestReadPrometheusRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "a list, as output by oc get -o yaml",
+			input: `apiVersion: v1
+kind: List
+items:
+- apiVersion: monitoring.coreos.com/v1
+  kind: PrometheusRule
+  metadata: {name: a, namespace: openshift-etcd}
+- apiVersion: monitoring.coreos.com/v1
+  kind: PrometheusRule
+  metadata: {name: b, namespace: openshift-monitoring}
+`,
+			expected: []string{"openshift-etcd/a", "openshift-monitoring/b"},
+		},
+		{
+			name: "multiple documents, skipping other kinds and empty documents",
+			input: `apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata: {name: a, namespace: openshift-etcd}
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: c, namespace: openshift-etcd}
+---
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata: {name: b, namespace: openshift-monitoring}
+`,
+			expected: []string{"openshift-etcd/a", "openshift-monitoring/b"},
+		},
+		{
+			name:     "JSON",
+			input:    `{"apiVersion": "monitoring.coreos.com/v1", "kind": "PrometheusRule", "metadata": {"name": "a", "namespace": "openshift-etcd"}}`,
+			expected: []string{"openshift-etcd/a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ReadPrometheusRules(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, rule := range rules {
+				got = append(got, rule.Namespace+"/"+rule.Name)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
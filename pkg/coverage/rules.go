@@ -0,0 +1,119 @@
+package coverage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// DefaultPrometheus is the prometheus label of alerts from the platform Prometheus.
+const DefaultPrometheus = "openshift-monitoring/k8s"
+
+// UserWorkloadPrometheus is the prometheus label of alerts from the user-workload Prometheus.
+const UserWorkloadPrometheus = "openshift-user-workload-monitoring/user-workload"
+
+// PlatformNamespaceLabel is set to "true" on the namespaces whose PrometheusRules the platform
+// Prometheus evaluates. The PrometheusRules of other namespaces are user workloads.
+const PlatformNamespaceLabel = "openshift.io/cluster-monitoring"
+
+// Alert is an alerting rule of a PrometheusRule, with the labels its alerts are known to have.
+type Alert struct {
+	Name string
+	// Namespace is the namespace of the PrometheusRule
+	Namespace string
+	// Labels are the static labels of the rule, with alertname, namespace and prometheus
+	// added as the Prometheus evaluating the rule would. Labels taken from the series of the
+	// rule's expression aren't known.
+	Labels map[string]string
+}
+
+// AlertsFromPrometheusRules returns the alerting rules of the PrometheusRules, in order.
+// Recording rules are skipped. The rules of the namespaces platform is true for are evaluated
+// by the platform Prometheus, whose alerts take their namespace from their series; those are
+// assumed to be in the namespace of their PrometheusRule unless the rule sets one. The other
+// rules are evaluated by the user-workload Prometheus, which sets the namespace of their
+// PrometheusRule on their alerts.
+func AlertsFromPrometheusRules(rules []monitoringv1.PrometheusRule, platform func(namespace string) bool) []Alert {
+	var alerts []Alert
+	for _, rule := range rules {
+		isPlatform := platform(rule.Namespace)
+		for _, group := range rule.Spec.Groups {
+			for _, r := range group.Rules {
+				if r.Alert == "" {
+					continue
+				}
+				var labels map[string]string
+				if isPlatform {
+					labels = map[string]string{"namespace": rule.Namespace, "prometheus": DefaultPrometheus}
+					maps.Copy(labels, r.Labels)
+				} else {
+					labels = map[string]string{"prometheus": UserWorkloadPrometheus}
+					maps.Copy(labels, r.Labels)
+					labels["namespace"] = rule.Namespace
+				}
+				labels["alertname"] = r.Alert
+				alerts = append(alerts, Alert{Name: r.Alert, Namespace: rule.Namespace, Labels: labels})
+			}
+		}
+	}
+	return alerts
+}
+
+// ReadPrometheusRules reads the PrometheusRules in YAML or JSON documents, such as the output
+// of `oc get prometheusrules -A -o yaml`. Lists are flattened and other kinds are skipped.
+func ReadPrometheusRules(r io.Reader) ([]monitoringv1.PrometheusRule, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	var rules []monitoringv1.PrometheusRule
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return rules, nil
+			}
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+		docRules, err := decodePrometheusRules(doc)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, docRules...)
+	}
+}
+
+func decodePrometheusRules(doc json.RawMessage) ([]monitoringv1.PrometheusRule, error) {
+	if len(doc) == 0 || string(doc) == "null" {
+		return nil, nil
+	}
+	var object struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(doc, &object); err != nil {
+		return nil, fmt.Errorf("failed to decode object: %w", err)
+	}
+
+	switch object.Kind {
+	case "PrometheusRule":
+		var rule monitoringv1.PrometheusRule
+		if err := json.Unmarshal(doc, &rule); err != nil {
+			return nil, fmt.Errorf("failed to decode PrometheusRule: %w", err)
+		}
+		return []monitoringv1.PrometheusRule{rule}, nil
+	case "List", "PrometheusRuleList":
+		var rules []monitoringv1.PrometheusRule
+		for _, item := range object.Items {
+			itemRules, err := decodePrometheusRules(item)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, itemRules...)
+		}
+		return rules, nil
+	}
+	return nil, nil
+}
@@ -16,9 +16,9 @@ package metrics
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/openshift/configure-alertmanager-operator/config"
-	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	corev1 "k8s.io/api/core/v1"
@@ -70,6 +70,76 @@ var (
 		Name: "alertmanager_config_validation_failed",
 		Help: "Alertmanager config validation failed (1=failed, 0=succeeded)",
 	}, []string{"name"})
+	metricClusterReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_cluster_ready",
+		Help: "Cluster is considered ready for paging integrations (1=ready, 0=not ready)",
+	}, []string{"name"})
+	metricClusterReadyReason = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_cluster_ready_reason",
+		Help: "Reason for the most recent cluster readiness decision (1 for the current reason)",
+	}, []string{"name", "reason"})
+	metricClusterTimeToReady = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "camo_cluster_time_to_ready_seconds",
+		Help:    "Time between cluster creation and the cluster being declared ready",
+		Buckets: []float64{300, 600, 1200, 1800, 2700, 3600, 5400, 7200, 10800, 14400},
+	}, []string{"name"})
+	metricClusterBlockingOperators = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_cluster_blocking_operators",
+		Help: "Number of ClusterOperators that are Progressing, Degraded or not Available",
+	}, []string{"name"})
+	metricSettlingWindowActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_settling_window_active",
+		Help: "Paging is withheld while the cluster settles after resuming (1=active, 0=inactive)",
+	}, []string{"name"})
+	metricSettlingWindowsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "camo_settling_windows_total",
+		Help: "Number of settling windows entered after the cluster resumed",
+	}, []string{"name"})
+
+	metricConfigDrifted = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_alertmanager_config_drifted",
+		Help: "alertmanager-main holds manual edits that were preserved by the drift policy (1=drifted, 0=as written)",
+	}, []string{"name"})
+	metricConfigDriftDetectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "camo_alertmanager_config_drift_detected_total",
+		Help: "Number of manual edits to alertmanager-main detected, by the drift policy applied",
+	}, []string{"name", "policy"})
+	metricConfigManagementPaused = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_alertmanager_config_management_paused",
+		Help: "Management of alertmanager-main is paused by annotation (1=paused, 0=managed)",
+	}, []string{"name"})
+	metricAlertmanagerOverridesRejected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_alertmanager_overrides_rejected",
+		Help: "The alertmanager-overrides ConfigMap or Secret was rejected and not merged (1=rejected, 0=merged or absent)",
+	}, []string{"name"})
+	metricAlertmanagerConfigs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_alertmanagerconfigs",
+		Help: "Number of selected AlertmanagerConfig resources, by whether they were aggregated or rejected",
+	}, []string{"name", "state"})
+	metricUserWorkloadConfigValidationFailed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_user_workload_alertmanager_config_validation_failed",
+		Help: "The user-workload Alertmanager config failed validation or could not be written (1=failed, 0=succeeded)",
+	}, []string{"name"})
+	metricClusterType = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_cluster_type",
+		Help: "Cluster type in effect and how it was determined (1 for the current type)",
+	}, []string{"name", "type", "source"})
+	metricNamespaceEntriesRejected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_namespace_entries_rejected",
+		Help: "Number of invalid entries skipped in a namespace list of a *-namespaces ConfigMap",
+	}, []string{"name", "configmap", "list"})
+	metricNamespaceSource = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_namespace_source",
+		Help: "Number of namespace expressions routed from each namespace source, by how the source was used",
+	}, []string{"name", "source", "state"})
+	metricRoutingCoverageAlerts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_routing_coverage_alerts",
+		Help: "Number of PrometheusRule alerts by where alertmanager-main routes them, from the last routing coverage report",
+	}, []string{"name", "outcome"})
+	metricRoutingCoverageUnusedSuppressions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "camo_routing_coverage_unused_suppressions",
+		Help: "Number of routes to the null receiver matching no PrometheusRule alert, from the last routing coverage report",
+	}, []string{"name"})
 
 	metricsList = []prometheus.Collector{
 		metricGASecretExists,
@@ -82,6 +152,23 @@ var (
 		metricManNSConfigMapExists,
 		metricOcpNSConfigMapExists,
 		metricAlertmanagerConfigValidationFailed,
+		metricClusterReady,
+		metricClusterReadyReason,
+		metricClusterTimeToReady,
+		metricClusterBlockingOperators,
+		metricSettlingWindowActive,
+		metricSettlingWindowsTotal,
+		metricConfigDrifted,
+		metricConfigDriftDetectedTotal,
+		metricConfigManagementPaused,
+		metricAlertmanagerOverridesRejected,
+		metricAlertmanagerConfigs,
+		metricUserWorkloadConfigValidationFailed,
+		metricClusterType,
+		metricNamespaceEntriesRejected,
+		metricNamespaceSource,
+		metricRoutingCoverageAlerts,
+		metricRoutingCoverageUnusedSuppressions,
 	}
 )
 
@@ -110,93 +197,38 @@ func RegisterMetrics() error {
 	return nil
 }
 
-// UpdateSecretsMetrics updates all metrics related to the existence and contents of Secrets
-// used by configure-alertmanager-operator.
-func UpdateSecretsMetrics(list *corev1.SecretList, amconfig *alertmanager.Config) {
+// UpdateAlertmanagerSecretMetric records whether the alertmanager-main Secret exists.
+func UpdateAlertmanagerSecretMetric(exists bool) {
+	setBool(metricAMSecretExists, exists)
+}
 
-	// Default to false.
-	gaSecretExists := false
-	pdSecretExists := false
-	dmsSecretExists := false
-	amSecretExists := false
-	amSecretContainsGA := false
-	amSecretContainsPD := false
-	amSecretContainsDMS := false
-
-	// Update the metric if the secret is found in the SecretList.
-	for _, secret := range list.Items {
-		switch secret.Name {
-		case "goalert-secret":
-			gaSecretExists = true
-		case "pd-secret":
-			pdSecretExists = true
-		case "dms-secret":
-			dmsSecretExists = true
-		case "alertmanager-main":
-			amSecretExists = true
-		}
-	}
+// UpdatePagerDutySecretMetrics records whether the pd-secret Secret exists and whether
+// alertmanager-main has its receiver.
+func UpdatePagerDutySecretMetrics(exists bool, inConfig bool) {
+	setBool(metricPDSecretExists, exists)
+	setBool(metricAMSecretContainsPD, inConfig)
+}
 
-	// Check for the presence of GoAlert, PD and DMS configs inside the AlertManager config and report metrics.
-	if amSecretExists {
-		if gaSecretExists {
-			for _, receiver := range amconfig.Receivers {
-				if receiver.Name == "goalert" {
-					amSecretContainsGA = true
-				}
-			}
-		}
-		if pdSecretExists {
-			for _, receiver := range amconfig.Receivers {
-				if receiver.Name == "pagerduty" {
-					amSecretContainsPD = true
-				}
-			}
-		}
-		if dmsSecretExists {
-			for _, receiver := range amconfig.Receivers {
-				if receiver.Name == "watchdog" {
-					amSecretContainsDMS = true
-				}
-			}
-		}
-	}
+// UpdateGoAlertSecretMetrics records whether the goalert-secret Secret exists and whether
+// alertmanager-main has its receiver.
+func UpdateGoAlertSecretMetrics(exists bool, inConfig bool) {
+	setBool(metricGASecretExists, exists)
+	setBool(metricAMSecretContainsGA, inConfig)
+}
 
-	// Only set metrics once per run.
-	if gaSecretExists {
-		metricGASecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
-	} else {
-		metricGASecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
-	}
-	if pdSecretExists {
-		metricPDSecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
-	} else {
-		metricPDSecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
-	}
-	if dmsSecretExists {
-		metricDMSSecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
-	} else {
-		metricDMSSecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
-	}
-	if amSecretExists {
-		metricAMSecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
-	} else {
-		metricAMSecretExists.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
-	}
-	if amSecretContainsGA {
-		metricAMSecretContainsGA.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
-	} else {
-		metricAMSecretContainsGA.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
-	}
-	if amSecretContainsPD {
-		metricAMSecretContainsPD.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
-	} else {
-		metricAMSecretContainsPD.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
-	}
-	if amSecretContainsDMS {
-		metricAMSecretContainsDMS.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
+// UpdateDMSSecretMetrics records whether the dms-secret Secret exists and whether
+// alertmanager-main has its receiver.
+func UpdateDMSSecretMetrics(exists bool, inConfig bool) {
+	setBool(metricDMSSecretExists, exists)
+	setBool(metricAMSecretContainsDMS, inConfig)
+}
+
+// setBool sets a gauge to 1 for true and 0 for false.
+func setBool(gauge *prometheus.GaugeVec, value bool) {
+	if value {
+		gauge.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
 	} else {
-		metricAMSecretContainsDMS.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
+		gauge.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
 	}
 }
 
@@ -240,3 +272,104 @@ func UpdateAlertmanagerConfigValidationMetric(validationPassed bool) {
 		metricAlertmanagerConfigValidationFailed.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
 	}
 }
+
+// UpdateClusterReadinessMetrics records the outcome of a cluster readiness check.
+// Only the current reason is reported with a value of 1; previous reasons are cleared.
+func UpdateClusterReadinessMetrics(ready bool, reason string, blockingOperators int) {
+	if ready {
+		metricClusterReady.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
+	} else {
+		metricClusterReady.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
+	}
+	metricClusterReadyReason.Reset()
+	metricClusterReadyReason.With(prometheus.Labels{"name": config.OperatorName, "reason": reason}).Set(float64(1))
+	metricClusterBlockingOperators.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(blockingOperators))
+}
+
+// ObserveClusterTimeToReady records how long the cluster took to become ready after creation.
+func ObserveClusterTimeToReady(timeToReady time.Duration) {
+	metricClusterTimeToReady.With(prometheus.Labels{"name": config.OperatorName}).Observe(timeToReady.Seconds())
+}
+
+// UpdateSettlingWindowMetrics records a settling window starting (active=true) or ending.
+func UpdateSettlingWindowMetrics(active bool) {
+	if active {
+		metricSettlingWindowActive.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
+		metricSettlingWindowsTotal.With(prometheus.Labels{"name": config.OperatorName}).Inc()
+	} else {
+		metricSettlingWindowActive.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
+	}
+}
+
+// UpdateConfigDriftMetrics records whether alertmanager-main holds preserved manual edits
+// and whether its management is paused.
+func UpdateConfigDriftMetrics(drifted bool, paused bool) {
+	if drifted {
+		metricConfigDrifted.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
+	} else {
+		metricConfigDrifted.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
+	}
+	if paused {
+		metricConfigManagementPaused.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
+	} else {
+		metricConfigManagementPaused.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
+	}
+}
+
+// IncConfigDriftDetected counts a newly detected manual edit to alertmanager-main.
+func IncConfigDriftDetected(policy string) {
+	metricConfigDriftDetectedTotal.With(prometheus.Labels{"name": config.OperatorName, "policy": policy}).Inc()
+}
+
+// UpdateAlertmanagerOverridesMetric records whether the alertmanager overrides were rejected.
+func UpdateAlertmanagerOverridesMetric(rejected bool) {
+	if rejected {
+		metricAlertmanagerOverridesRejected.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
+	} else {
+		metricAlertmanagerOverridesRejected.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
+	}
+}
+
+// UpdateAlertmanagerConfigsMetric records how many selected AlertmanagerConfigs were aggregated and rejected.
+func UpdateAlertmanagerConfigsMetric(aggregated int, rejected int) {
+	metricAlertmanagerConfigs.With(prometheus.Labels{"name": config.OperatorName, "state": "aggregated"}).Set(float64(aggregated))
+	metricAlertmanagerConfigs.With(prometheus.Labels{"name": config.OperatorName, "state": "rejected"}).Set(float64(rejected))
+}
+
+// UpdateUserWorkloadConfigValidationMetric records whether the user-workload Alertmanager config was validated and written.
+func UpdateUserWorkloadConfigValidationMetric(validationPassed bool) {
+	if validationPassed {
+		metricUserWorkloadConfigValidationFailed.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(0))
+	} else {
+		metricUserWorkloadConfigValidationFailed.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(1))
+	}
+}
+
+// UpdateClusterTypeMetric records the cluster type in effect and its source. Only the
+// current type is reported; previous ones are cleared.
+func UpdateClusterTypeMetric(clusterType string, source string) {
+	metricClusterType.Reset()
+	metricClusterType.With(prometheus.Labels{"name": config.OperatorName, "type": clusterType, "source": source}).Set(float64(1))
+}
+
+// UpdateNamespaceEntriesRejectedMetric records how many entries of a namespace list in a
+// *-namespaces ConfigMap were skipped as invalid.
+func UpdateNamespaceEntriesRejectedMetric(configMap string, list string, rejected int) {
+	metricNamespaceEntriesRejected.With(prometheus.Labels{"name": config.OperatorName, "configmap": configMap, "list": list}).Set(float64(rejected))
+}
+
+// UpdateNamespaceSourceMetric records how a namespace source was used: Loaded, Defaulted,
+// Empty or Skipped. Only the current state of each source is reported.
+func UpdateNamespaceSourceMetric(source string, state string, namespaces int) {
+	metricNamespaceSource.DeletePartialMatch(prometheus.Labels{"source": source})
+	metricNamespaceSource.With(prometheus.Labels{"name": config.OperatorName, "source": source, "state": state}).Set(float64(namespaces))
+}
+
+// UpdateRoutingCoverageMetrics records how many alerts had each outcome in the routing
+// coverage report, and how many suppression routes matched no alert.
+func UpdateRoutingCoverageMetrics(outcomes map[string]int, unusedSuppressions int) {
+	for outcome, alerts := range outcomes {
+		metricRoutingCoverageAlerts.With(prometheus.Labels{"name": config.OperatorName, "outcome": outcome}).Set(float64(alerts))
+	}
+	metricRoutingCoverageUnusedSuppressions.With(prometheus.Labels{"name": config.OperatorName}).Set(float64(unusedSuppressions))
+}
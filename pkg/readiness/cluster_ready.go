@@ -4,19 +4,17 @@ package readiness
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
-	"net"
-	"net/http"
-	"net/url"
-	"os"
-	"strconv"
 	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
-	"github.com/prometheus/client_golang/api"
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -28,16 +26,34 @@ var log = logf.Log.WithName("readiness")
 type Impl struct {
 	// Client is a controller-runtime client capable of querying k8s.
 	Client client.Client
+	// Recorder records Events about readiness transitions against the ClusterVersion.
+	// Events are not recorded if it is nil.
+	Recorder events.EventRecorder
 	// result is what the calling Reconcile should return if it is otherwise successful.
 	result reconcile.Result
 	// ready indicates whether the cluster is considered ready. Once this is true,
-	// Check() is a no-op.
+	// IsReady() only watches for the cluster resuming from hibernation.
 	ready bool
+	// installing is set once the cluster was found not ready while younger than the max
+	// cluster age, so the transition to ready is only reported when it was seen to happen
+	// and not again after every operator restart.
+	installing bool
+	// settlingStarted and settlingUntil bound the current post-resume settling
+	// window. Both are zero if no window is active.
+	settlingStarted time.Time
+	settlingUntil   time.Time
+	// settlingExpired is set when a settling window expired with the ClusterOperators still
+	// blocking, so no other window is opened until fewer than half of them block. Otherwise a
+	// cluster that stays broken would withhold paging again after every window.
+	settlingExpired bool
 	// clusterCreationTime caches the birth time of the cluster so we only have to
 	// query prometheus once.
 	clusterCreationTime time.Time
 	// promAPI is a handle to the prometheus API client
 	promAPI promv1.API
+	// promCABundle is the service CA bundle promAPI was built with, so the client
+	// can be rebuilt when the CA is rotated.
+	promCABundle string
 }
 
 // Interface is the interface for the readiness engine.
@@ -51,52 +67,46 @@ type Interface interface {
 
 var _ Interface = &Impl{}
 
+// Reasons reported by the readiness engine via metrics and events.
 const (
-	maxClusterAgeKey     = "MAX_CLUSTER_AGE_MINUTES"
-	maxClusterAgeDefault = 90
+	// ReasonClusterOperatorsReady means every ClusterOperator is Available, not Progressing and not Degraded.
+	ReasonClusterOperatorsReady = "ClusterOperatorsReady"
+	// ReasonClusterAgeExceeded means the cluster was declared ready because it is older than the max cluster age.
+	ReasonClusterAgeExceeded = "ClusterAgeExceeded"
+	// ReasonClusterOperatorsProgressing means at least one ClusterOperator is still blocking readiness.
+	ReasonClusterOperatorsProgressing = "ClusterOperatorsProgressing"
+	// ReasonClusterCreationTimeUnknown means the age fallback could not be evaluated.
+	ReasonClusterCreationTimeUnknown = "ClusterCreationTimeUnknown"
+	// ReasonClusterResumeSettling means the cluster resumed (e.g. from hibernation) and
+	// paging is withheld until it settles.
+	ReasonClusterResumeSettling = "ClusterResumeSettling"
+	// ReasonSettlingWindowExpired means the settling window ended before all ClusterOperators recovered.
+	ReasonSettlingWindowExpired = "SettlingWindowExpired"
 )
 
 // IsReady determines whether the cluster is ready for PagerDuty configuration.
 // Ready is true if:
-//   - a previous check has already succeeded (cached);
+//   - a previous check has already succeeded (cached) and the cluster is not
+//     settling after a resume;
 //   - all ClusterOperators have Progressing=false; or
 //   - the cluster is older than maxClusterAgeMinutes (fallback)
 func (impl *Impl) IsReady() (bool, error) {
 	if impl.ready {
-		log.Info("DEBUG: Using cached positive cluster readiness.")
-		return impl.ready, nil
+		return impl.checkSettling()
 	}
 
 	impl.result = reconcile.Result{}
 
 	// Primary check: all ClusterOperators have stopped progressing
+	blockingOperators := 0
 	coList := &configv1.ClusterOperatorList{}
 	if err := impl.Client.List(context.TODO(), coList); err != nil {
 		log.Error(err, "Failed to list ClusterOperators, falling through to age check")
 	} else if len(coList.Items) > 0 {
-		allReady := true
-		for _, co := range coList.Items {
-			for _, cond := range co.Status.Conditions {
-				if cond.Type == configv1.OperatorProgressing && cond.Status == configv1.ConditionTrue {
-					allReady = false
-					break
-				}
-				if cond.Type == configv1.OperatorDegraded && cond.Status == configv1.ConditionTrue {
-					allReady = false
-					break
-				}
-				if cond.Type == configv1.OperatorAvailable && cond.Status == configv1.ConditionFalse {
-					allReady = false
-					break
-				}
-			}
-			if !allReady {
-				break
-			}
-		}
-		if allReady {
+		blockingOperators = countBlockingOperators(coList)
+		if blockingOperators == 0 {
 			log.Info(fmt.Sprintf("INFO: All %d ClusterOperators are Available, not Progressing, and not Degraded. Cluster is ready.", len(coList.Items)))
-			impl.ready = true
+			impl.setReady(ReasonClusterOperatorsReady)
 			return impl.ready, nil
 		}
 	}
@@ -104,61 +114,116 @@ func (impl *Impl) IsReady() (bool, error) {
 	// Fallback: cluster age check
 	if err := impl.setClusterCreationTime(); err != nil {
 		log.Error(err, "Failed to determine cluster creation time")
+		metrics.UpdateClusterReadinessMetrics(false, ReasonClusterCreationTimeUnknown, blockingOperators)
 		impl.result = reconcile.Result{Requeue: true, RequeueAfter: time.Second}
 		return false, nil
 	}
-	maxClusterAge, err := getEnvInt(maxClusterAgeKey, maxClusterAgeDefault)
-	if err != nil {
-		return false, err
-	}
+	settings := config.GetSettings()
+	maxClusterAge := settings.MaxClusterAgeMinutes
 	if impl.clusterTooOld(maxClusterAge) {
+		// After hibernation the operator restarts with the nodes, so the readiness cached
+		// before the resume is lost. The ClusterOperators of a cluster past its max age only
+		// become unready at once when it resumed.
+		if settings.ResumeSettling && impl.resumeDetected(coList, blockingOperators) {
+			return impl.startSettling(coList, blockingOperators)
+		}
 		log.Info(fmt.Sprintf("INFO: Cluster is older than %d minutes. Declaring ready.", maxClusterAge))
-		impl.ready = true
+		impl.setReady(ReasonClusterAgeExceeded)
 		return impl.ready, nil
 	}
 
-	delay := 30 * time.Second
-	log.Info(fmt.Sprintf("INFO: ClusterOperators still progressing. Requeueing after %v.", delay))
+	delay := settings.ReadinessRequeueInterval
+	log.Info(fmt.Sprintf("INFO: ClusterOperators still progressing. Requeueing after %v.", delay), "blockingOperators", blockingOperators)
+	metrics.UpdateClusterReadinessMetrics(false, ReasonClusterOperatorsProgressing, blockingOperators)
+	impl.installing = true
 	impl.result = reconcile.Result{Requeue: true, RequeueAfter: delay}
 	return false, nil
 }
 
-func (impl *Impl) Result() reconcile.Result {
-	return impl.result
+// countBlockingOperators returns the number of ClusterOperators that are Progressing,
+// Degraded, or not Available.
+func countBlockingOperators(coList *configv1.ClusterOperatorList) int {
+	blocking := 0
+	for i := range coList.Items {
+		if IsBlocking(&coList.Items[i]) {
+			blocking++
+		}
+	}
+	return blocking
 }
 
-func (impl *Impl) setPromAPI() error {
-	rawToken, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/token")
-	if err != nil {
-		return fmt.Errorf("couldn't read token file: %w", err)
-	}
-
-	client, err := api.NewClient(api.Config{
-		Address: "https://prometheus-k8s.openshift-monitoring.svc:9091",
-		RoundTripper: &http.Transport{
-			Proxy: func(request *http.Request) (*url.URL, error) {
-				request.Header.Add("Authorization", "Bearer "+string(rawToken))
-				return http.ProxyFromEnvironment(request)
-			},
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			TLSClientConfig: &tls.Config{
-				MinVersion: tls.VersionTLS12,
-				// disable "G402 (CWE-295): TLS InsecureSkipVerify set true."
-				// #nosec G402
-				InsecureSkipVerify: true,
-			},
-			TLSHandshakeTimeout: 10 * time.Second,
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("couldn't configure prometheus client: %w", err)
+// IsBlocking returns true if the ClusterOperator is Progressing, Degraded, or not Available.
+func IsBlocking(co *configv1.ClusterOperator) bool {
+	for _, cond := range co.Status.Conditions {
+		if (cond.Type == configv1.OperatorProgressing && cond.Status == configv1.ConditionTrue) ||
+			(cond.Type == configv1.OperatorDegraded && cond.Status == configv1.ConditionTrue) ||
+			(cond.Type == configv1.OperatorAvailable && cond.Status == configv1.ConditionFalse) {
+			return true
+		}
 	}
+	return false
+}
 
-	impl.promAPI = promv1.NewAPI(client)
-	return nil
+// setReady caches a positive readiness decision and reports it via metrics. The time to
+// ready is observed and an Event recorded only if the cluster was seen installing; an
+// operator restarting on a cluster that was declared ready before doesn't report it again.
+func (impl *Impl) setReady(reason string) {
+	impl.ready = true
+	metrics.UpdateClusterReadinessMetrics(true, reason, 0)
+	if !impl.installing {
+		return
+	}
+	impl.installing = false
+
+	birth := impl.clusterBirthTime()
+	if !birth.IsZero() {
+		metrics.ObserveClusterTimeToReady(time.Since(birth))
+	}
+	impl.recordReadyEvent(reason, birth)
+}
+
+// clusterBirthTime returns the cluster creation time, preferring the value already
+// fetched from prometheus and falling back to the ClusterVersion creation timestamp.
+// A zero time is returned if neither is available.
+func (impl *Impl) clusterBirthTime() time.Time {
+	if !impl.clusterCreationTime.IsZero() {
+		return impl.clusterCreationTime
+	}
+	var version configv1.ClusterVersion
+	if err := impl.Client.Get(context.TODO(), client.ObjectKey{Name: "version"}, &version); err != nil {
+		log.Error(err, "Failed to get ClusterVersion to determine time to ready")
+		return time.Time{}
+	}
+	return version.CreationTimestamp.Time
+}
+
+// recordReadyEvent creates a Kubernetes event when the cluster transitions to ready
+func (impl *Impl) recordReadyEvent(reason string, birth time.Time) {
+	message := fmt.Sprintf("Cluster declared ready for paging integrations (reason: %s).", reason)
+	if !birth.IsZero() {
+		message = fmt.Sprintf("Cluster declared ready for paging integrations %s after creation (reason: %s).", time.Since(birth).Round(time.Second), reason)
+	}
+	impl.recordEvent(corev1.EventTypeNormal, "ClusterReady", message)
+}
+
+// eventActionReadiness is the action reported on readiness Events.
+const eventActionReadiness = "EvaluateReadiness"
+
+// recordEvent records an Event about the cluster's readiness against the ClusterVersion.
+func (impl *Impl) recordEvent(eventType, reason, message string) {
+	if impl.Recorder == nil {
+		return
+	}
+	// Prefer the live object so the Event carries its UID
+	version := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}}
+	if err := impl.Client.Get(context.TODO(), client.ObjectKeyFromObject(version), version); err != nil {
+		log.Error(err, "Failed to get ClusterVersion for readiness event", "reason", reason)
+	}
+	impl.Recorder.Eventf(version, nil, eventType, reason, eventActionReadiness, "%s", message)
+}
+
+func (impl *Impl) Result() reconcile.Result {
+	return impl.result
 }
 
 func (impl *Impl) setClusterCreationTime() error {
@@ -199,15 +264,3 @@ func (impl *Impl) clusterTooOld(maxAgeMinutes int) bool {
 	maxAge := time.Now().Add(time.Duration(-maxAgeMinutes) * time.Minute)
 	return impl.clusterCreationTime.Before(maxAge)
 }
-
-func getEnvInt(key string, def int) (int, error) {
-	strVal := os.Getenv(key)
-	if strVal == "" {
-		return def, nil
-	}
-	intVal, err := strconv.Atoi(strVal)
-	if err != nil {
-		return 0, fmt.Errorf("invalid value for env var: %s=%s (expected int): %v", key, strVal, err)
-	}
-	return intVal, nil
-}
@@ -0,0 +1,475 @@
+package readiness
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/configure-alertmanager-operator/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newFakeClient(objs ...client.Object) client.Client {
+	fakeScheme := k8sruntime.NewScheme()
+	utilruntime.Must(configv1.AddToScheme(fakeScheme))
+	utilruntime.Must(corev1.AddToScheme(fakeScheme))
+	return fake.NewClientBuilder().WithScheme(fakeScheme).WithObjects(objs...).Build()
+}
+
+// drainEvents returns the events recorded so far by a FakeRecorder.
+func drainEvents(recorder *events.FakeRecorder) []string {
+	recorded := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			recorded = append(recorded, event)
+		default:
+			return recorded
+		}
+	}
+}
+
+func newClusterOperator(name string, available, progressing, degraded configv1.ConditionStatus) *configv1.ClusterOperator {
+	return &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: configv1.ClusterOperatorStatus{
+			Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: available},
+				{Type: configv1.OperatorProgressing, Status: progressing},
+				{Type: configv1.OperatorDegraded, Status: degraded},
+			},
+		},
+	}
+}
+
+func Test_countBlockingOperators(t *testing.T) {
+	coList := &configv1.ClusterOperatorList{
+		Items: []configv1.ClusterOperator{
+			*newClusterOperator("ready", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse),
+			*newClusterOperator("progressing", configv1.ConditionTrue, configv1.ConditionTrue, configv1.ConditionFalse),
+			*newClusterOperator("degraded", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionTrue),
+			*newClusterOperator("unavailable", configv1.ConditionFalse, configv1.ConditionFalse, configv1.ConditionFalse),
+			*newClusterOperator("everything", configv1.ConditionFalse, configv1.ConditionTrue, configv1.ConditionTrue),
+		},
+	}
+	if got := countBlockingOperators(coList); got != 4 {
+		t.Fatalf("Expected 4 blocking operators, got %d", got)
+	}
+}
+
+func Test_IsReady_AllOperatorsReady(t *testing.T) {
+	ctx := context.TODO()
+	version := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "version",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+	}
+	coB := newClusterOperator("b", configv1.ConditionTrue, configv1.ConditionTrue, configv1.ConditionFalse)
+	recorder := events.NewFakeRecorder(10)
+	impl := &Impl{Client: newFakeClient(
+		version,
+		newClusterOperator("a", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse),
+		coB,
+	), Recorder: recorder, clusterCreationTime: time.Now().Add(-time.Hour)}
+
+	// The cluster is still installing
+	if ready, err := impl.IsReady(); err != nil || ready {
+		t.Fatalf("Expected cluster not to be ready while installing, got ready=%v err=%v", ready, err)
+	}
+
+	coB.Status.Conditions[1].Status = configv1.ConditionFalse
+	if err := impl.Client.Update(ctx, coB); err != nil {
+		t.Fatal(err)
+	}
+	ready, err := impl.IsReady()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !ready {
+		t.Fatal("Expected cluster to be ready")
+	}
+	if impl.Result().Requeue || impl.Result().RequeueAfter != 0 {
+		t.Fatalf("Expected no requeue, got %v", impl.Result())
+	}
+
+	recordedEvents := drainEvents(recorder)
+	if len(recordedEvents) != 1 || !strings.HasPrefix(recordedEvents[0], "Normal ClusterReady ") {
+		t.Fatalf("Expected a single ClusterReady event, got %v", recordedEvents)
+	}
+}
+
+func Test_IsReady_OperatorRestartOnReadyCluster(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		progressing configv1.ConditionStatus
+	}{
+		{"all ClusterOperators ready", configv1.ConditionFalse},
+		{"past the max cluster age", configv1.ConditionTrue},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := events.NewFakeRecorder(10)
+			impl := &Impl{Client: newFakeClient(
+				&configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}},
+				newClusterOperator("a", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse),
+				newClusterOperator("b", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse),
+				newClusterOperator("c", configv1.ConditionTrue, tt.progressing, configv1.ConditionFalse),
+			), Recorder: recorder, clusterCreationTime: time.Now().Add(-48 * time.Hour)}
+
+			if ready, err := impl.IsReady(); err != nil || !ready {
+				t.Fatalf("Expected cluster to be ready, got ready=%v err=%v", ready, err)
+			}
+			if recordedEvents := drainEvents(recorder); len(recordedEvents) != 0 {
+				t.Fatalf("Expected no ClusterReady event after a restart, got %v", recordedEvents)
+			}
+		})
+	}
+}
+
+func Test_IsReady_OperatorsProgressing_CreationTimeUnknown(t *testing.T) {
+	impl := &Impl{Client: newFakeClient(
+		newClusterOperator("a", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse),
+		newClusterOperator("b", configv1.ConditionTrue, configv1.ConditionTrue, configv1.ConditionFalse),
+	)}
+
+	// Outside of a cluster the prometheus client cannot be configured, so the age
+	// fallback fails and we expect a short requeue.
+	ready, err := impl.IsReady()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ready {
+		t.Fatal("Expected cluster not to be ready")
+	}
+	if impl.Result().RequeueAfter != time.Second {
+		t.Fatalf("Expected requeue after 1s, got %v", impl.Result())
+	}
+}
+
+func Test_setPromAPI_MissingServiceCA(t *testing.T) {
+	impl := &Impl{Client: newFakeClient()}
+	err := impl.setPromAPI()
+	if err == nil || !strings.Contains(err.Error(), serviceCAConfigMapName) {
+		t.Fatalf("Expected error referencing %s, got %v", serviceCAConfigMapName, err)
+	}
+}
+
+func Test_setPromAPI_EmptyServiceCA(t *testing.T) {
+	impl := &Impl{Client: newFakeClient(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: serviceCAConfigMapName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{},
+	})}
+	err := impl.setPromAPI()
+	if err == nil || !strings.Contains(err.Error(), serviceCAConfigMapKey) {
+		t.Fatalf("Expected error referencing %s, got %v", serviceCAConfigMapKey, err)
+	}
+}
+
+func Test_getPrometheusURL(t *testing.T) {
+	t.Setenv(prometheusURLKey, "")
+	if got := getPrometheusURL(); got != prometheusURLDefault {
+		t.Fatalf("Expected default %s, got %s", prometheusURLDefault, got)
+	}
+	thanos := "https://thanos-querier.openshift-monitoring.svc:9091"
+	t.Setenv(prometheusURLKey, thanos)
+	if got := getPrometheusURL(); got != thanos {
+		t.Fatalf("Expected %s, got %s", thanos, got)
+	}
+}
+
+func Test_IsReady_SettlingAfterResume(t *testing.T) {
+	ctx := context.TODO()
+	version := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}}
+	coA := newClusterOperator("a", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse)
+	coB := newClusterOperator("b", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse)
+	recorder := events.NewFakeRecorder(10)
+	impl := &Impl{Client: newFakeClient(version, coA, coB), Recorder: recorder, ready: true}
+
+	// Stable cluster: cached readiness is used.
+	if ready, err := impl.IsReady(); err != nil || !ready {
+		t.Fatalf("Expected cached readiness, got ready=%v err=%v", ready, err)
+	}
+
+	// Every operator progressing at once, as after hibernation: enter the settling window.
+	coA.Status.Conditions[1].Status = configv1.ConditionTrue
+	coB.Status.Conditions[1].Status = configv1.ConditionTrue
+	if err := impl.Client.Update(ctx, coA); err != nil {
+		t.Fatal(err)
+	}
+	if err := impl.Client.Update(ctx, coB); err != nil {
+		t.Fatal(err)
+	}
+	if ready, err := impl.IsReady(); err != nil || ready {
+		t.Fatalf("Expected paging to be withheld while settling, got ready=%v err=%v", ready, err)
+	}
+	if delay := config.GetSettings().ReadinessRequeueInterval; impl.Result().RequeueAfter != delay {
+		t.Fatalf("Expected requeue after %v, got %v", delay, impl.Result())
+	}
+
+	// Operators recover: the window closes early.
+	coA.Status.Conditions[1].Status = configv1.ConditionFalse
+	coB.Status.Conditions[1].Status = configv1.ConditionFalse
+	if err := impl.Client.Update(ctx, coA); err != nil {
+		t.Fatal(err)
+	}
+	if err := impl.Client.Update(ctx, coB); err != nil {
+		t.Fatal(err)
+	}
+	if ready, err := impl.IsReady(); err != nil || !ready {
+		t.Fatalf("Expected cluster to be ready after settling, got ready=%v err=%v", ready, err)
+	}
+
+	recordedEvents := drainEvents(recorder)
+	if len(recordedEvents) != 2 ||
+		!strings.HasPrefix(recordedEvents[0], "Warning SettlingWindowStarted ") ||
+		!strings.HasPrefix(recordedEvents[1], "Normal SettlingWindowEnded ") {
+		t.Fatalf("Expected settling window events, got %v", recordedEvents)
+	}
+}
+
+func Test_IsReady_SettlingAfterRestartOnResume(t *testing.T) {
+	defer config.SetSettings(config.DefaultSettings())
+	settings := config.DefaultSettings()
+	settings.ReadinessRequeueInterval = 45 * time.Second
+	config.SetSettings(settings)
+
+	// The operator restarted with the nodes of a hibernated cluster, so nothing is cached
+	recorder := events.NewFakeRecorder(10)
+	impl := &Impl{Client: newFakeClient(
+		&configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}},
+		newClusterOperator("a", configv1.ConditionTrue, configv1.ConditionTrue, configv1.ConditionFalse),
+		newClusterOperator("b", configv1.ConditionFalse, configv1.ConditionTrue, configv1.ConditionFalse),
+		newClusterOperator("c", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse),
+	), Recorder: recorder, clusterCreationTime: time.Now().Add(-48 * time.Hour)}
+
+	if ready, err := impl.IsReady(); err != nil || ready {
+		t.Fatalf("Expected paging to be withheld on an old cluster resuming, got ready=%v err=%v", ready, err)
+	}
+	if !impl.settling() {
+		t.Fatal("Expected a settling window to be active")
+	}
+	if impl.Result().RequeueAfter != 45*time.Second {
+		t.Fatalf("Expected requeue after the readiness requeue interval, got %v", impl.Result())
+	}
+	recordedEvents := drainEvents(recorder)
+	if len(recordedEvents) != 1 || !strings.HasPrefix(recordedEvents[0], "Warning SettlingWindowStarted ") {
+		t.Fatalf("Expected a SettlingWindowStarted event, got %v", recordedEvents)
+	}
+
+	// Without resume settling the age fallback pages at once
+	settings.ResumeSettling = false
+	config.SetSettings(settings)
+	impl = &Impl{Client: impl.Client, clusterCreationTime: time.Now().Add(-48 * time.Hour)}
+	if ready, err := impl.IsReady(); err != nil || !ready {
+		t.Fatalf("Expected the max cluster age to declare readiness, got ready=%v err=%v", ready, err)
+	}
+}
+
+func Test_IsReady_SettlingWindowExpires(t *testing.T) {
+	version := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}}
+	impl := &Impl{Client: newFakeClient(
+		version,
+		newClusterOperator("a", configv1.ConditionFalse, configv1.ConditionTrue, configv1.ConditionFalse),
+	), ready: true}
+	impl.settlingStarted = time.Now().Add(-time.Hour)
+	impl.settlingUntil = time.Now().Add(-time.Minute)
+
+	if ready, err := impl.IsReady(); err != nil || !ready {
+		t.Fatalf("Expected expired settling window to restore readiness, got ready=%v err=%v", ready, err)
+	}
+	if impl.settling() {
+		t.Fatal("Expected settling window to be closed")
+	}
+
+	// The ClusterOperators still blocking after the window expired don't open another one
+	if ready, err := impl.IsReady(); err != nil || !ready {
+		t.Fatalf("Expected readiness to hold while the ClusterOperators still block, got ready=%v err=%v", ready, err)
+	}
+	if impl.settling() {
+		t.Fatal("Expected no new settling window while the ClusterOperators still block")
+	}
+}
+
+// Test_IsReady_SettlingAgainAfterRecovery verifies that a settling window can open again once
+// the ClusterOperators blocking when the last one expired have recovered.
+func Test_IsReady_SettlingAgainAfterRecovery(t *testing.T) {
+	version := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}}
+	operator := newClusterOperator("a", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse)
+	c := newFakeClient(version, operator)
+	impl := &Impl{Client: c, ready: true, settlingExpired: true}
+
+	if ready, err := impl.IsReady(); err != nil || !ready {
+		t.Fatalf("Expected recovered ClusterOperators to be ready, got ready=%v err=%v", ready, err)
+	}
+	if impl.settlingExpired {
+		t.Fatal("Expected the expired window to be forgotten once the ClusterOperators recovered")
+	}
+
+	operator.Status.Conditions = newClusterOperator("a", configv1.ConditionFalse, configv1.ConditionTrue, configv1.ConditionFalse).Status.Conditions
+	if err := c.Update(context.TODO(), operator); err != nil {
+		t.Fatal(err)
+	}
+	if ready, err := impl.IsReady(); err != nil || ready {
+		t.Fatalf("Expected a new resume to withhold paging, got ready=%v err=%v", ready, err)
+	}
+	if !impl.settling() {
+		t.Fatal("Expected a new settling window")
+	}
+}
+
+func Test_IsReady_NoSettlingDuringUpgrade(t *testing.T) {
+	version := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue},
+			},
+		},
+	}
+	impl := &Impl{Client: newFakeClient(
+		version,
+		newClusterOperator("a", configv1.ConditionTrue, configv1.ConditionTrue, configv1.ConditionFalse),
+		newClusterOperator("b", configv1.ConditionTrue, configv1.ConditionFalse, configv1.ConditionFalse),
+	), ready: true}
+
+	if ready, err := impl.IsReady(); err != nil || !ready {
+		t.Fatalf("Expected upgrade not to trigger settling, got ready=%v err=%v", ready, err)
+	}
+}
+
+// newPrometheusServer returns a TLS server answering Prometheus queries with a cluster
+// created at createdAt, for requests carrying the bearer token token() returns.
+func newPrometheusServer(t *testing.T, createdAt time.Time, token func() string) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"type":"initial"},"value":[%d,"%d"]}]}}`, time.Now().Unix(), createdAt.Unix())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// serviceCA returns the service CA ConfigMap holding the PEM encoded certificate.
+func serviceCA(cert []byte) *corev1.ConfigMap {
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: serviceCAConfigMapName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{serviceCAConfigMapKey: string(caBundle)},
+	}
+}
+
+// otherCA returns a self-signed CA certificate that signed none of the test servers.
+func otherCA(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "other-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// tokenFile points serviceAccountTokenFile at a file in a temporary directory for the test.
+func tokenFile(t *testing.T) {
+	previous := serviceAccountTokenFile
+	serviceAccountTokenFile = filepath.Join(t.TempDir(), "token")
+	t.Cleanup(func() { serviceAccountTokenFile = previous })
+}
+
+// writeToken writes token to serviceAccountTokenFile, as the kubelet does on rotation.
+func writeToken(t *testing.T, token string) {
+	if err := os.WriteFile(serviceAccountTokenFile, []byte(token), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_setClusterCreationTime_TrustsServiceCA(t *testing.T) {
+	createdAt := time.Unix(1600000000, 0)
+	server := newPrometheusServer(t, createdAt, func() string { return "token" })
+	t.Setenv(prometheusURLKey, server.URL)
+	tokenFile(t)
+	writeToken(t, "token")
+
+	impl := &Impl{Client: newFakeClient(serviceCA(server.Certificate().Raw))}
+	if err := impl.setClusterCreationTime(); err != nil {
+		t.Fatalf("Unexpected error querying Prometheus signed by the service CA: %v", err)
+	}
+	if !impl.clusterCreationTime.Equal(createdAt) {
+		t.Errorf("Expected cluster creation time %v, got %v", createdAt, impl.clusterCreationTime)
+	}
+
+	impl = &Impl{Client: newFakeClient(serviceCA(otherCA(t)))}
+	if err := impl.setClusterCreationTime(); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("Expected a certificate error querying Prometheus not signed by the service CA, got %v", err)
+	}
+}
+
+func Test_setPromAPI_RotatedToken(t *testing.T) {
+	var lock sync.Mutex
+	accepted := "first"
+	server := newPrometheusServer(t, time.Unix(1600000000, 0), func() string {
+		lock.Lock()
+		defer lock.Unlock()
+		return accepted
+	})
+	t.Setenv(prometheusURLKey, server.URL)
+	tokenFile(t)
+	writeToken(t, "first")
+
+	impl := &Impl{Client: newFakeClient(serviceCA(server.Certificate().Raw))}
+	if err := impl.setPromAPI(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := impl.promAPI.Query(context.TODO(), "up", time.Now()); err != nil {
+		t.Fatalf("Unexpected error with the first token: %v", err)
+	}
+
+	// The kubelet rotates the token and the old one is no longer accepted
+	writeToken(t, "second")
+	lock.Lock()
+	accepted = "second"
+	lock.Unlock()
+
+	// The cached token is rejected once, then the rotated one is read
+	if _, _, err := impl.promAPI.Query(context.TODO(), "up", time.Now()); err == nil {
+		t.Fatal("Expected the cached token to be rejected")
+	}
+	if _, _, err := impl.promAPI.Query(context.TODO(), "up", time.Now()); err != nil {
+		t.Fatalf("Expected the rotated token to be read, got %v", err)
+	}
+}
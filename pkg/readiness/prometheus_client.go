@@ -0,0 +1,111 @@
+package readiness
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/transport"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// prometheusURLKey is the environment variable used to override the Prometheus
+	// (or Thanos querier) endpoint used for readiness queries.
+	prometheusURLKey     = "PROMETHEUS_URL"
+	prometheusURLDefault = "https://prometheus-k8s.openshift-monitoring.svc:9091"
+
+	// serviceCAConfigMapName is the ConfigMap OpenShift publishes into every namespace
+	// containing the service CA bundle, which signs the in-cluster monitoring endpoints.
+	serviceCAConfigMapName = "openshift-service-ca.crt"
+	serviceCAConfigMapKey  = "service-ca.crt"
+)
+
+// serviceAccountTokenFile is the projected service account token. It is rotated by the
+// kubelet, so it is re-read every minute, and as soon as Prometheus rejects it, rather
+// than once.
+var serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token" // #nosec G101
+
+// setPromAPI configures the prometheus API client. The client trusts only the OpenShift
+// service CA and authenticates with the projected service account token, which is
+// refreshed from disk as it rotates. The client is reused across calls and only
+// rebuilt when the service CA bundle changes.
+func (impl *Impl) setPromAPI() error {
+	caBundle, err := impl.readServiceCABundle()
+	if err != nil {
+		return err
+	}
+	if impl.promAPI != nil && impl.promCABundle == caBundle {
+		return nil
+	}
+
+	tokenSource := transport.NewCachedFileTokenSource(serviceAccountTokenFile)
+	if _, err := tokenSource.Token(); err != nil {
+		return fmt.Errorf("couldn't read token file: %w", err)
+	}
+
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM([]byte(caBundle)) {
+		return fmt.Errorf("no valid certificates found in %s/%s key %s", config.OperatorNamespace, serviceCAConfigMapName, serviceCAConfigMapKey)
+	}
+
+	roundTripper := transport.ResettableTokenSourceWrapTransport(tokenSource)(&http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    rootCAs,
+		},
+		TLSHandshakeTimeout: 10 * time.Second,
+	})
+
+	address := getPrometheusURL()
+	promClient, err := api.NewClient(api.Config{
+		Address:      address,
+		RoundTripper: roundTripper,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't configure prometheus client: %w", err)
+	}
+
+	log.Info("INFO: Configured prometheus client", "address", address)
+	impl.promAPI = promv1.NewAPI(promClient)
+	impl.promCABundle = caBundle
+	return nil
+}
+
+// readServiceCABundle returns the PEM encoded OpenShift service CA bundle.
+func (impl *Impl) readServiceCABundle() (string, error) {
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: config.OperatorNamespace, Name: serviceCAConfigMapName}
+	if err := impl.Client.Get(context.TODO(), key, cm); err != nil {
+		return "", fmt.Errorf("couldn't read service CA bundle from configmap %s: %w", key, err)
+	}
+	caBundle := cm.Data[serviceCAConfigMapKey]
+	if caBundle == "" {
+		return "", fmt.Errorf("configmap %s has no %s key", key, serviceCAConfigMapKey)
+	}
+	return caBundle, nil
+}
+
+// getPrometheusURL returns the endpoint used for readiness queries, e.g.
+// https://thanos-querier.openshift-monitoring.svc:9091 on clusters where only the
+// querier is reachable.
+func getPrometheusURL() string {
+	if address := os.Getenv(prometheusURLKey); address != "" {
+		return address
+	}
+	return prometheusURLDefault
+}
@@ -0,0 +1,131 @@
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/configure-alertmanager-operator/config"
+	"github.com/openshift/configure-alertmanager-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// checkSettling is called once the cluster has been declared ready. A cluster resuming
+// from hibernation comes back with most ClusterOperators progressing at once, which
+// would page immediately. When such a mass transition is seen, IsReady() reports the
+// cluster as not ready for a bounded settling window so paging routes are withheld
+// until the ClusterOperators recover or the window expires. The operator usually
+// restarts with the nodes, which IsReady() detects before the max cluster age fallback.
+func (impl *Impl) checkSettling() (bool, error) {
+	impl.result = reconcile.Result{}
+
+	settings := config.GetSettings()
+	if !settings.ResumeSettling {
+		if impl.settling() {
+			impl.endSettling(ReasonClusterOperatorsReady)
+		}
+		return true, nil
+	}
+
+	coList := &configv1.ClusterOperatorList{}
+	if err := impl.Client.List(context.TODO(), coList); err != nil {
+		log.Error(err, "Failed to list ClusterOperators, using cached cluster readiness")
+		return !impl.settling(), nil
+	}
+	blockingOperators := countBlockingOperators(coList)
+
+	if impl.settling() {
+		if blockingOperators == 0 {
+			impl.endSettling(ReasonClusterOperatorsReady)
+			return true, nil
+		}
+		if time.Now().After(impl.settlingUntil) {
+			impl.endSettling(ReasonSettlingWindowExpired)
+			impl.settlingExpired = true
+			return true, nil
+		}
+		log.Info(fmt.Sprintf("INFO: Cluster is settling after resume until %v. Requeueing after %v.", impl.settlingUntil.UTC(), settings.ReadinessRequeueInterval), "blockingOperators", blockingOperators)
+		metrics.UpdateClusterReadinessMetrics(false, ReasonClusterResumeSettling, blockingOperators)
+		impl.result = reconcile.Result{Requeue: true, RequeueAfter: settings.ReadinessRequeueInterval}
+		return false, nil
+	}
+
+	if impl.settlingExpired {
+		if massTransition(blockingOperators, len(coList.Items)) {
+			log.Info("DEBUG: ClusterOperators are still blocking since the settling window expired; not settling again.", "blockingOperators", blockingOperators)
+			return true, nil
+		}
+		impl.settlingExpired = false
+	}
+
+	if !impl.resumeDetected(coList, blockingOperators) {
+		log.Info("DEBUG: Using cached positive cluster readiness.")
+		return true, nil
+	}
+
+	return impl.startSettling(coList, blockingOperators)
+}
+
+// startSettling opens a settling window after the cluster was seen to resume, withholding
+// paging until the ClusterOperators recover or the window expires.
+func (impl *Impl) startSettling(coList *configv1.ClusterOperatorList, blockingOperators int) (bool, error) {
+	settings := config.GetSettings()
+	window := settings.SettlingWindowMinutes
+	impl.ready = true
+	impl.settlingStarted = time.Now()
+	impl.settlingUntil = impl.settlingStarted.Add(time.Duration(window) * time.Minute)
+	log.Info(fmt.Sprintf("INFO: %d of %d ClusterOperators became unready at once; cluster appears to have resumed. Withholding paging for up to %d minutes.", blockingOperators, len(coList.Items), window))
+	metrics.UpdateSettlingWindowMetrics(true)
+	metrics.UpdateClusterReadinessMetrics(false, ReasonClusterResumeSettling, blockingOperators)
+	impl.recordEvent(corev1.EventTypeWarning, "SettlingWindowStarted",
+		fmt.Sprintf("%d of %d ClusterOperators became unready at once, which indicates the cluster resumed from hibernation. PagerDuty and GoAlert routes are withheld until the ClusterOperators recover or %v.", blockingOperators, len(coList.Items), impl.settlingUntil.UTC().Format(time.RFC3339)))
+	impl.result = reconcile.Result{Requeue: true, RequeueAfter: settings.ReadinessRequeueInterval}
+	return false, nil
+}
+
+// settling returns true if a settling window is active.
+func (impl *Impl) settling() bool {
+	return !impl.settlingUntil.IsZero()
+}
+
+// endSettling closes the active settling window and restores paging.
+func (impl *Impl) endSettling(reason string) {
+	duration := time.Since(impl.settlingStarted).Round(time.Second)
+	impl.settlingStarted = time.Time{}
+	impl.settlingUntil = time.Time{}
+	log.Info("INFO: Cluster settling window ended. Cluster is ready.", "reason", reason)
+	metrics.UpdateSettlingWindowMetrics(false)
+	metrics.UpdateClusterReadinessMetrics(true, reason, 0)
+	impl.recordEvent(corev1.EventTypeNormal, "SettlingWindowEnded",
+		fmt.Sprintf("Settling window ended after %s (reason: %s). PagerDuty and GoAlert routes are restored.", duration, reason))
+}
+
+// resumeDetected returns true if at least half of the ClusterOperators are blocking
+// readiness while no upgrade is in progress. A cluster upgrade also makes ClusterOperators
+// progress, but one at a time, and is reported by the ClusterVersion Progressing condition.
+func (impl *Impl) resumeDetected(coList *configv1.ClusterOperatorList, blockingOperators int) bool {
+	if !massTransition(blockingOperators, len(coList.Items)) {
+		return false
+	}
+
+	var version configv1.ClusterVersion
+	if err := impl.Client.Get(context.TODO(), client.ObjectKey{Name: "version"}, &version); err != nil {
+		log.Error(err, "Failed to get ClusterVersion while checking for cluster resume")
+		return true
+	}
+	for _, cond := range version.Status.Conditions {
+		if cond.Type == configv1.OperatorProgressing && cond.Status == configv1.ConditionTrue {
+			log.Info("INFO: ClusterOperators are unready but the cluster is upgrading; not entering a settling window.", "blockingOperators", blockingOperators)
+			return false
+		}
+	}
+	return true
+}
+
+// massTransition returns true if at least half of the ClusterOperators are blocking readiness.
+func massTransition(blockingOperators, operators int) bool {
+	return blockingOperators > 0 && blockingOperators*2 >= operators
+}
@@ -0,0 +1,214 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	amconfig "github.com/prometheus/alertmanager/config"
+	yaml "gopkg.in/yaml.v2"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+var update = flag.Bool("update", false, "update the expected alertmanager.yaml of each scenario in testdata/scenarios")
+
+// scenario is the inputs.yaml of a directory in testdata/scenarios. Integrations are rendered
+// in IntegrationOrder, as the operator registers them.
+type scenario struct {
+	Cluster struct {
+		ID     string `yaml:"id"`
+		Region string `yaml:"region"`
+		Proxy  string `yaml:"proxy"`
+	} `yaml:"cluster"`
+	Profile struct {
+		FedRAMP    bool `yaml:"fedramp"`
+		Management bool `yaml:"management"`
+	} `yaml:"profile"`
+	// UserWorkload renders the user-workload config instead of the platform config
+	UserWorkload bool                `yaml:"userWorkload"`
+	Integrations integrationFixtures `yaml:"integrations"`
+	Namespaces   struct {
+		Managed  []string          `yaml:"managed"`
+		Teams    map[string]string `yaml:"teams"`
+		Labelled []struct {
+			Name        string `yaml:"name"`
+			MinSeverity string `yaml:"minSeverity"`
+			Team        string `yaml:"team"`
+		} `yaml:"labelled"`
+		HostedControlPlanes []struct {
+			Namespace string `yaml:"namespace"`
+			ClusterID string `yaml:"clusterID"`
+		} `yaml:"hostedControlPlanes"`
+	} `yaml:"namespaces"`
+}
+
+// integrationFixtures are the settings of the integrations of a scenario, keyed like their
+// names in IntegrationOrder.
+type integrationFixtures struct {
+	DeadMansSnitch *urlFixture `yaml:"deadMansSnitch"`
+	OCMAgent       *urlFixture `yaml:"ocmAgent"`
+	Automation     *struct {
+		Routes []struct {
+			Name                string            `yaml:"name"`
+			Receiver            string            `yaml:"receiver"`
+			MatchLabels         map[string]string `yaml:"matchLabels"`
+			Continue            bool              `yaml:"continue"`
+			PagerDutyRoutingKey string            `yaml:"pagerDutyRoutingKey"`
+			WebhookURL          string            `yaml:"webhookURL"`
+		} `yaml:"routes"`
+	} `yaml:"automation"`
+	PagerDuty *struct {
+		RoutingKey      string            `yaml:"routingKey"`
+		TeamRoutingKeys map[string]string `yaml:"teamRoutingKeys"`
+	} `yaml:"pagerDuty"`
+	GoAlert *struct {
+		LowURL  string `yaml:"lowURL"`
+		HighURL string `yaml:"highURL"`
+	} `yaml:"goAlert"`
+	GoAlertHeartbeat *urlFixture `yaml:"goAlertHeartbeat"`
+}
+
+type urlFixture struct {
+	URL string `yaml:"url"`
+}
+
+// byName returns the configured integrations by their name in IntegrationOrder.
+func (f integrationFixtures) byName() map[string]Integration {
+	integrations := map[string]Integration{}
+	if f.DeadMansSnitch != nil {
+		integrations["DeadMansSnitch"] = DeadMansSnitch{URL: f.DeadMansSnitch.URL}
+	}
+	if f.OCMAgent != nil {
+		integrations["OCMAgent"] = OCMAgent{URL: f.OCMAgent.URL}
+	}
+	if f.Automation != nil {
+		var automation Automation
+		for _, route := range f.Automation.Routes {
+			automation.Routes = append(automation.Routes, AutomationRoute(route))
+		}
+		integrations["Automation"] = automation
+	}
+	if f.PagerDuty != nil {
+		integrations["PagerDuty"] = PagerDuty(*f.PagerDuty)
+	}
+	if f.GoAlert != nil {
+		integrations["GoAlert"] = GoAlert(*f.GoAlert)
+	}
+	if f.GoAlertHeartbeat != nil {
+		integrations["GoAlertHeartbeat"] = GoAlertHeartbeat{URL: f.GoAlertHeartbeat.URL}
+	}
+	return integrations
+}
+
+func (s scenario) inputs() Inputs {
+	in := Inputs{
+		Cluster: Cluster(s.Cluster),
+		Profile: Profile(s.Profile),
+		Namespaces: Namespaces{
+			Managed: s.Namespaces.Managed,
+			Teams:   s.Namespaces.Teams,
+		},
+	}
+	for _, ns := range s.Namespaces.Labelled {
+		in.Namespaces.Labelled = append(in.Namespaces.Labelled, Namespace(ns))
+	}
+	for _, hcp := range s.Namespaces.HostedControlPlanes {
+		in.Namespaces.HostedControlPlanes = append(in.Namespaces.HostedControlPlanes, HostedControlPlane(hcp))
+	}
+	integrations := s.Integrations.byName()
+	for _, name := range IntegrationOrder {
+		if integration, ok := integrations[name]; ok {
+			in.Integrations = append(in.Integrations, integration)
+		}
+	}
+	return in
+}
+
+// renderScenario renders the config of the scenario in dir.
+func renderScenario(t *testing.T, dir string) *alertmanager.Config {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "inputs.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s scenario
+	if err := yaml.UnmarshalStrict(data, &s); err != nil {
+		t.Fatalf("invalid %s/inputs.yaml: %v", dir, err)
+	}
+
+	render := Render
+	if s.UserWorkload {
+		render = RenderUserWorkload
+	}
+	cfg, _, err := render(s.inputs())
+	if err != nil {
+		t.Fatalf("unexpected error rendering %s: %v", dir, err)
+	}
+	return cfg
+}
+
+// TestRender_Scenarios renders each scenario and compares it to its alertmanager.yaml. Run
+// `go test ./pkg/render -update` to regenerate them after an intended routing change, and
+// review the diff.
+func TestRender_Scenarios(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "scenarios", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) == 0 {
+		t.Fatal("no scenarios in testdata/scenarios")
+	}
+
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			got, err := yaml.Marshal(renderScenario(t, dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := amconfig.Load(string(got)); err != nil {
+				t.Errorf("Alertmanager rejects the rendered config: %v", err)
+			}
+
+			golden := filepath.Join(dir, "alertmanager.yaml")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o600); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v; run with -update to create it", err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("rendered config differs from %s; run with -update and review the diff:\n%s", golden, lineDiff(string(expected), string(got)))
+			}
+		})
+	}
+}
+
+// lineDiff returns the lines removed from and added to expected, around the first line
+// that differs.
+func lineDiff(expected, got string) string {
+	expectedLines := bytes.Split([]byte(expected), []byte("\n"))
+	gotLines := bytes.Split([]byte(got), []byte("\n"))
+	first := 0
+	for first < len(expectedLines) && first < len(gotLines) && bytes.Equal(expectedLines[first], gotLines[first]) {
+		first++
+	}
+	var diff bytes.Buffer
+	for _, lines := range []struct {
+		prefix string
+		lines  [][]byte
+	}{{"- ", expectedLines}, {"+ ", gotLines}} {
+		for i := first; i < len(lines.lines) && i < first+10; i++ {
+			diff.WriteString(lines.prefix)
+			diff.Write(lines.lines[i])
+			diff.WriteString("\n")
+		}
+	}
+	return diff.String()
+}
@@ -0,0 +1,244 @@
+package render
+
+import (
+	"maps"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Integration is a service alerts are sent to. The built-in integrations are PagerDuty,
+// Automation, GoAlert, GoAlertHeartbeat, DeadMansSnitch and OCMAgent; other packages can
+// add their own by implementing Integration.
+type Integration interface {
+	// Configured is true if the integration has the settings it needs, and warns if not.
+	Configured(r *Renderer) bool
+	// RenderRoutes returns the routes added below the root route, after those of the
+	// integrations before it.
+	RenderRoutes(r *Renderer) []*alertmanager.Route
+	// RenderReceivers returns the receivers the routes send to, named differently from those
+	// of any other integration.
+	RenderReceivers(r *Renderer) []*alertmanager.Receiver
+}
+
+// IntegrationOrder names the built-in integrations in the order the operator adds their
+// routes below the root route. Integrations added outside this package come after them.
+var IntegrationOrder = []string{"DeadMansSnitch", "OCMAgent", "Automation", "PagerDuty", "GoAlert", "GoAlertHeartbeat"}
+
+// PagerDuty pages SRE for alerts from the managed namespaces. Hosted clusters and teams
+// owning namespaces get receivers of their own.
+type PagerDuty struct {
+	RoutingKey string
+	// TeamRoutingKeys maps teams owning namespaces to the routing key of their service.
+	// Teams without one page the RoutingKey service.
+	TeamRoutingKeys map[string]string
+}
+
+// Configured is true with a routing key, and warns about teams without one of their own.
+func (p PagerDuty) Configured(r *Renderer) bool {
+	if p.RoutingKey == "" {
+		r.Warn("PagerDuty", "not configured without a routing key")
+		return false
+	}
+	for _, team := range r.in.Namespaces.teamNames() {
+		if len(p.TeamRoutingKeys) > 0 && p.TeamRoutingKeys[team] == "" {
+			r.Warn("PagerDuty", "team %s has no routing key, paging the default service", team)
+		}
+	}
+	return true
+}
+
+// RenderRoutes routes alerts from the managed namespaces to PagerDuty.
+func (p PagerDuty) RenderRoutes(r *Renderer) []*alertmanager.Route {
+	return []*alertmanager.Route{r.createSubroutes(pagerduty)}
+}
+
+// RenderReceivers creates the PagerDuty receivers, those overriding the severity, and those
+// of the hosted clusters and teams.
+func (p PagerDuty) RenderReceivers(r *Renderer) []*alertmanager.Receiver {
+	receivers := r.createPagerdutyReceivers(p.RoutingKey)
+	receivers = append(receivers, r.createHostedClusterReceivers(p.RoutingKey)...)
+	return append(receivers, r.createTeamReceivers(p.RoutingKey, p.TeamRoutingKeys)...)
+}
+
+// Automation sends alerts with matching labels to automation services, such as CAD, ahead of
+// the paging integrations.
+type Automation struct {
+	Routes []AutomationRoute
+}
+
+// AutomationRoute sends alerts with matching labels to an automation service's PagerDuty
+// service, its webhook, or both.
+type AutomationRoute struct {
+	Name string
+	// Receiver defaults to ReceiverAutomationPrefix followed by the Name
+	Receiver    string
+	MatchLabels map[string]string
+	// Continue routes matching alerts to the routes after this one as well
+	Continue            bool
+	PagerDutyRoutingKey string
+	WebhookURL          string
+}
+
+func (a AutomationRoute) receiver() string {
+	if a.Receiver == "" {
+		return ReceiverAutomationPrefix + a.Name
+	}
+	return a.Receiver
+}
+
+// ready returns the routes with labels to match and somewhere to send alerts. A route
+// without labels would match every alert.
+func (a Automation) ready() []AutomationRoute {
+	var routes []AutomationRoute
+	for _, route := range a.Routes {
+		if len(route.MatchLabels) > 0 && (route.PagerDutyRoutingKey != "" || route.WebhookURL != "") {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// Configured is true if a route has labels to match and somewhere to send alerts, and warns
+// about the routes that don't.
+func (a Automation) Configured(r *Renderer) bool {
+	for _, route := range a.Routes {
+		if len(route.MatchLabels) == 0 {
+			r.Warn("Automation", "skipping route %s without labels to match", route.Name)
+		} else if route.PagerDutyRoutingKey == "" && route.WebhookURL == "" {
+			r.Warn("Automation", "skipping route %s without a PagerDuty routing key or webhook URL", route.Name)
+		}
+	}
+	return len(a.ready()) > 0
+}
+
+// RenderRoutes creates the routes sending alerts to the automation services, in order.
+func (a Automation) RenderRoutes(*Renderer) []*alertmanager.Route {
+	routes := a.ready()
+	amroutes := make([]*alertmanager.Route, 0, len(routes))
+	for _, route := range routes {
+		amroutes = append(amroutes, &alertmanager.Route{
+			Match:    maps.Clone(route.MatchLabels),
+			Receiver: route.receiver(),
+			Continue: route.Continue,
+		})
+	}
+	return amroutes
+}
+
+// RenderReceivers creates a receiver for each automation route, notifying its PagerDuty
+// service, its webhook, or both.
+func (a Automation) RenderReceivers(r *Renderer) []*alertmanager.Receiver {
+	routes := a.ready()
+	receivers := make([]*alertmanager.Receiver, 0, len(routes))
+	for _, route := range routes {
+		receiver := &alertmanager.Receiver{Name: route.receiver()}
+		if route.PagerDutyRoutingKey != "" {
+			receiver.PagerdutyConfigs = []*alertmanager.PagerdutyConfig{r.createPagerdutyConfig(route.PagerDutyRoutingKey)}
+		}
+		if route.WebhookURL != "" {
+			receiver.WebhookConfigs = []*alertmanager.WebhookConfig{createWebhookConfig(route.WebhookURL, r.in.Cluster.Proxy)}
+		}
+		receivers = append(receivers, receiver)
+	}
+	return receivers
+}
+
+// GoAlert sends alerts from the managed namespaces to GoAlert, paging for the high
+// severities only.
+type GoAlert struct {
+	LowURL  string
+	HighURL string
+}
+
+// Configured is true with both a low and a high URL.
+func (g GoAlert) Configured(r *Renderer) bool {
+	if g.LowURL == "" || g.HighURL == "" {
+		r.Warn("GoAlert", "not configured without both a low and a high URL")
+		return false
+	}
+	return true
+}
+
+// RenderRoutes routes alerts from the managed namespaces to GoAlert by severity.
+func (g GoAlert) RenderRoutes(r *Renderer) []*alertmanager.Route {
+	return []*alertmanager.Route{r.createSubroutes(goalert)}
+}
+
+// RenderReceivers creates the low and high GoAlert receivers.
+func (g GoAlert) RenderReceivers(r *Renderer) []*alertmanager.Receiver {
+	receivers := createGoalertReceiver(g.LowURL, ReceiverGoAlertLow, r.in.Cluster.Proxy)
+	return append(receivers, createGoalertReceiver(g.HighURL, ReceiverGoAlertHigh, r.in.Cluster.Proxy)...)
+}
+
+// GoAlertHeartbeat reports that Alertmanager is up to a GoAlert heartbeat monitor.
+type GoAlertHeartbeat struct {
+	URL string
+}
+
+// Configured is true with a URL.
+func (g GoAlertHeartbeat) Configured(r *Renderer) bool {
+	if g.URL == "" {
+		r.Warn("GoAlertHeartbeat", "not configured without a URL")
+		return false
+	}
+	return true
+}
+
+// RenderRoutes sends the Watchdog alert to the heartbeat, continuing to the routes after it.
+func (g GoAlertHeartbeat) RenderRoutes(*Renderer) []*alertmanager.Route {
+	return []*alertmanager.Route{createHeartbeatRoute()}
+}
+
+// RenderReceivers creates the heartbeat receiver.
+func (g GoAlertHeartbeat) RenderReceivers(r *Renderer) []*alertmanager.Receiver {
+	return createHeartbeatReceivers(g.URL, r.in.Cluster.Proxy)
+}
+
+// DeadMansSnitch reports that Alertmanager is up to Dead Man's Snitch.
+type DeadMansSnitch struct {
+	URL string
+}
+
+// Configured is true with a URL.
+func (d DeadMansSnitch) Configured(r *Renderer) bool {
+	if d.URL == "" {
+		r.Warn("DeadMansSnitch", "not configured without a URL")
+		return false
+	}
+	return true
+}
+
+// RenderRoutes sends the Watchdog alert to Dead Man's Snitch, continuing to the routes
+// after it.
+func (d DeadMansSnitch) RenderRoutes(*Renderer) []*alertmanager.Route {
+	return []*alertmanager.Route{createWatchdogRoute()}
+}
+
+// RenderReceivers creates the Dead Man's Snitch receiver.
+func (d DeadMansSnitch) RenderReceivers(r *Renderer) []*alertmanager.Receiver {
+	return createWatchdogReceivers(d.URL, r.in.Cluster.Proxy)
+}
+
+// OCMAgent sends managed notifications to OCM Agent, which sends them to the customer.
+type OCMAgent struct {
+	URL string
+}
+
+// Configured is true with a URL.
+func (o OCMAgent) Configured(r *Renderer) bool {
+	if o.URL == "" {
+		r.Warn("OCMAgent", "not configured without a URL")
+		return false
+	}
+	return true
+}
+
+// RenderRoutes sends managed notifications to OCM Agent, and no further.
+func (o OCMAgent) RenderRoutes(*Renderer) []*alertmanager.Route {
+	return []*alertmanager.Route{createOCMAgentRoute()}
+}
+
+// RenderReceivers creates the OCM Agent receiver.
+func (o OCMAgent) RenderReceivers(*Renderer) []*alertmanager.Receiver {
+	return createOCMAgentReceiver(o.URL)
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// PagingSeverities are the severities that can page, from the highest.
+var PagingSeverities = []string{"critical", "error", "warning"}
+
+// Namespaces are the namespaces whose alerts are routed to the paging integrations.
+type Namespaces struct {
+	// Managed are regular expressions of the namespaces routed to PagerDuty and GoAlert
+	Managed []string
+	// Teams maps entries of Managed to the team owning their alerts
+	Teams map[string]string
+	// Labelled are namespaces with a minimum severity or owning team of their own, routed
+	// ahead of Managed
+	Labelled []Namespace
+	// HostedControlPlanes are paged for with the ID of their hosted cluster, on a management cluster
+	HostedControlPlanes []HostedControlPlane
+}
+
+// Namespace is a namespace with a minimum severity or an owning team.
+type Namespace struct {
+	Name string
+	// MinSeverity is the lowest of PagingSeverities that pages, or empty for all of them
+	MinSeverity string
+	Team        string
+}
+
+// HostedControlPlane is the namespace on a management cluster that runs the control plane of
+// a hosted cluster.
+type HostedControlPlane struct {
+	Namespace string
+	ClusterID string
+}
+
+// receiverName is the name of the PagerDuty receiver for the hosted cluster.
+func (h HostedControlPlane) receiverName() string {
+	return ReceiverPagerDutyHostedClusterPrefix + h.ClusterID
+}
+
+// pagerdutyReceiver is the PagerDuty receiver for the namespace's alerts.
+func (n Namespace) pagerdutyReceiver() string {
+	if n.Team == "" {
+		return ReceiverPagerDuty
+	}
+	return TeamReceiver(n.Team, ReceiverPagerDuty)
+}
+
+// severities returns the alert severities that page for the namespace.
+func (n Namespace) severities() []string {
+	if n.MinSeverity == "" {
+		return PagingSeverities
+	}
+	return PagingSeverities[:slices.Index(PagingSeverities, n.MinSeverity)+1]
+}
+
+// severityMatchRE matches the alert severities that page for the namespace, if it has a minimum.
+func (n Namespace) severityMatchRE() map[string]string {
+	if n.MinSeverity == "" {
+		return nil
+	}
+	return map[string]string{"severity": strings.Join(n.severities(), "|")}
+}
+
+// teamNames returns the teams owning namespaces, sorted.
+func (n Namespaces) teamNames() []string {
+	var teams []string
+	for _, namespace := range n.Labelled {
+		if namespace.Team != "" && !slices.Contains(teams, namespace.Team) {
+			teams = append(teams, namespace.Team)
+		}
+	}
+	for _, team := range n.Teams {
+		if !slices.Contains(teams, team) {
+			teams = append(teams, team)
+		}
+	}
+	slices.Sort(teams)
+	return teams
+}
+
+// owner returns the team owning a namespace, from its labels or a Managed entry matching it.
+func (n Namespaces) owner(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	for _, labelled := range n.Labelled {
+		if labelled.Name == namespace && labelled.Team != "" {
+			return labelled.Team
+		}
+	}
+	for _, namespaceRE := range slices.Sorted(maps.Keys(n.Teams)) {
+		// match_re is anchored by Alertmanager
+		if matched, _ := regexp.MatchString("^(?:"+namespaceRE+")$", namespace); matched {
+			return n.Teams[namespaceRE]
+		}
+	}
+	return ""
+}
+
+// hostedControlPlaneNamespaceRegexes returns the HostedControlPlane namespaces as regular
+// expressions, in the format used for managed namespaces.
+func (n Namespaces) hostedControlPlaneNamespaceRegexes() []string {
+	namespaces := []string{}
+	for _, hcp := range n.HostedControlPlanes {
+		namespaces = append(namespaces, "^"+regexp.QuoteMeta(hcp.Namespace)+"$")
+	}
+	return namespaces
+}
+
+// createLabelledNamespaceRoutes routes alerts from the namespaces that have a minimum
+// severity or owning team. Alerts below the minimum severity go to the null receiver, so
+// they aren't paged by a broader managed namespace regex.
+func createLabelledNamespaceRoutes(namespaces []Namespace, receiver receiverType) []*alertmanager.Route {
+	routes := []*alertmanager.Route{}
+	for _, namespace := range namespaces {
+		switch receiver {
+		case pagerduty:
+			routes = append(routes,
+				&alertmanager.Route{Receiver: namespace.pagerdutyReceiver(), MatchRE: namespace.severityMatchRE(), Match: map[string]string{"exported_namespace": namespace.Name, "prometheus": "openshift-monitoring/k8s"}},
+				&alertmanager.Route{Receiver: namespace.pagerdutyReceiver(), MatchRE: namespace.severityMatchRE(), Match: map[string]string{"namespace": namespace.Name, "exported_namespace": "", "prometheus": "openshift-monitoring/k8s"}},
+			)
+		case goalert:
+			goalertReceivers := map[string]string{"critical": ReceiverGoAlertHigh, "error": ReceiverGoAlertHigh, "warning": ReceiverGoAlertLow}
+			for _, severity := range namespace.severities() {
+				routes = append(routes, &alertmanager.Route{Receiver: goalertReceivers[severity], Match: map[string]string{"namespace": namespace.Name, "exported_namespace": "", "prometheus": "openshift-monitoring/k8s", "severity": severity}})
+			}
+		}
+		if namespace.MinSeverity != "" {
+			routes = append(routes,
+				&alertmanager.Route{Receiver: ReceiverNull, Match: map[string]string{"exported_namespace": namespace.Name}},
+				&alertmanager.Route{Receiver: ReceiverNull, Match: map[string]string{"namespace": namespace.Name, "exported_namespace": ""}},
+			)
+		}
+	}
+	return routes
+}
+
+// createHostedClusterRoutes routes alerts from each HostedControlPlane namespace to the
+// PagerDuty receiver of its hosted cluster. Like the routes for managed namespaces, an alert
+// is matched on exported_namespace if it has one, and on namespace otherwise.
+func createHostedClusterRoutes(hostedControlPlanes []HostedControlPlane) []*alertmanager.Route {
+	routes := []*alertmanager.Route{}
+	for _, hcp := range hostedControlPlanes {
+		routes = append(routes,
+			&alertmanager.Route{Receiver: hcp.receiverName(), Match: map[string]string{"exported_namespace": hcp.Namespace, "prometheus": "openshift-monitoring/k8s"}},
+			&alertmanager.Route{Receiver: hcp.receiverName(), Match: map[string]string{"namespace": hcp.Namespace, "exported_namespace": "", "prometheus": "openshift-monitoring/k8s"}},
+		)
+	}
+	return routes
+}
+
+// createHostedClusterReceivers creates a PagerDuty receiver for each hosted cluster, which
+// adds its ID to the incident details next to the management cluster's.
+func (r *Renderer) createHostedClusterReceivers(pagerdutyRoutingKey string) []*alertmanager.Receiver {
+	receivers := []*alertmanager.Receiver{}
+	if pagerdutyRoutingKey == "" {
+		return receivers
+	}
+
+	seen := map[string]bool{}
+	for _, hcp := range r.in.Namespaces.HostedControlPlanes {
+		// a hosted cluster may have more than one namespace
+		if seen[hcp.ClusterID] {
+			continue
+		}
+		seen[hcp.ClusterID] = true
+
+		pdconfig := r.createPagerdutyConfig(pagerdutyRoutingKey)
+		pdconfig.Details["hosted_cluster_id"] = hcp.ClusterID
+		if r.in.Profile.FedRAMP {
+			// cluster IDs are blanked like the management cluster's
+			pdconfig.Details["hosted_cluster_id"] = ``
+		}
+		receivers = append(receivers, &alertmanager.Receiver{
+			Name:             hcp.receiverName(),
+			PagerdutyConfigs: []*alertmanager.PagerdutyConfig{pdconfig},
+		})
+	}
+	return receivers
+}
+
+// createTeamReceivers creates the PagerDuty receivers for each team owning namespaces: one
+// adding the team to the incident details, and one for each severity override like
+// createPagerdutyReceivers. They use the team's routing key, or the default one if the team
+// has none.
+func (r *Renderer) createTeamReceivers(pagerdutyRoutingKey string, teamRoutingKeys map[string]string) []*alertmanager.Receiver {
+	receivers := []*alertmanager.Receiver{}
+	if pagerdutyRoutingKey == "" {
+		return receivers
+	}
+
+	for _, team := range r.in.Namespaces.teamNames() {
+		routingKey := teamRoutingKeys[team]
+		if routingKey == "" {
+			routingKey = pagerdutyRoutingKey
+		}
+		for _, receiver := range r.createPagerdutyReceivers(routingKey) {
+			receiver.Name = TeamReceiver(team, receiver.Name)
+			receiver.PagerdutyConfigs[0].Details["team"] = team
+			receivers = append(receivers, receiver)
+		}
+	}
+	return receivers
+}
@@ -0,0 +1,255 @@
+package render
+
+import (
+	"fmt"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+const (
+	// High alerts for GoAlert. These alerts will page
+	ReceiverGoAlertHigh = "goalert-high"
+
+	// Low alerts for GoAlert. These alerts will not page
+	ReceiverGoAlertLow = "goalert"
+
+	// GoAlert cluster heartbeat monitor
+	ReceiverGoAlertHeartbeat = "goalert-heartbeat"
+
+	// anything routed to "null" receiver does not get routed to PD or GoAlert
+	ReceiverNull = "null"
+
+	// anything routed to "make-it-warning" receiver has severity=warning
+	ReceiverMakeItWarning = "make-it-warning"
+
+	// anything routed to "make-it-error" receiver has severity=error
+	ReceiverMakeItError = "make-it-error"
+
+	// anything routed to "make-it-critical" receiver has severity=critical
+	ReceiverMakeItCritical = "make-it-critical"
+
+	// anything routed to "pagerduty" will alert/notify SREP
+	ReceiverPagerDuty = "pagerduty"
+
+	// prefix of the PagerDuty receiver for each owning team, followed by the team
+	ReceiverPagerDutyTeamPrefix = "pagerduty-team-"
+
+	// prefix of the PagerDuty receiver for each hosted cluster, followed by its ID
+	ReceiverPagerDutyHostedClusterPrefix = "pagerduty-hosted-"
+
+	// receivers of automation routes without a receiver are named after the route
+	ReceiverAutomationPrefix = "automation-"
+
+	// anything going to Dead Man's Snitch (watchdog)
+	ReceiverWatchdog = "watchdog"
+
+	// anything routed to "ocmagent" will not alert/notify SREP and will be handled by OCM Agent
+	ReceiverOCMAgent = "ocmagent"
+
+	// the default receiver used by the route used for pagerduty
+	defaultReceiver = ReceiverNull
+
+	// global config for PagerdutyURL
+	PagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+	// alert label used for identifying OCM Agent-bound alerts
+	ManagedNotificationLabel = "send_managed_notification"
+)
+
+// TeamReceiver returns the team's counterpart of a PagerDuty receiver: pagerduty itself,
+// or one of the receivers overriding the severity.
+func TeamReceiver(team, receiver string) string {
+	if receiver == ReceiverPagerDuty {
+		return ReceiverPagerDutyTeamPrefix + team
+	}
+	return ReceiverPagerDutyTeamPrefix + team + "-" + receiver
+}
+
+// HTTPConfig creates a HttpConfig used for receivers that can accept that configuration
+func HTTPConfig(clusterProxy string) alertmanager.HttpConfig {
+	if clusterProxy == "" {
+		return alertmanager.HttpConfig{}
+	}
+	return alertmanager.HttpConfig{
+		ProxyURL:  clusterProxy,
+		TLSConfig: alertmanager.TLSConfig{},
+	}
+}
+
+// createPagerdutyConfig creates an AlertManager PagerdutyConfig for PagerDuty in memory.
+func (r *Renderer) createPagerdutyConfig(pagerdutyRoutingKey string) *alertmanager.PagerdutyConfig {
+	cluster := r.in.Cluster
+	detailsMap := map[string]string{
+		"alert_name":   `{{ .CommonLabels.alertname }}`,
+		"link":         `{{ if .CommonAnnotations.runbook_url }}{{ .CommonAnnotations.runbook_url }}{{ else if .CommonAnnotations.link }}{{ .CommonAnnotations.link }}{{ else if .CommonLabels.link }}{{ .CommonLabels.link }}{{ else }}https://github.com/openshift/ops-sop/tree/master/v4/alerts/{{ .CommonLabels.alertname }}.md{{ end }}`,
+		"ocm_link":     fmt.Sprintf("https://console.redhat.com/openshift/details/%s", cluster.ID),
+		"num_firing":   `{{ .Alerts.Firing | len }}`,
+		"num_resolved": `{{ .Alerts.Resolved | len }}`,
+		"resolved":     `{{ template "pagerduty.default.instances" .Alerts.Resolved }}`,
+		"cluster_id":   cluster.ID,
+		"region":       cluster.Region,
+	}
+	clientURL := `{{ template "pagerduty.default.clientURL" . }}`
+
+	if r.in.Profile.FedRAMP {
+		detailsMap["ocm_link"] = ``
+		detailsMap["resolved"] = ``
+		detailsMap["cluster_id"] = ``
+		detailsMap["region"] = ``
+		detailsMap["firing"] = ``
+
+		// The default value contains the cluster name which is considered sensitive
+		// information for FedRAMP, so setting it to "ROSA"
+		clientURL = "ROSA"
+	}
+
+	return &alertmanager.PagerdutyConfig{
+		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
+		RoutingKey:     pagerdutyRoutingKey,
+		Severity:       `{{ if .CommonLabels.severity }}{{ .CommonLabels.severity | toLower }}{{ else }}critical{{ end }}`,
+		ClientURL:      clientURL,
+		Description:    `{{ .CommonLabels.alertname }} {{ .CommonLabels.severity | toUpper }} ({{ len .Alerts }})`,
+		Details:        detailsMap,
+		HttpConfig:     HTTPConfig(cluster.Proxy),
+	}
+}
+
+// createPagerdutyReceivers creates an AlertManager Receiver for PagerDuty in memory.
+func (r *Renderer) createPagerdutyReceivers(pagerdutyRoutingKey string) []*alertmanager.Receiver {
+	if pagerdutyRoutingKey == "" {
+		return []*alertmanager.Receiver{}
+	}
+
+	receivers := []*alertmanager.Receiver{
+		{
+			Name:             ReceiverPagerDuty,
+			PagerdutyConfigs: []*alertmanager.PagerdutyConfig{r.createPagerdutyConfig(pagerdutyRoutingKey)},
+		},
+	}
+
+	// make-it-warning overrides the severity
+	pdconfig := r.createPagerdutyConfig(pagerdutyRoutingKey)
+	pdconfig.Severity = "warning"
+	receivers = append(receivers, &alertmanager.Receiver{
+		Name:             ReceiverMakeItWarning,
+		PagerdutyConfigs: []*alertmanager.PagerdutyConfig{pdconfig},
+	})
+
+	// make-it-error overrides the severity
+	highpdconfig := r.createPagerdutyConfig(pagerdutyRoutingKey)
+	highpdconfig.Severity = "error"
+	receivers = append(receivers, &alertmanager.Receiver{
+		Name:             ReceiverMakeItError,
+		PagerdutyConfigs: []*alertmanager.PagerdutyConfig{highpdconfig},
+	})
+
+	// make-it-critical overrides the severity
+	criticalpdconfig := r.createPagerdutyConfig(pagerdutyRoutingKey)
+	criticalpdconfig.Severity = "critical"
+	receivers = append(receivers, &alertmanager.Receiver{
+		Name:             ReceiverMakeItCritical,
+		PagerdutyConfigs: []*alertmanager.PagerdutyConfig{criticalpdconfig},
+	})
+
+	return receivers
+}
+
+// createWebhookConfig creates an AlertManager WebhookConfig sent through the cluster proxy.
+func createWebhookConfig(url, clusterProxy string) *alertmanager.WebhookConfig {
+	return &alertmanager.WebhookConfig{
+		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
+		URL:            url,
+		HttpConfig:     HTTPConfig(clusterProxy),
+	}
+}
+
+func createGoalertReceiver(goalertURL, goalertReceiverName, clusterProxy string) []*alertmanager.Receiver {
+	if goalertURL == "" {
+		return []*alertmanager.Receiver{}
+	}
+
+	receivers := []*alertmanager.Receiver{
+		{
+			Name:           goalertReceiverName,
+			WebhookConfigs: []*alertmanager.WebhookConfig{createWebhookConfig(goalertURL, clusterProxy)},
+		},
+	}
+
+	return receivers
+}
+
+// creatHeartbeatRoute creates an AlertManager Route for GoAlert Heartbeat in memory.
+func createHeartbeatRoute() *alertmanager.Route {
+	return &alertmanager.Route{
+		Receiver:       ReceiverGoAlertHeartbeat,
+		RepeatInterval: "5m",
+		Match:          map[string]string{"alertname": "Watchdog"},
+		Continue:       true,
+	}
+}
+
+// createWatchdogRoute creates an AlertManager Route for Watchdog (Dead Man's Snitch) in memory.
+func createWatchdogRoute() *alertmanager.Route {
+	return &alertmanager.Route{
+		Receiver:       ReceiverWatchdog,
+		RepeatInterval: "5m",
+		Match:          map[string]string{"alertname": "Watchdog"},
+		Continue:       true,
+	}
+}
+
+func createHeartbeatReceivers(heartbeatURL string, clusterProxy string) []*alertmanager.Receiver {
+	if heartbeatURL == "" {
+		return []*alertmanager.Receiver{}
+	}
+
+	return []*alertmanager.Receiver{
+		{
+			Name:           ReceiverGoAlertHeartbeat,
+			WebhookConfigs: []*alertmanager.WebhookConfig{createWebhookConfig(heartbeatURL, clusterProxy)},
+		},
+	}
+}
+
+// createWatchdogReceivers creates an AlertManager Receiver for Watchdog (Dead Man's Snitch) in memory.
+func createWatchdogReceivers(watchdogURL string, clusterProxy string) []*alertmanager.Receiver {
+	if watchdogURL == "" {
+		return []*alertmanager.Receiver{}
+	}
+
+	return []*alertmanager.Receiver{
+		{
+			Name:           ReceiverWatchdog,
+			WebhookConfigs: []*alertmanager.WebhookConfig{createWebhookConfig(watchdogURL, clusterProxy)},
+		},
+	}
+}
+
+// createOCMAgentRoute creates an AlertManager Route for OcmAgent in memory.
+func createOCMAgentRoute() *alertmanager.Route {
+	return &alertmanager.Route{
+		Receiver:       ReceiverOCMAgent,
+		Continue:       false,
+		Match:          map[string]string{ManagedNotificationLabel: "true"},
+		RepeatInterval: "10m",
+	}
+}
+
+// createOCMAgentReceiver creates an AlertManager Receiver for OCM Agent in memory.
+func createOCMAgentReceiver(ocmAgentURL string) []*alertmanager.Receiver {
+	if ocmAgentURL == "" {
+		return []*alertmanager.Receiver{}
+	}
+
+	ocmAgentConfig := &alertmanager.WebhookConfig{
+		NotifierConfig: alertmanager.NotifierConfig{VSendResolved: true},
+		URL:            ocmAgentURL,
+	}
+
+	return []*alertmanager.Receiver{
+		{
+			Name:           ReceiverOCMAgent,
+			WebhookConfigs: []*alertmanager.WebhookConfig{ocmAgentConfig},
+		},
+	}
+}
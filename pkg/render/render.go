@@ -0,0 +1,280 @@
+// Package render builds the Alertmanager configs written by the operator from a typed
+// description of the cluster, its integrations and its namespaces. It has no Kubernetes
+// dependency, so tools other than the operator can render the config a cluster would get.
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Inputs are everything a config is rendered from.
+type Inputs struct {
+	Cluster Cluster
+	Profile Profile
+	// Integrations are the services alerts are sent to. Their routes are added to the root
+	// route in order.
+	Integrations []Integration
+	Namespaces   Namespaces
+}
+
+// Cluster identifies the cluster in notifications, and the proxy they are sent through.
+type Cluster struct {
+	ID     string
+	Region string
+	// Proxy is the URL of the cluster-wide proxy, if any
+	Proxy string
+}
+
+// Profile is the kind of cluster the config is rendered for.
+type Profile struct {
+	// FedRAMP clusters leave cluster details out of notifications, and keep alerts for
+	// components that aren't available there.
+	FedRAMP bool
+	// Management clusters page for the hosted clusters whose control planes they run.
+	Management bool
+}
+
+// Warning is an input that was ignored, or used in a way that may not be intended.
+type Warning struct {
+	// Input names the integration or input the warning is about
+	Input   string
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Input + ": " + w.Message
+}
+
+// Renderer holds the inputs of one render and the warnings it found. It is passed to the
+// integrations being rendered.
+type Renderer struct {
+	in       Inputs
+	warnings []Warning
+}
+
+// newRenderer checks the inputs. Hosted control planes on a cluster that isn't a management
+// cluster and unknown minimum severities are dropped with a warning.
+func newRenderer(in Inputs) (*Renderer, error) {
+	r := &Renderer{in: in}
+	for _, namespaceRE := range in.Namespaces.Managed {
+		if _, err := regexp.Compile(namespaceRE); err != nil {
+			return nil, fmt.Errorf("invalid managed namespace regular expression %q: %w", namespaceRE, err)
+		}
+	}
+	for namespaceRE := range in.Namespaces.Teams {
+		if _, err := regexp.Compile(namespaceRE); err != nil {
+			return nil, fmt.Errorf("invalid team namespace regular expression %q: %w", namespaceRE, err)
+		}
+	}
+
+	if len(in.Namespaces.HostedControlPlanes) > 0 && !in.Profile.Management {
+		r.Warn("Namespaces", "ignoring %d hosted control planes, since this isn't a management cluster", len(in.Namespaces.HostedControlPlanes))
+		r.in.Namespaces.HostedControlPlanes = nil
+	}
+	r.in.Namespaces.Labelled = slices.Clone(in.Namespaces.Labelled)
+	for i, namespace := range r.in.Namespaces.Labelled {
+		if namespace.MinSeverity != "" && !slices.Contains(PagingSeverities, namespace.MinSeverity) {
+			r.Warn("Namespaces", "ignoring unknown minimum severity %q of namespace %s", namespace.MinSeverity, namespace.Name)
+			r.in.Namespaces.Labelled[i].MinSeverity = ""
+		}
+	}
+	return r, nil
+}
+
+// Inputs returns the inputs being rendered, after the checks of newRenderer.
+func (r *Renderer) Inputs() Inputs {
+	return r.in
+}
+
+// Warn reports that input was ignored, or used in a way that may not be intended.
+func (r *Renderer) Warn(input, format string, args ...any) {
+	r.warnings = append(r.warnings, Warning{Input: input, Message: fmt.Sprintf(format, args...)})
+}
+
+// Render renders the config of the platform Alertmanager. It returns an error if the inputs
+// can't be rendered into a valid config.
+func Render(in Inputs) (*alertmanager.Config, []Warning, error) {
+	r, err := newRenderer(in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	routes := []*alertmanager.Route{}
+	receivers := []*alertmanager.Receiver{}
+	for _, integration := range in.Integrations {
+		if integration == nil || !integration.Configured(r) {
+			continue
+		}
+		routes = append(routes, integration.RenderRoutes(r)...)
+		receivers = append(receivers, integration.RenderReceivers(r)...)
+	}
+
+	// always have the "null" receiver
+	receivers = append(receivers, &alertmanager.Receiver{Name: ReceiverNull})
+	if err := checkReceiverNames(receivers); err != nil {
+		return nil, nil, err
+	}
+	return createConfig(routes, receivers, platformInhibitRules()), r.warnings, nil
+}
+
+// checkReceiverNames returns an error if two integrations render a receiver of the same name,
+// such as when an integration is configured twice.
+func checkReceiverNames(receivers []*alertmanager.Receiver) error {
+	seen := map[string]bool{}
+	for _, receiver := range receivers {
+		if seen[receiver.Name] {
+			return fmt.Errorf("receiver %q is rendered more than once", receiver.Name)
+		}
+		seen[receiver.Name] = true
+	}
+	return nil
+}
+
+// createConfig creates an Alertmanager Config with the given routes below the root route.
+func createConfig(routes []*alertmanager.Route, receivers []*alertmanager.Receiver, inhibitRules []*alertmanager.InhibitRule) *alertmanager.Config {
+	return &alertmanager.Config{
+		Global: &alertmanager.GlobalConfig{
+			ResolveTimeout: "5m",
+			PagerdutyURL:   PagerDutyURL,
+		},
+		Route: &alertmanager.Route{
+			Receiver: defaultReceiver,
+			GroupByStr: []string{
+				"job",
+			},
+			GroupWait:      "30s",
+			GroupInterval:  "5m",
+			RepeatInterval: "12h",
+			Routes:         routes,
+		},
+		Receivers:    receivers,
+		Templates:    []string{},
+		InhibitRules: inhibitRules,
+	}
+}
+
+// platformInhibitRules keep related alerts on the platform from notifying more than once.
+func platformInhibitRules() []*alertmanager.InhibitRule {
+	// Work request: https://issues.redhat.com/browse/OSD-4623
+	// Reference: https://github.com/openshift/cluster-monitoring-operator/blob/6a02b14773169330d7a31ede73dce5adb1c66bb4/assets/alertmanager/secret.yaml
+	return []*alertmanager.InhibitRule{
+		{
+			// Critical alert shouldn't also alert for warning/info
+			Equal: []string{
+				"namespace",
+				"alertname",
+			},
+			SourceMatch: map[string]string{
+				"severity": "critical",
+			},
+			TargetMatchRE: map[string]string{
+				"severity": "warning|info",
+			},
+		},
+		{
+			// Warning alerts shouldn't also alert for info
+			Equal: []string{
+				"namespace",
+				"alertname",
+			},
+			SourceMatch: map[string]string{
+				"severity": "warning",
+			},
+			TargetMatchRE: map[string]string{
+				"severity": "info",
+			},
+		},
+		{
+			// If a cluster operator is degraded, don't also fire ClusterOperatorDown
+			// The degraded alert is critical, and usually has more details
+			Equal: []string{
+				"namespace",
+				"name",
+			},
+			SourceMatch: map[string]string{
+				"alertname": "ClusterOperatorDegraded",
+				"severity":  "critical",
+			},
+			TargetMatchRE: map[string]string{
+				"alertname": "ClusterOperatorDown",
+			},
+		},
+		{
+			// If a node is not ready, we already know it's Unreachable
+			Equal: []string{
+				"node",
+				"instance",
+			},
+			SourceMatch: map[string]string{
+				"alertname": "KubeNodeNotReady",
+			},
+			TargetMatchRE: map[string]string{
+				"alertname": "KubeNodeUnreachable",
+			},
+		},
+		{
+			// node being Unreachable may also trigger certain pods being unavailable
+			SourceMatch: map[string]string{
+				"alertname": "KubeNodeUnreachable",
+			},
+			TargetMatchRE: map[string]string{
+				"alertname": "SDNPodNotReady|TargetDown",
+			},
+		},
+		{
+			// If a node is NotReady, then we also know that there will be pods that aren't running
+			Equal: []string{
+				"instance",
+			},
+			SourceMatch: map[string]string{
+				"alertname": "KubeNodeNotReady",
+			},
+			TargetMatchRE: map[string]string{
+				"alertname": "KubeDaemonSetRolloutStuck|KubeDaemonSetMisScheduled|KubeDeploymentReplicasMismatch|KubeStatefulSetReplicasMismatch|KubePodNotReady",
+			},
+		},
+		{
+			// If a deployment doesn't have it's replicas mismatch, then we don't need to fire for the pod not being ready
+			Equal: []string{
+				"namespace",
+			},
+			SourceMatch: map[string]string{
+				"alertname": "KubeDeploymentReplicasMismatch",
+			},
+			TargetMatchRE: map[string]string{
+				"alertname": "KubePodNotReady|KubePodCrashLooping",
+			},
+		},
+		{
+			// NB this label obviously won't match and that's both ok and expected. When a label is missing (or empty) on both source and target, the rule will apply (see: docs ).
+			// see: https://www.prometheus.io/docs/alerting/latest/configuration/#inhibit_rule
+
+			// If there wasn't a label here, the tests exploded spectacularly, so I figured a label that would never match is the next best thing.
+			Equal: []string{
+				"dummylabel",
+			},
+			SourceMatch: map[string]string{
+				"alertname": "ElasticsearchOperatorCSVNotSuccessful",
+			},
+			TargetMatchRE: map[string]string{
+				"alertname": "ElasticsearchClusterNotHealthy",
+			},
+		},
+		// https://issues.redhat.com/browse/OSD-13721
+		{
+			Equal: []string{
+				"severity",
+			},
+			SourceMatch: map[string]string{
+				"alertname": "KubeAPIErrorBudgetBurn",
+			},
+			TargetMatchRE: map[string]string{
+				"alertname": "api-ErrorBudgetBurn",
+			},
+		},
+	}
+}
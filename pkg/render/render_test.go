@@ -0,0 +1,536 @@
+package render
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+func receiverNames(receivers []*alertmanager.Receiver) []string {
+	var names []string
+	for _, receiver := range receivers {
+		names = append(names, receiver.Name)
+	}
+	return names
+}
+
+func routeReceivers(routes []*alertmanager.Route) []string {
+	var receivers []string
+	for _, route := range routes {
+		receivers = append(receivers, route.Receiver)
+	}
+	return receivers
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        Inputs
+		routes    []string
+		receivers []string
+	}{
+		{
+			name:      "only the null receiver without integrations",
+			in:        Inputs{Namespaces: Namespaces{Managed: []string{"^openshift-.*"}}},
+			receivers: []string{ReceiverNull},
+		},
+		{
+			name: "integrations are routed in order",
+			in: Inputs{
+				Integrations: []Integration{
+					DeadMansSnitch{URL: "https://dms"},
+					OCMAgent{URL: "https://ocm-agent"},
+					PagerDuty{RoutingKey: "pdkey"},
+					GoAlert{LowURL: "https://goalert/low", HighURL: "https://goalert/high"},
+					GoAlertHeartbeat{URL: "https://goalert/heartbeat"},
+				},
+				Namespaces: Namespaces{Managed: []string{"^openshift-.*"}},
+			},
+			routes: []string{ReceiverWatchdog, ReceiverOCMAgent, ReceiverNull, ReceiverNull, ReceiverGoAlertHeartbeat},
+			receivers: []string{ReceiverWatchdog, ReceiverOCMAgent, ReceiverPagerDuty, ReceiverMakeItWarning, ReceiverMakeItError, ReceiverMakeItCritical,
+				ReceiverGoAlertLow, ReceiverGoAlertHigh, ReceiverGoAlertHeartbeat, ReceiverNull},
+		},
+		{
+			name: "integrations without settings and nil integrations are left out",
+			in: Inputs{
+				Integrations: []Integration{nil, PagerDuty{}, GoAlert{LowURL: "https://goalert/low"}, DeadMansSnitch{}},
+			},
+			receivers: []string{ReceiverNull},
+		},
+		{
+			name: "automation routes ahead of PagerDuty",
+			in: Inputs{
+				Integrations: []Integration{
+					Automation{Routes: []AutomationRoute{
+						{Name: "cad", Receiver: "pagerduty-cad", MatchLabels: map[string]string{"route_to_cad": "true"}, PagerDutyRoutingKey: "cadkey"},
+						{Name: "remediation", MatchLabels: map[string]string{"route_to_remediation": "true"}, WebhookURL: "https://remediation"},
+					}},
+					PagerDuty{RoutingKey: "pdkey"},
+				},
+			},
+			routes:    []string{"pagerduty-cad", ReceiverAutomationPrefix + "remediation", ReceiverNull},
+			receivers: []string{"pagerduty-cad", ReceiverAutomationPrefix + "remediation", ReceiverPagerDuty, ReceiverMakeItWarning, ReceiverMakeItError, ReceiverMakeItCritical, ReceiverNull},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amconfig, _, err := Render(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := routeReceivers(amconfig.Route.Routes); !reflect.DeepEqual(got, tt.routes) {
+				t.Errorf("expected routes to %v, got %v", tt.routes, got)
+			}
+			if got := receiverNames(amconfig.Receivers); !reflect.DeepEqual(got, tt.receivers) {
+				t.Errorf("expected receivers %v, got %v", tt.receivers, got)
+			}
+			if amconfig.Route.Receiver != ReceiverNull || amconfig.Global.PagerdutyURL != PagerDutyURL {
+				t.Errorf("expected the root route to the null receiver and the global PagerDuty URL, got %s and %s", amconfig.Route.Receiver, amconfig.Global.PagerdutyURL)
+			}
+			if len(amconfig.InhibitRules) != len(platformInhibitRules()) {
+				t.Errorf("expected the platform inhibit rules, got %d", len(amconfig.InhibitRules))
+			}
+		})
+	}
+}
+
+func TestRender_Warnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       Inputs
+		expected []Warning
+	}{
+		{
+			name:     "integrations without settings",
+			in:       Inputs{Integrations: []Integration{PagerDuty{}, GoAlert{HighURL: "https://goalert/high"}, OCMAgent{}}},
+			expected: []Warning{{Input: "PagerDuty", Message: "not configured without a routing key"}, {Input: "GoAlert", Message: "not configured without both a low and a high URL"}, {Input: "OCMAgent", Message: "not configured without a URL"}},
+		},
+		{
+			name: "hosted control planes on a cluster that isn't a management cluster",
+			in: Inputs{
+				Integrations: []Integration{PagerDuty{RoutingKey: "pdkey"}},
+				Namespaces:   Namespaces{HostedControlPlanes: []HostedControlPlane{{Namespace: "ocm-production-abc-hcp", ClusterID: "abc"}}},
+			},
+			expected: []Warning{{Input: "Namespaces", Message: "ignoring 1 hosted control planes, since this isn't a management cluster"}},
+		},
+		{
+			name: "unknown minimum severity",
+			in: Inputs{
+				Integrations: []Integration{PagerDuty{RoutingKey: "pdkey"}},
+				Namespaces:   Namespaces{Labelled: []Namespace{{Name: "noisy-operator", MinSeverity: "info"}}},
+			},
+			expected: []Warning{{Input: "Namespaces", Message: `ignoring unknown minimum severity "info" of namespace noisy-operator`}},
+		},
+		{
+			name: "team without a routing key",
+			in: Inputs{
+				Integrations: []Integration{PagerDuty{RoutingKey: "pdkey", TeamRoutingKeys: map[string]string{"storage": "storagekey"}}},
+				Namespaces:   Namespaces{Labelled: []Namespace{{Name: "storage-csi", Team: "storage"}, {Name: "payments-api", Team: "payments"}}},
+			},
+			expected: []Warning{{Input: "PagerDuty", Message: "team payments has no routing key, paging the default service"}},
+		},
+		{
+			name: "incomplete automation routes",
+			in: Inputs{Integrations: []Integration{Automation{Routes: []AutomationRoute{
+				{Name: "everything", PagerDutyRoutingKey: "key"},
+				{Name: "nowhere", MatchLabels: map[string]string{"route_to_nowhere": "true"}},
+			}}}},
+			expected: []Warning{{Input: "Automation", Message: "skipping route everything without labels to match"}, {Input: "Automation", Message: "skipping route nowhere without a PagerDuty routing key or webhook URL"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, warnings, err := Render(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(warnings, tt.expected) {
+				t.Errorf("expected warnings %v, got %v", tt.expected, warnings)
+			}
+		})
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Inputs
+	}{
+		{
+			name: "invalid managed namespace regular expression",
+			in:   Inputs{Namespaces: Namespaces{Managed: []string{"openshift-(.*"}}},
+		},
+		{
+			name: "invalid team namespace regular expression",
+			in:   Inputs{Namespaces: Namespaces{Managed: []string{"^a$"}, Teams: map[string]string{"payments-[": "payments"}}},
+		},
+		{
+			name: "integration configured twice",
+			in:   Inputs{Integrations: []Integration{PagerDuty{RoutingKey: "a"}, PagerDuty{RoutingKey: "b"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if amconfig, _, err := Render(tt.in); err == nil {
+				t.Errorf("expected an error, got a config with receivers %v", receiverNames(amconfig.Receivers))
+			}
+		})
+	}
+}
+
+// ticketing is an integration implemented outside the built-in ones.
+type ticketing struct {
+	URL string
+}
+
+func (t ticketing) Configured(r *Renderer) bool {
+	if t.URL == "" {
+		r.Warn("Ticketing", "not configured without a URL")
+		return false
+	}
+	return true
+}
+
+func (t ticketing) RenderRoutes(*Renderer) []*alertmanager.Route {
+	return []*alertmanager.Route{{Receiver: "ticketing", Match: map[string]string{"ticket": "true"}}}
+}
+
+func (t ticketing) RenderReceivers(r *Renderer) []*alertmanager.Receiver {
+	return []*alertmanager.Receiver{{
+		Name:           "ticketing",
+		WebhookConfigs: []*alertmanager.WebhookConfig{{URL: t.URL, HttpConfig: HTTPConfig(r.Inputs().Cluster.Proxy)}},
+	}}
+}
+
+func TestRender_CustomIntegration(t *testing.T) {
+	amconfig, warnings, err := Render(Inputs{
+		Cluster:      Cluster{Proxy: "https://proxy"},
+		Integrations: []Integration{DeadMansSnitch{URL: "https://dms"}, ticketing{URL: "https://tickets"}, ticketing{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{ReceiverWatchdog, "ticketing"}; !reflect.DeepEqual(routeReceivers(amconfig.Route.Routes), expected) {
+		t.Errorf("expected routes to %v, got %v", expected, routeReceivers(amconfig.Route.Routes))
+	}
+	if expected := []string{ReceiverWatchdog, "ticketing", ReceiverNull}; !reflect.DeepEqual(receiverNames(amconfig.Receivers), expected) {
+		t.Errorf("expected receivers %v, got %v", expected, receiverNames(amconfig.Receivers))
+	}
+	if proxy := amconfig.Receivers[1].WebhookConfigs[0].HttpConfig.ProxyURL; proxy != "https://proxy" {
+		t.Errorf("expected the ticketing webhook to use the cluster proxy, got %q", proxy)
+	}
+	if expected := []Warning{{Input: "Ticketing", Message: "not configured without a URL"}}; !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected warnings %v, got %v", expected, warnings)
+	}
+}
+
+func TestCreateSubroutes(t *testing.T) {
+	hostedControlPlanes := []HostedControlPlane{
+		{Namespace: "ocm-production-abc-hcp", ClusterID: "abc"},
+		{Namespace: "ocm-production-def-hcp", ClusterID: "def"},
+	}
+	r, err := newRenderer(Inputs{
+		Profile:    Profile{Management: true},
+		Namespaces: Namespaces{Managed: []string{"^openshift-.*", "^redhat-.*"}, HostedControlPlanes: hostedControlPlanes},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pdRoute := r.createSubroutes(pagerduty)
+	if pdRoute.Receiver != ReceiverNull || !pdRoute.Continue {
+		t.Errorf("expected the PagerDuty route to continue from the null receiver, got %s", pdRoute.Receiver)
+	}
+	escalated := slices.ContainsFunc(pdRoute.Routes, func(route *alertmanager.Route) bool {
+		return route.Receiver == ReceiverMakeItCritical && route.Match["alertname"] == "etcdDatabaseQuotaLowSpace" && route.Match["severity"] == "warning"
+	})
+	if !escalated {
+		t.Error("expected etcdDatabaseQuotaLowSpace warnings to be escalated to critical")
+	}
+	hosted := slices.IndexFunc(pdRoute.Routes, func(route *alertmanager.Route) bool {
+		return route.Receiver == ReceiverPagerDutyHostedClusterPrefix+"abc"
+	})
+	managed := slices.IndexFunc(pdRoute.Routes, func(route *alertmanager.Route) bool { return route.MatchRE["namespace"] == "^openshift-.*" })
+	if hosted == -1 || managed == -1 || hosted > managed {
+		t.Errorf("expected hosted cluster routes ahead of managed namespace routes, got them at %d and %d", hosted, managed)
+	}
+
+	var goalertNamespaces []string
+	for _, route := range r.createSubroutes(goalert).Routes {
+		if route.Receiver == ReceiverGoAlertLow && route.MatchRE["namespace"] != "" {
+			goalertNamespaces = append(goalertNamespaces, route.MatchRE["namespace"])
+		}
+	}
+	expected := []string{"^openshift-.*", "^redhat-.*", "^ocm-production-abc-hcp$", "^ocm-production-def-hcp$"}
+	if !reflect.DeepEqual(goalertNamespaces, expected) {
+		t.Errorf("expected GoAlert to route hosted control planes like managed namespaces %v, got %v", expected, goalertNamespaces)
+	}
+}
+
+func TestCreateLabelledNamespaceRoutes(t *testing.T) {
+	namespaces := []Namespace{
+		{Name: "storage-operator", Team: "storage"},
+		{Name: "noisy-operator", MinSeverity: "critical"},
+	}
+
+	pdRoutes := createLabelledNamespaceRoutes(namespaces, pagerduty)
+	expected := []string{TeamReceiver("storage", ReceiverPagerDuty), TeamReceiver("storage", ReceiverPagerDuty), ReceiverPagerDuty, ReceiverPagerDuty, ReceiverNull, ReceiverNull}
+	if got := routeReceivers(pdRoutes); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected PagerDuty routes to %v, got %v", expected, got)
+	}
+	if pdRoutes[0].MatchRE != nil {
+		t.Errorf("expected every severity to page without a minimum, got %v", pdRoutes[0].MatchRE)
+	}
+	if !reflect.DeepEqual(pdRoutes[3].MatchRE, map[string]string{"severity": "critical"}) {
+		t.Errorf("expected only critical alerts to page, got %v", pdRoutes[3].MatchRE)
+	}
+
+	expected = []string{ReceiverGoAlertHigh, ReceiverGoAlertHigh, ReceiverGoAlertLow, ReceiverGoAlertHigh, ReceiverNull, ReceiverNull}
+	if got := routeReceivers(createLabelledNamespaceRoutes(namespaces, goalert)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected GoAlert routes by severity down to the minimum %v, got %v", expected, got)
+	}
+}
+
+func TestCreateTeamReceivers(t *testing.T) {
+	r, err := newRenderer(Inputs{
+		Cluster: Cluster{ID: "cluster-id", Region: "us-east-1"},
+		Namespaces: Namespaces{
+			Labelled: []Namespace{{Name: "storage-csi", Team: "storage"}, {Name: "storage-operator", Team: "storage"}},
+			Teams:    map[string]string{"^payments-.*": "payments"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	receivers := r.createTeamReceivers("pdkey", map[string]string{"storage": "storagekey"})
+	expected := []string{
+		TeamReceiver("payments", ReceiverPagerDuty), TeamReceiver("payments", ReceiverMakeItWarning), TeamReceiver("payments", ReceiverMakeItError), TeamReceiver("payments", ReceiverMakeItCritical),
+		TeamReceiver("storage", ReceiverPagerDuty), TeamReceiver("storage", ReceiverMakeItWarning), TeamReceiver("storage", ReceiverMakeItError), TeamReceiver("storage", ReceiverMakeItCritical),
+	}
+	if got := receiverNames(receivers); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected receivers %v, got %v", expected, got)
+	}
+	if key := receivers[0].PagerdutyConfigs[0].RoutingKey; key != "pdkey" {
+		t.Errorf("expected the default routing key for a team without one, got %s", key)
+	}
+	if key := receivers[4].PagerdutyConfigs[0].RoutingKey; key != "storagekey" {
+		t.Errorf("expected the team's routing key, got %s", key)
+	}
+	if team := receivers[5].PagerdutyConfigs[0].Details["team"]; team != "storage" {
+		t.Errorf("expected the team in the incident details, got %s", team)
+	}
+}
+
+func TestCreatePagerdutyConfig(t *testing.T) {
+	cluster := Cluster{ID: "cluster-id", Region: "us-east-1", Proxy: "https://proxy"}
+	tests := []struct {
+		name     string
+		profile  Profile
+		details  map[string]string
+		proxyURL string
+	}{
+		{
+			name:     "cluster details",
+			details:  map[string]string{"cluster_id": "cluster-id", "region": "us-east-1", "ocm_link": "https://console.redhat.com/openshift/details/cluster-id"},
+			proxyURL: "https://proxy",
+		},
+		{
+			name:     "FedRAMP leaves cluster details out",
+			profile:  Profile{FedRAMP: true},
+			details:  map[string]string{"cluster_id": "", "region": "", "ocm_link": ""},
+			proxyURL: "https://proxy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRenderer(Inputs{Cluster: cluster, Profile: tt.profile})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pdconfig := r.createPagerdutyConfig("pdkey")
+			for key, value := range tt.details {
+				if pdconfig.Details[key] != value {
+					t.Errorf("expected %s to be %q, got %q", key, value, pdconfig.Details[key])
+				}
+			}
+			if pdconfig.HttpConfig.ProxyURL != tt.proxyURL {
+				t.Errorf("expected proxy %s, got %s", tt.proxyURL, pdconfig.HttpConfig.ProxyURL)
+			}
+		})
+	}
+}
+
+func TestCreatePagerdutyReceivers(t *testing.T) {
+	r, err := newRenderer(Inputs{Cluster: Cluster{ID: "cluster-id", Region: "us-east-1", Proxy: "https://proxy"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivers := r.createPagerdutyReceivers(""); len(receivers) != 0 {
+		t.Errorf("expected no receivers without a routing key, got %v", receiverNames(receivers))
+	}
+
+	receivers := r.createPagerdutyReceivers("pdkey")
+	expected := []string{ReceiverPagerDuty, ReceiverMakeItWarning, ReceiverMakeItError, ReceiverMakeItCritical}
+	if got := receiverNames(receivers); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected receivers %v, got %v", expected, got)
+	}
+	severities := map[string]string{
+		ReceiverPagerDuty:      `{{ if .CommonLabels.severity }}{{ .CommonLabels.severity | toLower }}{{ else }}critical{{ end }}`,
+		ReceiverMakeItWarning:  "warning",
+		ReceiverMakeItError:    "error",
+		ReceiverMakeItCritical: "critical",
+	}
+	for _, receiver := range receivers {
+		if len(receiver.PagerdutyConfigs) != 1 {
+			t.Errorf("expected one PagerDuty config for %s, got %d", receiver.Name, len(receiver.PagerdutyConfigs))
+			continue
+		}
+		pdconfig := receiver.PagerdutyConfigs[0]
+		if !pdconfig.VSendResolved {
+			t.Errorf("expected %s to send resolved alerts", receiver.Name)
+		}
+		if pdconfig.RoutingKey != "pdkey" {
+			t.Errorf("expected %s to use the routing key, got %s", receiver.Name, pdconfig.RoutingKey)
+		}
+		if pdconfig.Severity != severities[receiver.Name] {
+			t.Errorf("expected %s to send severity %q, got %q", receiver.Name, severities[receiver.Name], pdconfig.Severity)
+		}
+		if pdconfig.HttpConfig.ProxyURL != "https://proxy" {
+			t.Errorf("expected %s to use the cluster proxy, got %s", receiver.Name, pdconfig.HttpConfig.ProxyURL)
+		}
+		if pdconfig.Details["cluster_id"] != "cluster-id" || pdconfig.Details["region"] != "us-east-1" {
+			t.Errorf("expected %s to have the cluster details, got %v", receiver.Name, pdconfig.Details)
+		}
+	}
+}
+
+// verifyWebhookReceivers checks that receivers is a single receiver named name, sending
+// resolved alerts to url through proxy.
+func verifyWebhookReceivers(t *testing.T, receivers []*alertmanager.Receiver, name, url, proxy string) {
+	t.Helper()
+	if got := receiverNames(receivers); !reflect.DeepEqual(got, []string{name}) {
+		t.Fatalf("expected receivers [%s], got %v", name, got)
+	}
+	if len(receivers[0].WebhookConfigs) != 1 {
+		t.Fatalf("expected one webhook config, got %d", len(receivers[0].WebhookConfigs))
+	}
+	webhook := receivers[0].WebhookConfigs[0]
+	if !webhook.VSendResolved {
+		t.Errorf("expected %s to send resolved alerts", name)
+	}
+	if webhook.URL != url {
+		t.Errorf("expected %s to send to %s, got %s", name, url, webhook.URL)
+	}
+	if webhook.HttpConfig.ProxyURL != proxy {
+		t.Errorf("expected %s to use proxy %q, got %q", name, proxy, webhook.HttpConfig.ProxyURL)
+	}
+}
+
+func TestCreateWebhookReceivers(t *testing.T) {
+	tests := []struct {
+		name     string
+		create   func(url, proxy string) []*alertmanager.Receiver
+		receiver string
+	}{
+		{
+			name: "GoAlert low",
+			create: func(url, proxy string) []*alertmanager.Receiver {
+				return createGoalertReceiver(url, ReceiverGoAlertLow, proxy)
+			},
+			receiver: ReceiverGoAlertLow,
+		},
+		{
+			name: "GoAlert high",
+			create: func(url, proxy string) []*alertmanager.Receiver {
+				return createGoalertReceiver(url, ReceiverGoAlertHigh, proxy)
+			},
+			receiver: ReceiverGoAlertHigh,
+		},
+		{
+			name:     "GoAlert heartbeat",
+			create:   createHeartbeatReceivers,
+			receiver: ReceiverGoAlertHeartbeat,
+		},
+		{
+			name:     "Dead Man's Snitch",
+			create:   createWatchdogReceivers,
+			receiver: ReceiverWatchdog,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if receivers := tt.create("", "https://proxy"); len(receivers) != 0 {
+				t.Errorf("expected no receivers without a URL, got %v", receiverNames(receivers))
+			}
+			verifyWebhookReceivers(t, tt.create("https://webhook", "https://proxy"), tt.receiver, "https://webhook", "https://proxy")
+			verifyWebhookReceivers(t, tt.create("https://webhook", ""), tt.receiver, "https://webhook", "")
+		})
+	}
+}
+
+func TestCreateWatchdogRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		route    *alertmanager.Route
+		receiver string
+	}{
+		{name: "Dead Man's Snitch", route: createWatchdogRoute(), receiver: ReceiverWatchdog},
+		{name: "GoAlert heartbeat", route: createHeartbeatRoute(), receiver: ReceiverGoAlertHeartbeat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := &alertmanager.Route{
+				Receiver:       tt.receiver,
+				RepeatInterval: "5m",
+				Match:          map[string]string{"alertname": "Watchdog"},
+				Continue:       true,
+			}
+			if !reflect.DeepEqual(tt.route, expected) {
+				t.Errorf("expected route %+v, got %+v", expected, tt.route)
+			}
+		})
+	}
+}
+
+func TestRenderUserWorkload(t *testing.T) {
+	amconfig, warnings, err := RenderUserWorkload(Inputs{
+		Integrations: []Integration{
+			DeadMansSnitch{URL: "https://dms"},
+			PagerDuty{RoutingKey: "pdkey"},
+			GoAlert{LowURL: "https://goalert/low", HighURL: "https://goalert/high"},
+		},
+		Namespaces: Namespaces{Managed: []string{"^my-app$"}, Labelled: []Namespace{{Name: "other-app"}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{ReceiverPagerDuty, ReceiverMakeItWarning, ReceiverMakeItError, ReceiverMakeItCritical, ReceiverGoAlertLow, ReceiverGoAlertHigh, ReceiverNull}
+	if got := receiverNames(amconfig.Receivers); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected receivers %v, got %v", expected, got)
+	}
+	pdRoutes := amconfig.Route.Routes[0].Routes
+	if len(pdRoutes) != 1 || !reflect.DeepEqual(pdRoutes[0].MatchRE, map[string]string{"namespace": "^my-app$"}) || pdRoutes[0].Match != nil {
+		t.Errorf("expected one PagerDuty route matching only the namespace, got %v", pdRoutes)
+	}
+	if len(amconfig.InhibitRules) != 2 {
+		t.Errorf("expected the user-workload inhibit rules, got %d", len(amconfig.InhibitRules))
+	}
+	expectedWarnings := []Warning{
+		{Input: "Namespaces", Message: "only managed namespaces are routed for user workloads"},
+		{Input: "Integrations", Message: "ignoring render.DeadMansSnitch, which doesn't apply to user workloads"},
+	}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Errorf("expected warnings %v, got %v", expectedWarnings, warnings)
+	}
+}
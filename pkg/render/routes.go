@@ -0,0 +1,281 @@
+package render
+
+import (
+	"slices"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// receiverType selects the integration createSubroutes routes alerts to.
+type receiverType int
+
+const (
+	goalert receiverType = iota
+	pagerduty
+)
+
+// createSubroutes routes alerts from the managed, labelled and hosted control plane namespaces
+// to PagerDuty or GoAlert, after the platform alerts that are silenced or have their severity
+// changed.
+func (r *Renderer) createSubroutes(receiver receiverType) *alertmanager.Route {
+	namespaces := r.in.Namespaces
+	namespaceList := namespaces.Managed
+
+	var receiverCommon, receiverCritical, receiverError, receiverWarning, receiverDefault string
+
+	switch receiver {
+	case goalert:
+		receiverCommon = ReceiverGoAlertLow
+		receiverCritical = ReceiverGoAlertHigh
+		receiverError = ReceiverGoAlertHigh
+		receiverWarning = ReceiverGoAlertLow
+		receiverDefault = ReceiverNull
+	case pagerduty:
+		receiverCommon = ReceiverPagerDuty
+		receiverCritical = ReceiverMakeItCritical
+		receiverError = ReceiverMakeItError
+		receiverWarning = ReceiverMakeItWarning
+		receiverDefault = defaultReceiver
+	default:
+		return nil
+	}
+
+	// order matters.
+	// these are sub-routes.  if any matches it will not continue processing.
+	// 1. route anything we consider critical to receiverCritical
+	// 2. route anything we want to silence to receiverNull
+	// 3. route anything that should be a warning to receiverWarning
+	// 4. route anything that should be an error to receiverError
+	// 5. route anything we want to go to receiverCommon
+	//
+	// the Route docs can be read at https://prometheus.io/docs/alerting/latest/configuration/#matcher
+	subroute := []*alertmanager.Route{
+		// Needed because we are now allowing DMS to continue to allow DMS and GoAlert Heartbeat to coexist. Now we just drop DMS.
+		// {Receiver: ReceiverNull, Match: map[string]string{"alertname": "SnitchHeartBeat", "severity": "deadman"}},
+		// Needed to drop GoAlert heartbeat alerts
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "Watchdog", "severity": "none"}},
+		// https://issues.redhat.com/browse/OSD-11298
+		// indications that master nodes have been terminated should be critical
+		// regex tests: https://regex101.com/r/Rn6F5A/1
+		{Receiver: receiverCritical, MatchRE: map[string]string{"name": "^.+-master-.*[0-9]+$"}, Match: map[string]string{"alertname": "MachineWithoutValidNode", "namespace": "openshift-machine-api"}},
+		{Receiver: receiverCritical, MatchRE: map[string]string{"name": "^.+-master-.*[0-9]+$"}, Match: map[string]string{"alertname": "MachineWithNoRunningPhase", "namespace": "openshift-machine-api"}},
+		// https://issues.redhat.com/browse/ROSAENG-420
+		{Receiver: receiverCritical, Match: map[string]string{"alertname": "etcdDatabaseQuotaLowSpace", "severity": "warning"}},
+		// Route CannotRetrieveUpdates to null, created CannotRetrieveUpdatesSRE in managed-cluster-config to address https://issues.redhat.com/browse/OSD-14149
+		// https://issues.redhat.com/browse/OCPBUGS-11636
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "CannotRetrieveUpdates"}},
+		// Silence anything intended for OCM Agent
+		// https://issues.redhat.com/browse/SDE-1315
+		{Receiver: ReceiverNull, Match: map[string]string{ManagedNotificationLabel: "true"}},
+		// https://issues.redhat.com/browse/OSD-1966
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "KubeQuotaExceeded"}},
+		// This will be renamed in release 4.5
+		// https://issues.redhat.com/browse/OSD-4017
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "KubeQuotaFullyUsed"}},
+		// TODO: Remove CPUThrottlingHigh entry after all OSD clusters upgrade to 4.6 and above version
+		// https://issues.redhat.com/browse/OSD-6351 based on https://bugzilla.redhat.com/show_bug.cgi?id=1843346
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "CPUThrottlingHigh"}},
+		// https://issues.redhat.com/browse/OSD-28223
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "NodeFilesystemSpaceFillingUp"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "NodeFilesystemFilesFillingUp"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "NodeFilesystemAlmostOutOfSpace"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "NodeFilesystemAlmostOutOfFiles"}},
+		// https://issues.redhat.com/browse/OSD-12379
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "NodeFileDescriptorLimit"}},
+		// https://issues.redhat.com/browse/OSD-2611
+		{Receiver: ReceiverNull, Match: map[string]string{"namespace": "openshift-customer-monitoring"}},
+		// https://issues.redhat.com/browse/OSD-3569
+		{Receiver: ReceiverNull, Match: map[string]string{"namespace": "openshift-operators"}},
+		// https://issues.redhat.com/browse/OSD-8337
+		{Receiver: ReceiverNull, Match: map[string]string{"namespace": "openshift-storage"}},
+		// https://issues.redhat.com/browse/OSD-8702
+		{Receiver: ReceiverNull, Match: map[string]string{"namespace": "openshift-compliance"}},
+		// https://issues.redhat.com/browse/OSD-8349
+		{Receiver: ReceiverNull, Match: map[string]string{"exported_namespace": "openshift-storage"}},
+		// https://issues.redhat.com/browse/OSD-6505
+		{Receiver: ReceiverNull, Match: map[string]string{"exported_namespace": "openshift-operators"}},
+		// https://issues.redhat.com/browse/OSD-7653
+		{Receiver: ReceiverNull, Match: map[string]string{"namespace": "openshift-operators-redhat"}},
+		// https://issues.redhat.com/browse/OSD-3629
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "CustomResourceDetected"}},
+		// https://issues.redhat.com/browse/OSD-3629
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ImagePruningDisabled"}},
+		// https://issues.redhat.com/browse/OSD-3794
+		{Receiver: ReceiverNull, Match: map[string]string{"severity": "info"}},
+		// https://issues.redhat.com/browse/OSD-27306
+		{Receiver: ReceiverNull, Match: map[string]string{"namespace": "openshift-user-workload-monitoring"}},
+		// https://issues.redhat.com/browse/OSD-19000 - Critical
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "KubePersistentVolumeFillingUp", "namespace": "openshift-logging"}},
+		// https://issues.redhat.com/browse/OSD-6598
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "PodDisruptionBudgetLimit"}},
+		// https://issues.redhat.com/browse/OSD-4373
+		{Receiver: ReceiverNull, MatchRE: map[string]string{"namespace": alertmanager.PDRegexLP}, Match: map[string]string{"alertname": "TargetDown"}},
+		// https://issues.redhat.com/browse/OSD-13306
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "KubeJobFailed"}},
+		// https://issues.redhat.com/browse/OSD-11273 - silence all elasticsearch alerts so we can handle only the ones that have extended logging support
+		// the list of alerts is pulled via
+		// ```
+		//  yq '.spec.groups[].rules[].alert | select( . != null) ' ../managed-cluster-config/resources/prometheusrules/fluentd_openshift-logging_collector.PrometheusRule.yaml | sort -u | awk '{print "{Receiver: ReceiverNull, Match: map[string]string{\"alertname\": \"" $1 "\", \"namespace\": \"openshift-logging\"}},"}'
+		// # for elasticsearch
+		// yq '.spec.groups[].rules[].alert | select( . != null) ' ../managed-cluster-config/resources/prometheusrules/elasticsearch_openshift-logging_elasticsearch-prometheus-rules.PrometheusRule.yaml | sort -u | awk '{print "{Receiver: ReceiverNull, Match: map[string]string{\"alertname\": \"" $1 "\", \"namespace\": \"openshift-logging\"}},"}'
+		// ```
+		// pass all the alerts that are SRE related to PD/GoAlert
+		{Receiver: receiverCommon, MatchRE: map[string]string{"alertname": "^.*SRE$"}, Match: map[string]string{"namespace": "openshift-logging"}},
+		// fluentd alerts
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "FluentDHighErrorRate", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "FluentDVeryHighErrorRate", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "FluentdNodeDown", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "FluentdNodeDown", "prometheus": "openshift-monitoring/k8s"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "FluentdQueueLengthIncreasing", "namespace": "openshift-logging"}}, //https://issues.redhat.com/browse/OSD-8403, https://issues.redhat.com/browse/OSD-8576
+		// elasticsearch alerts
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "AggregatedLoggingSystemCPUHigh", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchClusterNotHealthy", "namespace": "openshift-logging"}},   // this has happened last week
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchDiskSpaceRunningLow", "namespace": "openshift-logging"}}, // this has happened last week
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchHighFileDescriptorUsage", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchJVMHeapUseHigh", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchNodeDiskWatermarkReached", "namespace": "openshift-logging"}}, // this has happened last week
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchOperatorCSVNotSuccessful", "namespace": "openshift-logging"}}, // this has happened last week
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchProcessCPUHigh", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ElasticsearchWriteRequestsRejectionJumps", "namespace": "openshift-logging"}},
+		// END of https://issues.redhat.com/browse/OSD-11273
+		//
+		// https://issues.redhat.com/browse/OSD-17372 - silence all loki/vector alerts for none of them is in the support scope of extended logging support
+		// For detail explanation, please check https://issues.redhat.com/browse/OSD-17371
+		// vector alerts
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterLogForwarderRuntimeConfigurationMissingUnmatched", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterLogForwarderOutputErrorRate", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "CollectorNodeDown", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "CollectorHighErrorRate", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "CollectorVeryHighErrorRate", "namespace": "openshift-logging"}},
+		// loki alerts
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiRequestErrors", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiStackWriteRequestErrors", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiStackReadRequestErrors", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiRequestPanics", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiRequestLatency", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiTenantRateLimit", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiStorageSlowWrite", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiStorageSlowRead", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiWritePathHighLoad", "namespace": "openshift-logging"}},
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "LokiReadPathHighLoad", "namespace": "openshift-logging"}},
+		// END of https://issues.redhat.com/browse/OSD-17372
+		//
+		// Suppress the alerts and use HAProxyReloadFailSRE instead (openshift/managed-cluster-config#600)
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "HAProxyReloadFail", "severity": "critical"}},
+		// https://issues.redhat.com/browse/OHSS-2163
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "PrometheusRuleFailures"}},
+		// https://issues.redhat.com/browse/OSD-6215
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterOperatorDegraded", "name": "authentication", "reason": "IdentityProviderConfig_Error"}},
+		// https://issues.redhat.com/browse/OSD-6363
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterOperatorDegraded", "name": "authentication", "reason": "OAuthServerConfigObservation_Error"}},
+		//https://issues.redhat.com/browse/OSD-8320
+		// Sometimes only CLusterOperatorDown is firing, meaning the suppression set below in this file does not work
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "authentication", "reason": "IdentityProviderConfig_Error"}},
+		//https://issues.redhat.com/browse/OSD-8320
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "authentication", "reason": "OAuthServerConfigObservation_Error"}},
+		//https://issues.redhat.com/browse/OSD-7671
+		// might also be removed by https://issues.redhat.com/browse/OSD-11273
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "FluentdQueueLengthBurst", "namespace": "openshift-logging", "severity": "warning"}},
+		// https://issues.redhat.com/browse/OSD-9061
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterAutoscalerUnschedulablePods", "namespace": "openshift-machine-api"}},
+		// https://issues.redhat.com/browse/OSD-9062
+		{Receiver: ReceiverNull, Match: map[string]string{"severity": "alert"}},
+		// https://issues.redhat.com/browse/OSD-14071
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "MultipleDefaultStorageClasses", "namespace": "openshift-cluster-storage-operator"}},
+		// https://issues.redhat.com/browse/OSD-14857
+		{Receiver: ReceiverNull, MatchRE: map[string]string{"mountpoint": "/var/lib/ibmc-s3fs.*"}, Match: map[string]string{"alertname": "NodeFilesystemAlmostOutOfSpace", "severity": "critical"}},
+		// https://issues.redhat.com/browse/OSD-1922
+		{Receiver: receiverWarning, Match: map[string]string{"alertname": "KubeAPILatencyHigh", "severity": "critical"}},
+		// https://issues.redhat.com/browse/OSD-8983
+		{Receiver: receiverWarning, Match: map[string]string{"alertname": "etcdGRPCRequestsSlow", "namespace": "openshift-etcd"}},
+		// https://issues.redhat.com/browse/ROSAENG-59423
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "etcdMembersDown", "namespace": "openshift-etcd"}},
+		// https://issues.redhat.com/browse/OSD-10473
+		{Receiver: receiverWarning, Match: map[string]string{"alertname": "ExtremelyHighIndividualControlPlaneCPU", "namespace": "openshift-kube-apiserver"}},
+		// https://issues.redhat.com/browse/DVO-54
+		{Receiver: receiverWarning, Match: map[string]string{"severity": "critical", "namespace": "openshift-deployment-validation-operator"}},
+		// Ensure NodeClockNotSynchronising is routed to PD as a high alert
+		// https://issues.redhat.com/browse/OSD-8736
+		{Receiver: receiverError, Match: map[string]string{"alertname": "NodeClockNotSynchronising", "prometheus": "openshift-monitoring/k8s"}},
+		// fluentd: route any fluentd alert to PD/GoAlert
+		// https://issues.redhat.com/browse/OSD-3326
+		{Receiver: receiverCommon, Match: map[string]string{"job": "fluentd", "prometheus": "openshift-monitoring/k8s"}},
+		// elasticsearch: route any ES alert to PD
+		// https://issues.redhat.com/browse/OSD-3326
+		{Receiver: receiverCommon, Match: map[string]string{"cluster": "elasticsearch", "prometheus": "openshift-monitoring/k8s"}},
+		// https://issues.redhat.com/browse/OSD-20058
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "KubeAPIErrorBudgetBurn", "prometheus": "openshift-monitoring/k8s"}},
+		// Route HaproxyDown alert in cluster-ingress-operator to null
+		// use the SRE managed alerts instead, so that we can ignore the alert
+		// for non-default ingresscontroller in 4.13+ when user can control their own
+		// https://issues.redhat.com/browse/OSD-16014
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "HAProxyDown"}},
+		// Route CertificateIsAboutToExpire alert to null
+		// https://issues.redhat.com/browse/OSD-19439
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "CertificateIsAboutToExpire"}},
+		// Route ClusterOperatorDown for insights to null receiver https://issues.redhat.com/browse/OSD-19800
+		// Also needs to be silenced for FedRAMP until its made available in the environment https://issues.redhat.com/browse/OSD-13685
+		{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "insights"}},
+	}
+
+	if !r.in.Profile.FedRAMP {
+		// Route ClusterOperatorDown for monitoring to null receiver https://issues.redhat.com/browse/OSD-19769
+		subroute = append(subroute,
+			&alertmanager.Route{Receiver: ReceiverNull, Match: map[string]string{"alertname": "ClusterOperatorDown", "name": "monitoring"}},
+		)
+	}
+
+	// Alerts from a namespace owned by a team page the team's service, with the same severity overrides
+	if receiver == pagerduty {
+		for _, route := range subroute {
+			if team := namespaces.owner(route.Match["namespace"]); team != "" && route.Receiver != ReceiverNull {
+				route.Receiver = TeamReceiver(team, route.Receiver)
+			}
+		}
+	}
+
+	// HostedControlPlane namespaces page with the ID of their hosted cluster, and labelled
+	// namespaces with their minimum severity and owning team, ahead of any managed namespace
+	// regex that also matches them. GoAlert routes HostedControlPlanes like any other namespace.
+	if receiver == pagerduty {
+		subroute = append(subroute, createHostedClusterRoutes(namespaces.HostedControlPlanes)...)
+	} else {
+		namespaceList = append(slices.Clone(namespaceList), namespaces.hostedControlPlaneNamespaceRegexes()...)
+	}
+	subroute = append(subroute, createLabelledNamespaceRoutes(namespaces.Labelled, receiver)...)
+
+	for _, namespace := range namespaceList {
+		if receiver == pagerduty {
+			// namespaces owned by a team page the team's service
+			namespaceReceiver := receiverCommon
+			if team := namespaces.Teams[namespace]; team != "" {
+				namespaceReceiver = TeamReceiver(team, receiverCommon)
+			}
+			subroute = append(subroute, []*alertmanager.Route{
+				// https://issues.redhat.com/browse/OSD-3086
+				// https://issues.redhat.com/browse/OSD-5872
+				{Receiver: namespaceReceiver, MatchRE: map[string]string{"exported_namespace": namespace}, Match: map[string]string{"prometheus": "openshift-monitoring/k8s"}},
+				// general: route anything in core namespaces to PD
+				{Receiver: namespaceReceiver, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s"}},
+			}...)
+		}
+		// GoAlert config
+		if receiver == goalert {
+			subroute = append(subroute, []*alertmanager.Route{
+				{Receiver: receiverCritical, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s", "severity": "critical"}},
+				{Receiver: receiverError, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s", "severity": "error"}},
+				{Receiver: receiverWarning, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s", "severity": "warning"}},
+			}...)
+		}
+	}
+
+	return &alertmanager.Route{
+		Receiver: receiverDefault,
+		GroupByStr: []string{
+			"alertname",
+			"severity",
+		},
+		Continue: true,
+		Routes:   subroute,
+	}
+}
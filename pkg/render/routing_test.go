@@ -0,0 +1,56 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/openshift/configure-alertmanager-operator/pkg/routing"
+)
+
+// routingExpectations are the alerts of testdata/routing.yaml, with the receivers the config
+// rendered for a scenario sends them to.
+type routingExpectations struct {
+	Scenario string `yaml:"scenario"`
+	Alerts   []struct {
+		Name      string            `yaml:"name"`
+		Labels    map[string]string `yaml:"labels"`
+		Receivers []string          `yaml:"receivers"`
+	} `yaml:"alerts"`
+}
+
+// TestRender_Routing routes the alerts of testdata/routing.yaml with Alertmanager's own
+// routing, and reports the routes an alert took when it isn't sent to the expected receivers.
+func TestRender_Routing(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "routing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectations []routingExpectations
+	if err := yaml.UnmarshalStrict(data, &expectations); err != nil {
+		t.Fatalf("invalid routing.yaml: %v", err)
+	}
+
+	for _, expected := range expectations {
+		t.Run(expected.Scenario, func(t *testing.T) {
+			explainer, err := routing.NewExplainer(renderScenario(t, filepath.Join("testdata", "scenarios", expected.Scenario)))
+			if err != nil {
+				t.Fatalf("unexpected error loading the config: %v", err)
+			}
+			for _, alert := range expected.Alerts {
+				var receivers, routes []string
+				for _, match := range explainer.Explain(alert.Labels) {
+					receivers = append(receivers, match.Receiver)
+					routes = append(routes, match.Receiver+" via "+match.Route)
+				}
+				if !reflect.DeepEqual(receivers, alert.Receivers) {
+					t.Errorf("%s: expected %v to be sent to %v, got %v:\n  %s", alert.Name, alert.Labels, alert.Receivers, receivers, strings.Join(routes, "\n  "))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package render
+
+import (
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// RenderUserWorkload renders the config of the user-workload Alertmanager, which routes alerts
+// from the Managed namespaces to PagerDuty and GoAlert. Unlike the platform config, none of
+// the platform silences apply and alerts aren't required to come from the platform
+// Prometheus. Alerts from other namespaces are routed to the "null" receiver.
+//
+// Other integrations, namespace teams, labelled namespaces and hosted control planes don't
+// apply to user workloads, and are ignored with a warning.
+func RenderUserWorkload(in Inputs) (*alertmanager.Config, []Warning, error) {
+	r, err := newRenderer(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(in.Namespaces.Teams) > 0 || len(in.Namespaces.Labelled) > 0 || len(r.in.Namespaces.HostedControlPlanes) > 0 {
+		r.Warn("Namespaces", "only managed namespaces are routed for user workloads")
+	}
+
+	routes := []*alertmanager.Route{}
+	receivers := []*alertmanager.Receiver{}
+	for _, integration := range in.Integrations {
+		switch i := integration.(type) {
+		case PagerDuty:
+			if i.Configured(r) {
+				routes = append(routes, createUserWorkloadSubroutes(in.Namespaces.Managed, pagerduty))
+				receivers = append(receivers, r.createPagerdutyReceivers(i.RoutingKey)...)
+			}
+		case GoAlert:
+			if i.Configured(r) {
+				routes = append(routes, createUserWorkloadSubroutes(in.Namespaces.Managed, goalert))
+				receivers = append(receivers, i.RenderReceivers(r)...)
+			}
+		case nil:
+		default:
+			r.Warn("Integrations", "ignoring %T, which doesn't apply to user workloads", integration)
+		}
+	}
+
+	// always have the "null" receiver
+	receivers = append(receivers, &alertmanager.Receiver{Name: ReceiverNull})
+	if err := checkReceiverNames(receivers); err != nil {
+		return nil, nil, err
+	}
+	return createConfig(routes, receivers, userWorkloadInhibitRules()), r.warnings, nil
+}
+
+// createUserWorkloadSubroutes routes alerts from the listed namespaces to PagerDuty or GoAlert.
+func createUserWorkloadSubroutes(namespaceList []string, receiver receiverType) *alertmanager.Route {
+	subroute := []*alertmanager.Route{}
+	for _, namespace := range namespaceList {
+		switch receiver {
+		case pagerduty:
+			subroute = append(subroute,
+				&alertmanager.Route{Receiver: ReceiverPagerDuty, MatchRE: map[string]string{"namespace": namespace}},
+			)
+		case goalert:
+			subroute = append(subroute, []*alertmanager.Route{
+				{Receiver: ReceiverGoAlertHigh, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"severity": "critical"}},
+				{Receiver: ReceiverGoAlertHigh, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"severity": "error"}},
+				{Receiver: ReceiverGoAlertLow, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"severity": "warning"}},
+			}...)
+		default:
+			return nil
+		}
+	}
+
+	return &alertmanager.Route{
+		Receiver: ReceiverNull,
+		GroupByStr: []string{
+			"alertname",
+			"severity",
+		},
+		Continue: true,
+		Routes:   subroute,
+	}
+}
+
+// userWorkloadInhibitRules keep alerts of a lower severity from notifying as well.
+func userWorkloadInhibitRules() []*alertmanager.InhibitRule {
+	return []*alertmanager.InhibitRule{
+		{
+			// Critical alert shouldn't also alert for warning/info
+			Equal:         []string{"namespace", "alertname"},
+			SourceMatch:   map[string]string{"severity": "critical"},
+			TargetMatchRE: map[string]string{"severity": "warning|info"},
+		},
+		{
+			// Warning alerts shouldn't also alert for info
+			Equal:         []string{"namespace", "alertname"},
+			SourceMatch:   map[string]string{"severity": "warning"},
+			TargetMatchRE: map[string]string{"severity": "info"},
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package routing
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Compact returns a copy of route whose child routes are merged where that doesn't change
+// where any alert is routed. Leaf routes that differ only in the value required of one
+// label, such as the per-namespace routes, are merged into a single route matching an
+// alternation of those values. A route is only moved past routes that can't match the
+// same alerts or that would have routed them the same way.
+func Compact(route *alertmanager.Route) *alertmanager.Route {
+	if route == nil {
+		return nil
+	}
+	compacted := *route
+	if len(route.Routes) == 0 {
+		return &compacted
+	}
+
+	compacted.Routes = make([]*alertmanager.Route, 0, len(route.Routes))
+	for _, child := range route.Routes {
+		compacted.Routes = append(compacted.Routes, Compact(child))
+	}
+	// Merge on labels matched by regular expressions first, so that the routes for each
+	// namespace are merged with each other rather than with the routes for each severity.
+	compacted.Routes = compactRoutes(compacted.Routes, true)
+	compacted.Routes = compactRoutes(compacted.Routes, false)
+	return &compacted
+}
+
+// compactRoutes merges sibling routes. If regexOnly is set, routes are only merged on a
+// label both match with a regular expression.
+func compactRoutes(routes []*alertmanager.Route, regexOnly bool) []*alertmanager.Route {
+	var merged []*mergedRoute
+	for _, route := range routes {
+		c := newMergedRoute(route)
+		if c.mergeable() && mergeInto(merged, c, regexOnly) {
+			continue
+		}
+		merged = append(merged, c)
+	}
+
+	compacted := make([]*alertmanager.Route, 0, len(merged))
+	for _, m := range merged {
+		compacted = append(compacted, m.route())
+	}
+	return compacted
+}
+
+// mergeInto merges c into the latest route in merged it can be merged with, provided c can
+// be moved up to it. It returns false if c has to stay where it is.
+func mergeInto(merged []*mergedRoute, c *mergedRoute, regexOnly bool) bool {
+	for i := len(merged) - 1; i >= 0; i-- {
+		if merged[i].absorb(c, regexOnly) {
+			return true
+		}
+		// c can't move ahead of a route that may match the same alert and route it elsewhere
+		if !merged[i].disjoint(c) && !merged[i].sameOutcome(c) {
+			return false
+		}
+	}
+	return false
+}
+
+// constraint is what a route requires of one label: an exact value for match, or a
+// regular expression for match_re.
+type constraint struct {
+	regex bool
+	value string
+}
+
+// pattern returns the constraint as a regular expression.
+func (c constraint) pattern() string {
+	if c.regex {
+		return c.value
+	}
+	return regexp.QuoteMeta(c.value)
+}
+
+// mergedRoute is a route from the original tree, together with the values of the label
+// it was merged on from the routes merged into it.
+type mergedRoute struct {
+	original    *alertmanager.Route
+	constraints map[string]constraint
+	// label is the label the route was merged on, and alternatives the patterns for it.
+	label        string
+	alternatives []string
+}
+
+func newMergedRoute(route *alertmanager.Route) *mergedRoute {
+	m := &mergedRoute{original: route, constraints: map[string]constraint{}}
+	for label, value := range route.Match {
+		m.constraints[label] = constraint{value: value}
+	}
+	for label, value := range route.MatchRE {
+		if _, ok := m.constraints[label]; ok {
+			// both match and match_re on a label; leave the route alone
+			m.constraints = nil
+			return m
+		}
+		m.constraints[label] = constraint{regex: true, value: value}
+	}
+	return m
+}
+
+// mergeable is true for leaf routes that stop at their receiver and only use match and
+// match_re, which are the routes whose matchers can be combined.
+func (m *mergedRoute) mergeable() bool {
+	r := m.original
+	return m.constraints != nil && !r.Continue && len(r.Routes) == 0 && len(r.Matchers) == 0
+}
+
+// sameOutcome is true if both routes stop at the same receiver with the same options, so
+// an alert matching either is handled the same way.
+func (m *mergedRoute) sameOutcome(c *mergedRoute) bool {
+	a, b := m.original, c.original
+	return m.mergeable() && c.mergeable() &&
+		a.Receiver == b.Receiver &&
+		slices.Equal(a.GroupByStr, b.GroupByStr) &&
+		a.GroupWait == b.GroupWait &&
+		a.GroupInterval == b.GroupInterval &&
+		a.RepeatInterval == b.RepeatInterval
+}
+
+// disjoint is true if no alert can match both routes, because they require different
+// exact values of the same label.
+func (m *mergedRoute) disjoint(c *mergedRoute) bool {
+	for label, value := range m.original.Match {
+		if label == m.label {
+			continue
+		}
+		if other, ok := c.original.Match[label]; ok && other != value {
+			return true
+		}
+	}
+	return false
+}
+
+// absorb merges c into m if they have the same outcome and constrain the same labels in
+// the same way, except for the value of at most one label. If regexOnly is set, that label
+// must be matched with a regular expression by both.
+func (m *mergedRoute) absorb(c *mergedRoute, regexOnly bool) bool {
+	if !m.sameOutcome(c) || !slices.Equal(slices.Sorted(maps.Keys(m.constraints)), slices.Sorted(maps.Keys(c.constraints))) {
+		return false
+	}
+
+	label := m.label
+	for l, want := range m.constraints {
+		if l == m.label || c.constraints[l] == want {
+			continue
+		}
+		if label != "" && label != l {
+			return false
+		}
+		if regexOnly && (!want.regex || !c.constraints[l].regex) {
+			return false
+		}
+		label = l
+	}
+
+	if label == "" {
+		// c is a duplicate of m, and can never match an alert m doesn't match first
+		return true
+	}
+	if m.label == "" {
+		m.label = label
+		m.alternatives = []string{m.constraints[label].pattern()}
+	}
+	if pattern := c.constraints[label].pattern(); !slices.Contains(m.alternatives, pattern) {
+		m.alternatives = append(m.alternatives, pattern)
+	}
+	return true
+}
+
+// route returns the route with the alternatives for the merged label as a match_re.
+func (m *mergedRoute) route() *alertmanager.Route {
+	if m.label == "" {
+		return m.original
+	}
+	route := *m.original
+	route.Match = maps.Clone(route.Match)
+	route.MatchRE = maps.Clone(route.MatchRE)
+	delete(route.Match, m.label)
+	if len(route.Match) == 0 {
+		route.Match = nil
+	}
+	if route.MatchRE == nil {
+		route.MatchRE = map[string]string{}
+	}
+	// Alertmanager anchors match_re, so the alternatives don't need their own anchors
+	// or grouping to keep their meaning.
+	route.MatchRE[m.label] = strings.Join(m.alternatives, "|")
+	return &route
+}
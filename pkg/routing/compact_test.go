@@ -0,0 +1,291 @@
+package routing
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/openshift/configure-alertmanager-operator/pkg/render"
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+func namespaceRoutes(receiver string, namespaces ...string) []*alertmanager.Route {
+	var routes []*alertmanager.Route
+	for _, namespace := range namespaces {
+		routes = append(routes,
+			&alertmanager.Route{Receiver: receiver, MatchRE: map[string]string{"exported_namespace": namespace}, Match: map[string]string{"prometheus": "openshift-monitoring/k8s"}},
+			&alertmanager.Route{Receiver: receiver, MatchRE: map[string]string{"namespace": namespace}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s"}},
+		)
+	}
+	return routes
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name     string
+		routes   []*alertmanager.Route
+		expected []*alertmanager.Route
+	}{
+		{
+			name:   "namespace routes are merged across interleaved routes with the same outcome",
+			routes: namespaceRoutes("pagerduty", "^a$", "^b$", "^openshift-.*"),
+			expected: []*alertmanager.Route{
+				{Receiver: "pagerduty", MatchRE: map[string]string{"exported_namespace": "^a$|^b$|^openshift-.*"}, Match: map[string]string{"prometheus": "openshift-monitoring/k8s"}},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^a$|^b$|^openshift-.*"}, Match: map[string]string{"exported_namespace": "", "prometheus": "openshift-monitoring/k8s"}},
+			},
+		},
+		{
+			name: "null routes on different alertnames are collapsed into an escaped alternation",
+			routes: []*alertmanager.Route{
+				{Receiver: "null", Match: map[string]string{"alertname": "KubeQuotaExceeded"}},
+				{Receiver: "null", Match: map[string]string{"alertname": "Kube.QuotaFullyUsed"}},
+			},
+			expected: []*alertmanager.Route{
+				{Receiver: "null", MatchRE: map[string]string{"alertname": `KubeQuotaExceeded|Kube\.QuotaFullyUsed`}},
+			},
+		},
+		{
+			name: "routes are moved past routes for other severities",
+			routes: []*alertmanager.Route{
+				{Receiver: "high", MatchRE: map[string]string{"namespace": "^a$"}, Match: map[string]string{"severity": "critical"}},
+				{Receiver: "low", MatchRE: map[string]string{"namespace": "^a$"}, Match: map[string]string{"severity": "warning"}},
+				{Receiver: "high", MatchRE: map[string]string{"namespace": "^b$"}, Match: map[string]string{"severity": "critical"}},
+				{Receiver: "low", MatchRE: map[string]string{"namespace": "^b$"}, Match: map[string]string{"severity": "warning"}},
+			},
+			expected: []*alertmanager.Route{
+				{Receiver: "high", MatchRE: map[string]string{"namespace": "^a$|^b$"}, Match: map[string]string{"severity": "critical"}},
+				{Receiver: "low", MatchRE: map[string]string{"namespace": "^a$|^b$"}, Match: map[string]string{"severity": "warning"}},
+			},
+		},
+		{
+			name: "routes are not moved past a route that may match the same alerts",
+			routes: []*alertmanager.Route{
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^a$"}},
+				{Receiver: "null", Match: map[string]string{"alertname": "KubeJobFailed"}},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^b$"}},
+			},
+			expected: []*alertmanager.Route{
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^a$"}},
+				{Receiver: "null", Match: map[string]string{"alertname": "KubeJobFailed"}},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^b$"}},
+			},
+		},
+		{
+			name: "routes that continue or have child routes are left alone",
+			routes: []*alertmanager.Route{
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^a$"}, Continue: true},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^b$"}, Continue: true},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^c$"}, Routes: []*alertmanager.Route{{Receiver: "null"}}},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^d$"}},
+			},
+			expected: []*alertmanager.Route{
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^a$"}, Continue: true},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^b$"}, Continue: true},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^c$"}, Routes: []*alertmanager.Route{{Receiver: "null"}}},
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^d$"}},
+			},
+		},
+		{
+			name: "duplicate routes are dropped",
+			routes: []*alertmanager.Route{
+				{Receiver: "null", Match: map[string]string{"severity": "info"}},
+				{Receiver: "null", Match: map[string]string{"severity": "info"}},
+			},
+			expected: []*alertmanager.Route{
+				{Receiver: "null", Match: map[string]string{"severity": "info"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compacted := Compact(&alertmanager.Route{Receiver: "default", Routes: tt.routes})
+			if !reflect.DeepEqual(tt.expected, compacted.Routes) {
+				t.Errorf("Expected routes %v, got %v", tt.expected, compacted.Routes)
+			}
+		})
+	}
+}
+
+// compactionTestConfig renders a config routing the given number of managed namespaces, along
+// with hosted control planes and labelled namespaces, to every integration. It returns the
+// config and the namespaces alerts are generated from.
+func compactionTestConfig(namespaces int) (*alertmanager.Config, []string) {
+	var namespaceNames []string
+	managed := []string{alertmanager.PDRegexKube, alertmanager.PDRegexOS, alertmanager.PDRegexLP}
+	for i := range namespaces {
+		name := fmt.Sprintf("managed-ns-%d", i)
+		namespaceNames = append(namespaceNames, name)
+		managed = append(managed, "^"+name+"$")
+	}
+	namespaceNames = append(namespaceNames, "ocm-production-abc-hcp", "ocm-production-def-hcp", "team-a-operator", "team-b-operator",
+		"openshift-monitoring", "redhat-rhoam", "kube-system", "customer-app")
+
+	cfg, _, err := render.Render(render.Inputs{
+		Cluster: render.Cluster{ID: "cluster-id", Region: "us-east-1", Proxy: "https://proxy"},
+		Profile: render.Profile{Management: true},
+		Integrations: []render.Integration{
+			render.DeadMansSnitch{URL: "https://dms/snitch"},
+			render.OCMAgent{URL: "https://ocm-agent"},
+			render.Automation{Routes: []render.AutomationRoute{
+				{Name: "cad", Receiver: "cad-pagerduty", MatchLabels: map[string]string{"route_to_cad": "true"}, PagerDutyRoutingKey: "cadkey"},
+			}},
+			render.PagerDuty{RoutingKey: "pdkey"},
+			render.GoAlert{LowURL: "https://goalert/low", HighURL: "https://goalert/high"},
+			render.GoAlertHeartbeat{URL: "https://goalert/heartbeat"},
+		},
+		Namespaces: render.Namespaces{
+			Managed: managed,
+			HostedControlPlanes: []render.HostedControlPlane{
+				{Namespace: "ocm-production-abc-hcp", ClusterID: "abc"},
+				{Namespace: "ocm-production-def-hcp", ClusterID: "def"},
+			},
+			Labelled: []render.Namespace{
+				{Name: "team-a-operator", MinSeverity: "error"},
+				{Name: "team-b-operator", Team: "team-b"},
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return cfg, namespaceNames
+}
+
+// countRoutes counts the routes in the tree below route.
+func countRoutes(route *alertmanager.Route) int {
+	count := len(route.Routes)
+	for _, child := range route.Routes {
+		count += countRoutes(child)
+	}
+	return count
+}
+
+// routingCorpus generates label sets from the values the routing tree matches on, so every
+// route is exercised: alerts from each namespace at each severity, and random combinations.
+func routingCorpus(route *alertmanager.Route, namespaces []string, random int) []map[string]string {
+	values := map[string][]string{
+		"namespace":          namespaces,
+		"exported_namespace": namespaces,
+		"severity":           {"critical", "error", "warning", "info", "none"},
+		"alertname":          {"PodCrashLooping", "ClusterLoggingSRE"},
+		"name":               {"cluster-master-0", "monitoring"},
+		"mountpoint":         {"/var/lib/ibmc-s3fs/bucket"},
+	}
+	var collect func(*alertmanager.Route)
+	collect = func(route *alertmanager.Route) {
+		for label, value := range route.Match {
+			if !slices.Contains(values[label], value) {
+				values[label] = append(values[label], value)
+			}
+		}
+		for _, child := range route.Routes {
+			collect(child)
+		}
+	}
+	collect(route)
+	labels := slices.Sorted(maps.Keys(values))
+
+	var corpus []map[string]string
+	for _, namespace := range namespaces {
+		for _, severity := range values["severity"] {
+			corpus = append(corpus,
+				map[string]string{"alertname": "PodCrashLooping", "namespace": namespace, "severity": severity, "prometheus": "openshift-monitoring/k8s"},
+				map[string]string{"alertname": "PodCrashLooping", "namespace": "openshift-monitoring", "exported_namespace": namespace, "severity": severity, "prometheus": "openshift-monitoring/k8s"},
+			)
+		}
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range random {
+		labelSet := map[string]string{}
+		for _, label := range labels {
+			// leave each label out about a third of the time
+			if i := rng.IntN(len(values[label]) + len(values[label])/2 + 1); i < len(values[label]) {
+				labelSet[label] = values[label][i]
+			}
+		}
+		corpus = append(corpus, labelSet)
+	}
+	return corpus
+}
+
+// withoutRoute drops the route identity from matches, which compaction changes by design.
+func withoutRoute(matches []Match) []Match {
+	for i := range matches {
+		matches[i].Route = ""
+	}
+	return matches
+}
+
+// TestCompact_PreservesRouting verifies that a compacted rendered config routes every alert
+// in a generated corpus exactly as the original config does.
+func TestCompact_PreservesRouting(t *testing.T) {
+	cfg, namespaces := compactionTestConfig(100)
+	compactedCfg := *cfg
+	compactedCfg.Route = Compact(cfg.Route)
+
+	if before, after := countRoutes(cfg.Route), countRoutes(compactedCfg.Route); after*4 > before {
+		t.Errorf("Expected compaction to remove most routes, went from %d to %d", before, after)
+	}
+
+	original, err := NewExplainer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the config: %v", err)
+	}
+	compacted, err := NewExplainer(&compactedCfg)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the compacted config: %v", err)
+	}
+
+	for _, labels := range routingCorpus(cfg.Route, namespaces, 20000) {
+		want, got := withoutRoute(original.Explain(labels)), withoutRoute(compacted.Explain(labels))
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("Expected %v to be routed to %v, got %v", labels, want, got)
+		}
+	}
+}
+
+// BenchmarkCompact compares the size of a rendered config and the time Alertmanager takes
+// to route an alert from the last managed namespace, with and without compaction.
+func BenchmarkCompact(b *testing.B) {
+	for _, namespaces := range []int{10, 100, 500} {
+		cfg, _ := compactionTestConfig(namespaces)
+		compactedCfg := *cfg
+		compactedCfg.Route = Compact(cfg.Route)
+		labels := map[string]string{
+			"alertname":  "PodCrashLooping",
+			"namespace":  fmt.Sprintf("managed-ns-%d", namespaces-1),
+			"severity":   "critical",
+			"prometheus": "openshift-monitoring/k8s",
+		}
+
+		for _, variant := range []struct {
+			name   string
+			config *alertmanager.Config
+		}{
+			{"original", cfg},
+			{"compacted", &compactedCfg},
+		} {
+			b.Run(fmt.Sprintf("namespaces=%d/%s", namespaces, variant.name), func(b *testing.B) {
+				cfgBytes, err := yaml.Marshal(variant.config)
+				if err != nil {
+					b.Fatalf("Unexpected error marshalling the config: %v", err)
+				}
+				explainer, err := NewExplainer(variant.config)
+				if err != nil {
+					b.Fatalf("Unexpected error loading the config: %v", err)
+				}
+				for b.Loop() {
+					explainer.Receivers(labels)
+				}
+				b.ReportMetric(float64(len(cfgBytes)), "config-bytes")
+				b.ReportMetric(float64(countRoutes(variant.config.Route)), "routes")
+			})
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package routing
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	amconfig "github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+	yaml "gopkg.in/yaml.v2"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+// Match is a route an alert was routed to, and how it is notified there.
+type Match struct {
+	// Route identifies the route by the matchers on the path to it from the root.
+	Route          string
+	Receiver       string
+	GroupBy        []string
+	GroupWait      time.Duration
+	GroupInterval  time.Duration
+	RepeatInterval time.Duration
+	// Default is true when the alert matched none of the route's child routes and was
+	// routed to the route's own receiver.
+	Default bool
+}
+
+// Explainer explains where alerts are routed by a config, using Alertmanager's own
+// routing, so the answer is the one Alertmanager would give.
+type Explainer struct {
+	root *dispatch.Route
+}
+
+// NewExplainer loads cfg as Alertmanager would. It returns an error if Alertmanager
+// would reject the config.
+func NewExplainer(cfg *alertmanager.Config) (*Explainer, error) {
+	cfgBytes, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal alertmanager config: %w", err)
+	}
+	loaded, err := amconfig.Load(string(cfgBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alertmanager config: %w", err)
+	}
+	return &Explainer{root: dispatch.NewRoute(loaded.Route, nil)}, nil
+}
+
+// Explain returns the routes an alert with the given labels is routed to, in order.
+func (e *Explainer) Explain(labels map[string]string) []Match {
+	var matches []Match
+	for _, route := range e.root.Match(labelSet(labels)) {
+		matches = append(matches, e.match(route))
+	}
+	return matches
+}
+
+// Leaves returns the routes without child routes, in config order.
+func (e *Explainer) Leaves() []Match {
+	var leaves []Match
+	e.root.Walk(func(route *dispatch.Route) {
+		if route != e.root && len(route.Routes) == 0 {
+			leaves = append(leaves, e.match(route))
+		}
+	})
+	return leaves
+}
+
+// Receivers returns the receivers an alert with the given labels is sent to, in order.
+func (e *Explainer) Receivers(labels map[string]string) []string {
+	var receivers []string
+	for _, route := range e.root.Match(labelSet(labels)) {
+		receivers = append(receivers, route.RouteOpts.Receiver)
+	}
+	return receivers
+}
+
+// NamespaceReceivers returns the receivers of the routes that select alerts by being in
+// namespace, and of the routes below them, in config order without duplicates.
+func (e *Explainer) NamespaceReceivers(namespace string) []string {
+	var receivers []string
+	e.root.Walk(func(route *dispatch.Route) {
+		if !selectsNamespace(route, namespace) {
+			return
+		}
+		route.Walk(func(child *dispatch.Route) {
+			if !slices.Contains(receivers, child.RouteOpts.Receiver) {
+				receivers = append(receivers, child.RouteOpts.Receiver)
+			}
+		})
+	})
+	return receivers
+}
+
+// selectsNamespace is true if route has a matcher on the namespace label that namespace
+// equals or matches. Negative matchers select every other namespace, so they are ignored.
+func selectsNamespace(route *dispatch.Route, namespace string) bool {
+	for _, matcher := range route.Matchers {
+		if matcher.Name == "namespace" && (matcher.Type == labels.MatchEqual || matcher.Type == labels.MatchRegexp) && matcher.Matches(namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Explainer) match(route *dispatch.Route) Match {
+	groupBy := make([]string, 0, len(route.RouteOpts.GroupBy))
+	for label := range route.RouteOpts.GroupBy {
+		groupBy = append(groupBy, string(label))
+	}
+	slices.Sort(groupBy)
+	return Match{
+		Route:          route.Key(),
+		Receiver:       route.RouteOpts.Receiver,
+		GroupBy:        groupBy,
+		GroupWait:      route.RouteOpts.GroupWait,
+		GroupInterval:  route.RouteOpts.GroupInterval,
+		RepeatInterval: route.RouteOpts.RepeatInterval,
+		Default:        route == e.root || len(route.Routes) > 0,
+	}
+}
+
+func labelSet(labels map[string]string) model.LabelSet {
+	lset := make(model.LabelSet, len(labels))
+	for name, value := range labels {
+		lset[model.LabelName(name)] = model.LabelValue(value)
+	}
+	return lset
+}
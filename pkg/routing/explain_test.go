@@ -0,0 +1,132 @@
+package routing
+
+import (
+	"reflect"
+	"testing"
+
+	alertmanager "github.com/openshift/configure-alertmanager-operator/pkg/types"
+)
+
+func explainTestConfig() *alertmanager.Config {
+	return &alertmanager.Config{
+		Route: &alertmanager.Route{
+			Receiver: "null",
+			Routes: []*alertmanager.Route{
+				{Receiver: "null", Match: map[string]string{"alertname": "Silenced"}},
+				{
+					Receiver: "null",
+					Match:    map[string]string{"prometheus": "openshift-monitoring/k8s"},
+					Routes: []*alertmanager.Route{
+						{Receiver: "pagerduty", Match: map[string]string{"severity": "critical"}},
+					},
+				},
+			},
+		},
+		Receivers: []*alertmanager.Receiver{{Name: "null"}, {Name: "pagerduty"}},
+	}
+}
+
+func TestExplainer_Default(t *testing.T) {
+	explainer, err := NewExplainer(explainTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		receiver string
+		expected bool
+	}{
+		{
+			name:     "alerts routed to a leaf are not defaulted",
+			labels:   map[string]string{"alertname": "Silenced"},
+			receiver: "null",
+			expected: false,
+		},
+		{
+			name:     "alerts matching no route fall to the root",
+			labels:   map[string]string{"alertname": "Other"},
+			receiver: "null",
+			expected: true,
+		},
+		{
+			name:     "alerts matching no child route fall to their parent",
+			labels:   map[string]string{"alertname": "Other", "prometheus": "openshift-monitoring/k8s", "severity": "warning"},
+			receiver: "null",
+			expected: true,
+		},
+		{
+			name:     "alerts matching a child route are not defaulted",
+			labels:   map[string]string{"alertname": "Other", "prometheus": "openshift-monitoring/k8s", "severity": "critical"},
+			receiver: "pagerduty",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := explainer.Explain(tt.labels)
+			if len(matches) != 1 {
+				t.Fatalf("Expected one match, got %v", matches)
+			}
+			if matches[0].Receiver != tt.receiver || matches[0].Default != tt.expected {
+				t.Errorf("Expected receiver %s with default %t, got %s with default %t", tt.receiver, tt.expected, matches[0].Receiver, matches[0].Default)
+			}
+		})
+	}
+}
+
+func TestExplainer_Leaves(t *testing.T) {
+	explainer, err := NewExplainer(explainTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var receivers []string
+	for _, leaf := range explainer.Leaves() {
+		if leaf.Default {
+			t.Errorf("Expected leaf %s not to be a default route", leaf.Route)
+		}
+		receivers = append(receivers, leaf.Receiver)
+	}
+	if expected := []string{"null", "pagerduty"}; !reflect.DeepEqual(receivers, expected) {
+		t.Errorf("Expected leaves routed to %v, got %v", expected, receivers)
+	}
+}
+
+func TestExplainer_NamespaceReceivers(t *testing.T) {
+	cfg := explainTestConfig()
+	cfg.Route.Routes = append(cfg.Route.Routes,
+		&alertmanager.Route{Receiver: "null", Match: map[string]string{"namespace": "openshift-silenced"}},
+		&alertmanager.Route{Receiver: "null", Matchers: []string{`namespace!~"^openshift-.*"`}},
+		&alertmanager.Route{
+			Receiver: "null",
+			Match:    map[string]string{"prometheus": "openshift-monitoring/k8s"},
+			Routes: []*alertmanager.Route{
+				{Receiver: "pagerduty", MatchRE: map[string]string{"namespace": "^openshift-.*"}},
+			},
+		},
+	)
+	explainer, err := NewExplainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		namespace string
+		expected  []string
+	}{
+		{namespace: "openshift-etcd", expected: []string{"pagerduty"}},
+		{namespace: "openshift-silenced", expected: []string{"null", "pagerduty"}},
+		{namespace: "customer", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			if got := explainer.NamespaceReceivers(tt.namespace); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected receivers %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
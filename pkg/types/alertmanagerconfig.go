@@ -102,6 +102,7 @@ type Route struct {
 
 	Match    map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
 	MatchRE  map[string]string `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+	Matchers []string          `yaml:"matchers,omitempty" json:"matchers,omitempty"`
 	Continue bool              `yaml:"continue,omitempty" json:"continue,omitempty"`
 	Routes   []*Route          `yaml:"routes,omitempty" json:"routes,omitempty"`
 
@@ -172,6 +173,27 @@ type ManagementClusterConfig struct {
 	AdditionalNamespaces []Namespace `yaml:"AdditionalNamespaces,omitempty" json:"AdditionalNamespaces,omitempty"`
 }
 
+// Namespace is an entry in a namespace ConfigMap. Name is a literal namespace name
+// unless Regex is set, in which case it is a regular expression matching whole names.
+// Team, if set, is the team owning the namespaces, whose PagerDuty service is paged.
 type Namespace struct {
-	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Regex bool   `yaml:"regex,omitempty" json:"regex,omitempty"`
+	Team  string `yaml:"team,omitempty" json:"team,omitempty"`
+}
+
+// ConfigOverrides is a partial Alertmanager config that is merged into the generated config.
+type ConfigOverrides struct {
+	Receivers    []*Receiver    `yaml:"receivers,omitempty" json:"receivers,omitempty"`
+	Routes       RouteOverrides `yaml:"routes,omitempty" json:"routes,omitempty"`
+	InhibitRules []*InhibitRule `yaml:"inhibit_rules,omitempty" json:"inhibit_rules,omitempty"`
+	Templates    []string       `yaml:"templates,omitempty" json:"templates,omitempty"`
+}
+
+// RouteOverrides are routes added to the top level of the generated route tree.
+type RouteOverrides struct {
+	// Prepend routes are evaluated before the generated routes.
+	Prepend []*Route `yaml:"prepend,omitempty" json:"prepend,omitempty"`
+	// Append routes are evaluated after the generated routes.
+	Append []*Route `yaml:"append,omitempty" json:"append,omitempty"`
 }